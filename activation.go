@@ -0,0 +1,214 @@
+package licenseedict
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK/keyformat"
+)
+
+// ActivationStep is a stage of Client.Activate.
+type ActivationStep int
+
+const (
+	// ActivationCheckKey checks the license key format locally.
+	ActivationCheckKey ActivationStep = iota
+	// ActivationRequestToken exchanges the license key for a signed token.
+	ActivationRequestToken
+	// ActivationVerifyToken verifies, caches and installs the token.
+	ActivationVerifyToken
+	// ActivationComplete means the license is active.
+	ActivationComplete
+)
+
+var activationStepNames = [...]string{
+	ActivationCheckKey:     "check_key",
+	ActivationRequestToken: "request_token",
+	ActivationVerifyToken:  "verify_token",
+	ActivationComplete:     "complete",
+}
+
+// String returns the snake_case name of the step.
+func (s ActivationStep) String() string {
+	if s >= 0 && int(s) < len(activationStepNames) {
+		return activationStepNames[s]
+	}
+	return fmt.Sprintf("activation_step(%d)", int(s))
+}
+
+// Activation is the state of a first-run activation with a license key. It
+// records how far activation got, so an activation interrupted by a network
+// failure or an application restart can be resumed by passing the same
+// Activation to Client.Activate again. It is JSON-serializable for
+// persisting between runs; OnProgress is not persisted.
+//
+// Once the server has issued a token it is kept in SignedToken, and resuming
+// does not ask the server again. ID is sent with the request so the server
+// can recognize a retried activation rather than counting a second one.
+type Activation struct {
+	ID          string         `json:"id"`
+	LicenseKey  string         `json:"license_key"`
+	Step        ActivationStep `json:"step"`
+	SignedToken string         `json:"signed_token,omitempty"`
+	StartedAt   time.Time      `json:"started_at"`
+
+	// OnProgress, if set, is called as each step starts, for driving a
+	// progress indicator. Progress is also reported as
+	// EventActivationProgress events.
+	OnProgress func(ActivationStep) `json:"-"`
+}
+
+// NewActivation starts an activation for licenseKey as entered by the user.
+// The key is normalized with NormalizeLicenseKey; if it fails the format
+// check, an *ActivationError wrapping a *KeyFormatError is returned so the
+// input field can be corrected before anything is sent to the server.
+func NewActivation(licenseKey string) (*Activation, error) {
+	a := &Activation{
+		ID:         newRequestID(),
+		LicenseKey: NormalizeLicenseKey(licenseKey),
+		StartedAt:  time.Now(),
+	}
+	if err := ValidateLicenseKey(a.LicenseKey); err != nil {
+		return nil, newActivationError(ActivationCheckKey, err, apiResponse{})
+	}
+	a.Step = ActivationRequestToken
+	return a, nil
+}
+
+// Done reports whether the activation completed.
+func (a *Activation) Done() bool {
+	return a.Step == ActivationComplete
+}
+
+// ActivationError is returned by NewActivation and Client.Activate. Prompt
+// is a short sentence suitable for showing to the user, and Retryable
+// reports whether calling Activate again with the same Activation may
+// succeed without different input, for example after a network failure.
+type ActivationError struct {
+	Step      ActivationStep
+	Prompt    string
+	Retryable bool
+	Err       error
+}
+
+func (e *ActivationError) Error() string {
+	return fmt.Sprintf("licenseedict: activation failed at %s: %v", e.Step, e.Err)
+}
+
+func (e *ActivationError) Unwrap() error {
+	return e.Err
+}
+
+// newActivationError classifies err from step into a user-facing prompt.
+// res is the server response for the request step, if any.
+func newActivationError(step ActivationStep, err error, res apiResponse) *ActivationError {
+	e := &ActivationError{Step: step, Err: err}
+
+	var keyErr *KeyFormatError
+	var vErr *ValidationError
+	switch {
+	case errors.As(err, &keyErr):
+		if keyErr.Group > 0 {
+			e.Prompt = fmt.Sprintf("The license key looks mistyped in group %d. Check it and try again.", keyErr.Group)
+		} else {
+			e.Prompt = fmt.Sprintf("Enter the %d-character license key from your purchase confirmation.", keyformat.Length)
+		}
+	case errors.Is(err, ErrNoServerURL), errors.Is(err, ErrNetworkDisabled):
+		e.Prompt = "No license server is configured for activation."
+	case errors.Is(err, ErrClientClosed), errors.Is(err, context.Canceled):
+		e.Prompt = "Activation was cancelled."
+		e.Retryable = true
+	case errors.Is(err, ErrVirtualizedEnvironment):
+		e.Prompt = "This license cannot be activated in a virtual machine or container."
+	case step == ActivationRequestToken && (res.StatusCode == 0 || res.StatusCode >= statusInternalServerError || res.StatusCode == statusTooManyRequests):
+		e.Prompt = "Could not reach the license server. Check your internet connection and try again."
+		e.Retryable = true
+	case step == ActivationRequestToken:
+		e.Prompt = "The license server did not accept this license key. Check the key or contact support."
+	case errors.Is(err, ErrLicenseExpired):
+		e.Prompt = "This license has expired."
+	case errors.As(err, &vErr) && vErr.Code == LicenseNotValidBefore:
+		e.Prompt = "This license is not valid yet. Check that the computer's date and time are correct."
+		e.Retryable = true
+	case errors.Is(err, ErrLicenseRevoked):
+		e.Prompt = "This license has been revoked. Contact support."
+	case errors.Is(err, ErrProductMismatch), errors.Is(err, ErrAppMismatch):
+		e.Prompt = "This license key is for a different product."
+	default:
+		e.Prompt = "The license could not be activated. Contact support if the problem persists."
+	}
+	return e
+}
+
+// Activate runs or resumes activation a, starting from a.Step, and returns
+// the activated license. a is updated as steps complete; on failure it is
+// left at the failed step and an *ActivationError is returned. Persist a if
+// the application may exit before retrying.
+//
+// A token the server issued but the client could not install (for example
+// because the system clock is wrong) is kept, so retrying does not use up
+// another activation. An activation resumed past ActivationRequestToken
+// without a token fails and is moved back to that step.
+func (c *Client) Activate(ctx context.Context, a *Activation) (*License, error) {
+	if c.closed.Load() {
+		return nil, newActivationError(a.Step, ErrClientClosed, apiResponse{})
+	}
+
+	if a.Step <= ActivationCheckKey {
+		c.activationProgress(a, ActivationCheckKey)
+		a.LicenseKey = NormalizeLicenseKey(a.LicenseKey)
+		if err := ValidateLicenseKey(a.LicenseKey); err != nil {
+			return nil, newActivationError(ActivationCheckKey, err, apiResponse{})
+		}
+		if a.ID == "" {
+			a.ID = newRequestID()
+		}
+		a.Step = ActivationRequestToken
+	}
+
+	if a.Step == ActivationRequestToken {
+		c.activationProgress(a, ActivationRequestToken)
+		if a.SignedToken == "" {
+			result, res, err := c.requestTokenByKey(ctx, a.LicenseKey, a.ID)
+			if err != nil {
+				if ctx.Err() != nil {
+					err = ctx.Err()
+				}
+				return nil, newActivationError(ActivationRequestToken, err, res)
+			}
+			a.SignedToken = result.SignedToken
+		}
+		a.Step = ActivationVerifyToken
+	}
+
+	c.activationProgress(a, ActivationVerifyToken)
+	if a.SignedToken == "" {
+		// A persisted activation past ActivationRequestToken, edited or
+		// saved without its token. Requesting one again lets a retry
+		// succeed.
+		err := &ValidationError{Code: LicenseDecodeError, Message: fmt.Sprintf("activation at step %s has no token", a.Step)}
+		a.Step = ActivationRequestToken
+		e := newActivationError(ActivationVerifyToken, err, apiResponse{})
+		e.Retryable = true
+		return nil, e
+	}
+	license, err := c.SetToken(a.SignedToken)
+	if err != nil {
+		if a.Step == ActivationComplete {
+			a.Step = ActivationVerifyToken
+		}
+		return nil, newActivationError(ActivationVerifyToken, err, apiResponse{})
+	}
+	a.Step = ActivationComplete
+	c.activationProgress(a, ActivationComplete)
+	return license, nil
+}
+
+func (c *Client) activationProgress(a *Activation, step ActivationStep) {
+	if a.OnProgress != nil {
+		c.safeCall("activation progress", func() { a.OnProgress(step) })
+	}
+	c.emitEvent(Event{Type: EventActivationProgress, Message: "activation " + step.String(), Data: step})
+}
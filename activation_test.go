@@ -0,0 +1,34 @@
+package licenseedict_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
+)
+
+func TestActivateWithoutToken(t *testing.T) {
+	r := newRaceIssuer(t)
+	c := r.client(t, "")
+	defer c.Close()
+	drain(c)
+
+	for _, step := range []licenseedict.ActivationStep{licenseedict.ActivationVerifyToken, licenseedict.ActivationComplete} {
+		a := &licenseedict.Activation{ID: "act-1", LicenseKey: "KEY", Step: step}
+		_, err := c.Activate(context.Background(), a)
+
+		var actErr *licenseedict.ActivationError
+		if !errors.As(err, &actErr) || actErr.Step != licenseedict.ActivationVerifyToken || !actErr.Retryable {
+			t.Fatalf("resume at %s: err = %#v, want a retryable ActivationError at verify_token", step, err)
+		}
+		var vErr *licenseedict.ValidationError
+		if !errors.As(err, &vErr) || !strings.Contains(vErr.Message, step.String()) {
+			t.Fatalf("resume at %s: err = %v, want a ValidationError naming the step", step, err)
+		}
+		if a.Step != licenseedict.ActivationRequestToken {
+			t.Fatalf("resume at %s: left at %s, want request_token", step, a.Step)
+		}
+	}
+}
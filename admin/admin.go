@@ -0,0 +1,361 @@
+// Package admin performs bulk administration of the licenses kept in a
+// sqlstore.Store: importing tokens from CSV or JSON, exporting them, and
+// revoking or extending the licenses of many tenants at once. It is meant
+// for operators migrating customers between systems or handling a support
+// escalation, where scripting one call per license is impractical.
+//
+// Every bulk operation works through its entries in batches, reports
+// progress after each batch, and stops between batches once ctx is done.
+// Failures for individual entries are collected in the Report instead of
+// aborting the run. With BulkOptions.DryRun set, the entries are checked
+// and the Report says what would change, but nothing is written:
+//
+//	entries, err := admin.ReadEntries(file, admin.FormatCSV)
+//	a := admin.New(store, validator)
+//	report, err := a.Import(ctx, entries, admin.BulkOptions{
+//	    DryRun:   true,
+//	    Progress: func(p admin.Progress) { log.Printf("%d/%d", p.Done, p.Total) },
+//	})
+//
+// With WithServer, Import, Revoke and Extend apply each change to the
+// license server's admin API first and write it to the store only once the
+// server has accepted it, so a migration leaves both in step and a failed
+// entry can simply be retried. Without it, only the store is changed; in
+// particular, revocations then do not reach clients that validate against
+// the server. Catalog, which lists the products defined on the server,
+// also requires WithServer.
+package admin
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK/issuer"
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK/sqlstore"
+)
+
+const defaultBatchSize = 100
+
+var (
+	// ErrNoIssuer is returned by Extend when the Admin was created without
+	// WithIssuer.
+	ErrNoIssuer = errors.New("admin: extending licenses requires an issuer")
+	// ErrInvalidEntry is wrapped by the failures of entries that lack a
+	// field the operation needs.
+	ErrInvalidEntry = errors.New("admin: invalid entry")
+	// ErrNoExpiry is wrapped by Extend failures for licenses that do not
+	// expire.
+	ErrNoExpiry = errors.New("admin: license does not expire")
+)
+
+// Entry identifies a license in a bulk operation. Import needs TenantID and
+// Token; Revoke and Extend need TenantID and apply to every license of the
+// tenant unless LicenseID is set.
+type Entry struct {
+	TenantID  string `json:"tenant_id"`
+	LicenseID string `json:"license_id,omitempty"`
+	Token     string `json:"token,omitempty"`
+}
+
+// BulkOptions configures a bulk operation.
+type BulkOptions struct {
+	// BatchSize is the number of entries processed between progress reports
+	// and cancellation checks. The default is 100.
+	BatchSize int
+	// DryRun checks every entry and reports what would change without
+	// writing to the store or contacting the server.
+	DryRun bool
+	// Progress, if set, is called after each batch.
+	Progress func(Progress)
+}
+
+// Progress reports how far a bulk operation has got.
+type Progress struct {
+	// Done is the number of entries processed so far, out of Total.
+	Done  int
+	Total int
+	// Failed is the number of processed entries that failed.
+	Failed int
+}
+
+// Report summarizes a bulk operation.
+type Report struct {
+	DryRun bool
+	Total  int
+	// Processed is the number of entries worked through; it is less than
+	// Total only if ctx was done before the run finished.
+	Processed int
+	// Licenses is the number of licenses that were, or with DryRun would
+	// have been, changed.
+	Licenses int
+	Failures []Failure
+}
+
+// Failure is an entry that could not be processed.
+type Failure struct {
+	// Index is the position of the entry in the input.
+	Index int
+	Entry Entry
+	Err   error
+}
+
+func (f Failure) Error() string {
+	return fmt.Sprintf("entry %d (tenant %q): %v", f.Index, f.Entry.TenantID, f.Err)
+}
+
+func (f Failure) Unwrap() error {
+	return f.Err
+}
+
+// Admin runs bulk operations on a Store. It is safe for concurrent use.
+type Admin struct {
+	store     *sqlstore.Store
+	validator *licenseedict.Validator
+	issuer    *issuer.Issuer
+	now       func() time.Time
+
+	server     *server
+	httpClient *http.Client
+}
+
+// Option configures an Admin.
+type Option func(*Admin)
+
+// WithIssuer sets the issuer that re-signs licenses for Extend. Its key must
+// match the public key of the validator.
+func WithIssuer(iss *issuer.Issuer) Option {
+	return func(a *Admin) {
+		a.issuer = iss
+	}
+}
+
+// WithClock sets the clock Extend counts from for expired licenses. It
+// defaults to time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(a *Admin) {
+		a.now = now
+	}
+}
+
+// New returns an Admin for store. v checks tokens on a dry run and should be
+// the validator the store was created with.
+func New(store *sqlstore.Store, v *licenseedict.Validator, opts ...Option) *Admin {
+	a := &Admin{store: store, validator: v, now: time.Now}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.server != nil {
+		a.server.client = a.httpClient
+		if a.server.client == nil {
+			a.server.client = &http.Client{Timeout: defaultTimeout}
+		}
+	}
+	return a
+}
+
+// Import stores the token of every entry for its tenant, as Store.Put does,
+// and with WithServer imports it into the server first. The tokens are
+// verified before anything is written; as with Put, tokens that are expired
+// or not valid yet are accepted, and tokens whose signature cannot be
+// verified, that have no license ID or that belong to another tenant fail.
+func (a *Admin) Import(ctx context.Context, entries []Entry, opts BulkOptions) (*Report, error) {
+	return a.run(ctx, entries, opts, func(e Entry) (int, error) {
+		if e.TenantID == "" || e.Token == "" {
+			return 0, fmt.Errorf("%w: tenant_id and token are required", ErrInvalidEntry)
+		}
+		if err := a.checkImport(e); err != nil {
+			return 0, err
+		}
+		if opts.DryRun {
+			return 1, nil
+		}
+		if a.server != nil {
+			if err := a.server.importLicense(ctx, e.TenantID, e.Token); err != nil {
+				return 0, err
+			}
+		}
+		if _, err := a.store.Put(ctx, e.TenantID, e.Token); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	})
+}
+
+// checkImport applies the checks of Store.Put to the token of e.
+func (a *Admin) checkImport(e Entry) error {
+	license, err := a.validator.Validate(e.Token)
+	switch {
+	case license == nil:
+		return err
+	case license.LicenseID == "":
+		return sqlstore.ErrNoLicenseID
+	case license.TenantID != "" && license.TenantID != e.TenantID:
+		return &licenseedict.ValidationError{
+			Code:    licenseedict.TenantMismatch,
+			Message: fmt.Sprintf("license is for tenant %q", license.TenantID),
+		}
+	}
+	return nil
+}
+
+// Revoke revokes the licenses of every entry with reason, as Store.Revoke
+// does. Licenses that are already revoked are left alone and not counted.
+// With WithServer, each license is revoked on the server before the store
+// records it, so a license whose server revocation failed is retried by the
+// next run.
+func (a *Admin) Revoke(ctx context.Context, entries []Entry, reason string, opts BulkOptions) (*Report, error) {
+	return a.run(ctx, entries, opts, func(e Entry) (int, error) {
+		recs, err := a.records(ctx, e)
+		if err != nil {
+			return 0, err
+		}
+		n := 0
+		for _, rec := range recs {
+			if rec.Revoked {
+				continue
+			}
+			if !opts.DryRun {
+				if a.server != nil {
+					if err := a.server.revoke(ctx, rec.TenantID, rec.LicenseID, reason); err != nil {
+						return n, fmt.Errorf("license %q: %w", rec.LicenseID, err)
+					}
+				}
+				if err := a.store.Revoke(ctx, rec.TenantID, rec.LicenseID, reason); err != nil && !errors.Is(err, sqlstore.ErrNotFound) {
+					return n, err
+				}
+			}
+			n++
+		}
+		return n, nil
+	})
+}
+
+// Extend moves the expiry of the licenses of every entry by the given
+// duration, counting from the current expiry or, for licenses that have
+// already expired, from now. The licenses are re-signed with the issuer set
+// by WithIssuer, keeping all other claims, and the new tokens are stored;
+// with WithServer, they are imported into the server first. Revoked licenses, licenses that do not expire and delegated licenses,
+// which only their reseller can re-sign, fail.
+func (a *Admin) Extend(ctx context.Context, entries []Entry, by time.Duration, opts BulkOptions) (*Report, error) {
+	if a.issuer == nil {
+		return nil, ErrNoIssuer
+	}
+	return a.run(ctx, entries, opts, func(e Entry) (int, error) {
+		recs, err := a.records(ctx, e)
+		if err != nil {
+			return 0, err
+		}
+		n := 0
+		for _, rec := range recs {
+			token, err := a.extend(rec, by)
+			if err != nil {
+				return n, fmt.Errorf("license %q: %w", rec.LicenseID, err)
+			}
+			if opts.DryRun {
+				if license, err := a.validator.Validate(token); license == nil {
+					return n, fmt.Errorf("license %q: %w", rec.LicenseID, err)
+				}
+				n++
+				continue
+			}
+			if a.server != nil {
+				if err := a.server.importLicense(ctx, rec.TenantID, token); err != nil {
+					return n, fmt.Errorf("license %q: %w", rec.LicenseID, err)
+				}
+			}
+			if _, err := a.store.Put(ctx, rec.TenantID, token); err != nil {
+				return n, err
+			}
+			n++
+		}
+		return n, nil
+	})
+}
+
+// extend returns rec's token re-signed with its expiry moved by by.
+func (a *Admin) extend(rec sqlstore.Record, by time.Duration) (string, error) {
+	switch {
+	case rec.Revoked:
+		return "", &licenseedict.ValidationError{Code: licenseedict.LicenseRevoked, Message: "license revoked: " + rec.RevocationReason}
+	case rec.ExpiresAt.IsZero():
+		return "", ErrNoExpiry
+	case strings.Contains(rec.Token, "."):
+		return "", errors.New("delegated licenses cannot be re-signed")
+	}
+	combined, err := base64.StdEncoding.DecodeString(rec.Token)
+	if err != nil || len(combined) <= ed25519.SignatureSize {
+		return "", errors.New("stored token is malformed")
+	}
+	var claims map[string]json.RawMessage
+	if err := json.Unmarshal(combined[ed25519.SignatureSize:], &claims); err != nil {
+		return "", fmt.Errorf("decode claims: %w", err)
+	}
+
+	from := rec.ExpiresAt
+	if now := a.now(); from.Before(now) {
+		from = now
+	}
+	expiry, err := json.Marshal(from.Add(by).UTC())
+	if err != nil {
+		return "", err
+	}
+	claims["expires_at"] = expiry
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	return a.issuer.SignPayload(payload), nil
+}
+
+// records returns the stored licenses e refers to.
+func (a *Admin) records(ctx context.Context, e Entry) ([]sqlstore.Record, error) {
+	if e.TenantID == "" {
+		return nil, fmt.Errorf("%w: tenant_id is required", ErrInvalidEntry)
+	}
+	recs, err := a.store.Get(ctx, e.TenantID)
+	if err != nil || e.LicenseID == "" {
+		return recs, err
+	}
+	for _, rec := range recs {
+		if rec.LicenseID == e.LicenseID {
+			return []sqlstore.Record{rec}, nil
+		}
+	}
+	return nil, sqlstore.ErrNotFound
+}
+
+// run applies fn to the entries in batches. fn returns the number of
+// licenses it changed.
+func (a *Admin) run(ctx context.Context, entries []Entry, opts BulkOptions, fn func(Entry) (int, error)) (*Report, error) {
+	batch := opts.BatchSize
+	if batch <= 0 {
+		batch = defaultBatchSize
+	}
+	report := &Report{DryRun: opts.DryRun, Total: len(entries)}
+	for start := 0; start < len(entries); start += batch {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		end := min(start+batch, len(entries))
+		for i := start; i < end; i++ {
+			n, err := fn(entries[i])
+			report.Licenses += n
+			if err != nil {
+				report.Failures = append(report.Failures, Failure{Index: i, Entry: entries[i], Err: err})
+			}
+		}
+		report.Processed = end
+		if opts.Progress != nil {
+			opts.Progress(Progress{Done: end, Total: len(entries), Failed: len(report.Failures)})
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK/sqlstore"
+)
+
+// Format is a file format for ReadEntries and Export.
+type Format int
+
+const (
+	// FormatCSV is comma-separated values with a header row naming the
+	// columns.
+	FormatCSV Format = iota
+	// FormatJSON is a JSON array of objects.
+	FormatJSON
+)
+
+// ReadEntries reads the entries of a bulk operation. CSV input must start
+// with a header row; the tenant_id, license_id and token columns are read
+// by name, in any order, and other columns are ignored, so the output of
+// Export can be read back. JSON input is an array of objects with the same
+// keys.
+func ReadEntries(r io.Reader, f Format) ([]Entry, error) {
+	switch f {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatJSON:
+		var entries []Entry
+		if err := json.NewDecoder(r).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("admin: read JSON entries: %w", err)
+		}
+		return entries, nil
+	}
+	return nil, fmt.Errorf("admin: unknown format %d", f)
+}
+
+func readCSV(r io.Reader) ([]Entry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("admin: read CSV header: %w", err)
+	}
+	cols := map[string]int{"tenant_id": -1, "license_id": -1, "token": -1}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := cols[name]; ok {
+			cols[name] = i
+		}
+	}
+	if cols["tenant_id"] < 0 {
+		return nil, errors.New("admin: CSV header has no tenant_id column")
+	}
+
+	field := func(row []string, name string) string {
+		if i := cols[name]; i >= 0 && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	var entries []Entry
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("admin: read CSV entries: %w", err)
+		}
+		entries = append(entries, Entry{
+			TenantID:  field(row, "tenant_id"),
+			LicenseID: field(row, "license_id"),
+			Token:     field(row, "token"),
+		})
+	}
+}
+
+// exportedRecord is the JSON form of a sqlstore.Record written by Export.
+type exportedRecord struct {
+	TenantID         string     `json:"tenant_id"`
+	LicenseID        string     `json:"license_id"`
+	ProductID        string     `json:"product_id"`
+	Token            string     `json:"token"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	Valid            bool       `json:"valid"`
+	LastError        string     `json:"last_error,omitempty"`
+	Revoked          bool       `json:"revoked"`
+	RevocationReason string     `json:"revocation_reason,omitempty"`
+}
+
+// Export writes every stored license to w, ordered by tenant and license
+// ID. Times are written in RFC 3339 format; licenses that do not expire
+// have an empty expires_at.
+func (a *Admin) Export(ctx context.Context, w io.Writer, f Format) error {
+	recs, err := a.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	switch f {
+	case FormatCSV:
+		return writeCSV(w, recs)
+	case FormatJSON:
+		out := make([]exportedRecord, len(recs))
+		for i, rec := range recs {
+			out[i] = exportedRecord{
+				TenantID:         rec.TenantID,
+				LicenseID:        rec.LicenseID,
+				ProductID:        rec.ProductID,
+				Token:            rec.Token,
+				Valid:            rec.Valid,
+				LastError:        rec.LastError,
+				Revoked:          rec.Revoked,
+				RevocationReason: rec.RevocationReason,
+			}
+			if !rec.ExpiresAt.IsZero() {
+				out[i].ExpiresAt = &recs[i].ExpiresAt
+			}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	return fmt.Errorf("admin: unknown format %d", f)
+}
+
+func writeCSV(w io.Writer, recs []sqlstore.Record) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"tenant_id", "license_id", "product_id", "token", "expires_at", "valid", "last_error", "revoked", "revocation_reason"})
+	for _, rec := range recs {
+		var expires string
+		if !rec.ExpiresAt.IsZero() {
+			expires = rec.ExpiresAt.UTC().Format(time.RFC3339)
+		}
+		cw.Write([]string{rec.TenantID, rec.LicenseID, rec.ProductID, rec.Token, expires,
+			strconv.FormatBool(rec.Valid), rec.LastError, strconv.FormatBool(rec.Revoked), rec.RevocationReason})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
+)
+
+// Admin API paths on the license server.
+const (
+	importPath  = "/admin/licenses/import"
+	revokePath  = "/admin/licenses/revoke"
+	catalogPath = "/admin/catalog"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// maxErrorBody limits how much of an error response is kept as the
+// ServerError message.
+const maxErrorBody = 4 << 10
+
+// ErrNoServer is returned by Catalog when the Admin was created without
+// WithServer.
+var ErrNoServer = errors.New("admin: no server configured; use WithServer")
+
+// ServerError is returned when the license server's admin API refuses a
+// request.
+type ServerError struct {
+	StatusCode int
+	// Message is the server's explanation, if it gave one.
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	msg := fmt.Sprintf("admin: server returned status %d", e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// WithServer makes Import, Revoke and Extend apply every change to the
+// admin API of the license server at url before writing it to the store.
+// credential is sent in the Authorization header: a bare credential as a
+// bearer token, a value that includes a scheme verbatim.
+func WithServer(url, credential string) Option {
+	return func(a *Admin) {
+		a.server = &server{url: strings.TrimRight(url, "/"), credential: credential}
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to reach the server set with
+// WithServer. The default has a 30 second timeout.
+func WithHTTPClient(c *http.Client) Option {
+	return func(a *Admin) {
+		a.httpClient = c
+	}
+}
+
+// Catalog fetches the definitions of every product on the server: their
+// plans, feature lists and display names, as Client.ProductInfo returns them
+// for a single licensed product.
+func (a *Admin) Catalog(ctx context.Context) ([]licenseedict.ProductInfo, error) {
+	if a.server == nil {
+		return nil, ErrNoServer
+	}
+	var resp struct {
+		Products []licenseedict.ProductInfo `json:"products"`
+	}
+	if err := a.server.do(ctx, http.MethodGet, catalogPath, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Products, nil
+}
+
+// server is a client for the license server's admin API.
+type server struct {
+	url        string
+	credential string
+	client     *http.Client
+}
+
+// importLicense stores token for tenantID on the server. A token for a
+// license the server already holds replaces it.
+func (s *server) importLicense(ctx context.Context, tenantID, token string) error {
+	return s.do(ctx, http.MethodPost, importPath, map[string]string{
+		"tenant_id": tenantID,
+		"token":     token,
+	}, nil)
+}
+
+// revoke revokes a license on the server.
+func (s *server) revoke(ctx context.Context, tenantID, licenseID, reason string) error {
+	return s.do(ctx, http.MethodPost, revokePath, map[string]string{
+		"tenant_id":  tenantID,
+		"license_id": licenseID,
+		"reason":     reason,
+	}, nil)
+}
+
+// do sends body, if any, as JSON to path and decodes a successful response
+// into result, if set. Responses outside 2xx yield a *ServerError.
+func (s *server) do(ctx context.Context, method, path string, body, result interface{}) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.url+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.credential != "" {
+		auth := s.credential
+		if !strings.Contains(auth, " ") {
+			auth = "Bearer " + auth
+		}
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return serverError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("admin: decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// serverError builds the *ServerError for resp, taking the message from a
+// JSON "message" or "error" field or else the plain response body.
+func serverError(resp *http.Response) *ServerError {
+	e := &ServerError{StatusCode: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	var body struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	switch {
+	case json.Unmarshal(data, &body) == nil:
+		e.Message = body.Message
+		if e.Message == "" {
+			e.Message = body.Error
+		}
+	default:
+		e.Message = strings.TrimSpace(string(data))
+	}
+	return e
+}
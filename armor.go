@@ -0,0 +1,158 @@
+package licenseedict
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	armorBegin = "-----BEGIN LICENSEEDICT LICENSE-----"
+	armorEnd   = "-----END LICENSEEDICT LICENSE-----"
+
+	// armorLineLength is the base64 line width, short enough to survive
+	// mail clients that rewrap long lines.
+	armorLineLength = 64
+)
+
+// ArmoredLicense is a license token in the armored text format:
+//
+//	-----BEGIN LICENSEEDICT LICENSE-----
+//	License-ID: lic_123
+//	Expires: 2026-01-01T00:00:00Z
+//
+//	<base64 token, wrapped at 64 columns>
+//	-----END LICENSEEDICT LICENSE-----
+//
+// Headers are informational only and are not covered by the signature; use
+// Validate to obtain trusted license details.
+type ArmoredLicense struct {
+	Token   string
+	Headers map[string]string
+}
+
+// ParseArmoredLicense extracts the license from armored text. It tolerates
+// surrounding text, CRLF line endings, indentation, and "> " reply quoting,
+// so a license pasted from an email or ticket can be used as-is.
+func ParseArmoredLicense(data []byte) (*ArmoredLicense, error) {
+	armored := &ArmoredLicense{Headers: map[string]string{}}
+	var body strings.Builder
+	inBlock, inHeaders, done := false, false, false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := unquoteArmorLine(scanner.Text())
+		switch {
+		case !inBlock:
+			if line == armorBegin {
+				inBlock, inHeaders = true, true
+			}
+		case line == armorEnd:
+			done = true
+		case inHeaders && line == "":
+			inHeaders = false
+		case inHeaders && strings.Contains(line, ":"):
+			key, value, _ := strings.Cut(line, ":")
+			armored.Headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		default:
+			inHeaders = false
+			body.WriteString(strings.Join(strings.Fields(line), ""))
+		}
+		if done {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !inBlock {
+		return nil, &ValidationError{Code: LicenseDecodeError, Message: "no armored license block found"}
+	}
+	if !done {
+		return nil, &ValidationError{Code: LicenseDecodeError, Message: "armored license block is not terminated"}
+	}
+	armored.Token = body.String()
+	if _, err := decodeTokenPayload(armored.Token); err != nil {
+		return nil, err
+	}
+	return armored, nil
+}
+
+// unquoteArmorLine strips whitespace and email reply quoting from a line.
+func unquoteArmorLine(line string) string {
+	line = strings.TrimSpace(line)
+	for strings.HasPrefix(line, ">") {
+		line = strings.TrimSpace(line[1:])
+	}
+	return line
+}
+
+// WriteArmoredLicense writes token in the armored format. Informational
+// headers (license ID, product, licensee, expiry) are taken from the token's
+// payload; the signature is not verified.
+func WriteArmoredLicense(w io.Writer, token string) error {
+	payload, err := decodeTokenPayload(token)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{
+		"License-ID": payload.LicenseID,
+		"Product-ID": payload.ProductID,
+		"Licensee":   payload.Licensee,
+		"Plan":       payload.Plan,
+	}
+	if !payload.ExpiresAt.IsZero() {
+		headers["Expires"] = payload.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	keys := make([]string, 0, len(headers))
+	for k, v := range headers {
+		if v != "" && !strings.ContainsAny(v, "\r\n") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString(armorBegin + "\n")
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%s: %s\n", k, headers[k])
+	}
+	buf.WriteString("\n")
+	for len(token) > armorLineLength {
+		buf.WriteString(token[:armorLineLength] + "\n")
+		token = token[armorLineLength:]
+	}
+	buf.WriteString(token + "\n")
+	buf.WriteString(armorEnd + "\n")
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// LoadLicenseFile reads a license token from path. The file may be armored
+// or contain a bare token.
+func LoadLicenseFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("licenseedict: read license file: %w", err)
+	}
+	if bytes.Contains(data, []byte(armorBegin)) {
+		armored, err := ParseArmoredLicense(data)
+		if err != nil {
+			return "", err
+		}
+		return armored.Token, nil
+	}
+	token := string(bytes.TrimSpace(data))
+	if _, err := decodeTokenPayload(token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
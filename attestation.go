@@ -0,0 +1,57 @@
+package licenseedict
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"sync"
+)
+
+const heartbeatStatusAttestationRejected = "attestation_rejected"
+
+// binaryAttestation lazily computes the hash of the running executable.
+type binaryAttestation struct {
+	once sync.Once
+	hash string
+	err  error
+}
+
+// BinaryHash returns the hex SHA-256 of the running executable. The hash is
+// computed once and cached.
+func (c *Client) BinaryHash() (string, error) {
+	c.attestation.once.Do(func() {
+		c.attestation.hash, c.attestation.err = hashExecutable()
+	})
+	return c.attestation.hash, c.attestation.err
+}
+
+func hashExecutable() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// attestationMetadata adds the binary hash and app version to heartbeat
+// metadata when WithBinaryAttestation is set.
+func (c *Client) attestationMetadata(metadata map[string]string) {
+	if !c.cfg.binaryAttestation {
+		return
+	}
+	if hash, err := c.BinaryHash(); err == nil {
+		metadata["binary_sha256"] = hash
+	}
+	metadata["app_version"] = c.cfg.appVersion
+}
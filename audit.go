@@ -0,0 +1,504 @@
+package licenseedict
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultAuditInterval is how often audit snapshots are written when
+// AuditOptions.Interval is zero.
+const defaultAuditInterval = 24 * time.Hour
+
+// auditAnchorTimeout bounds the report of a new log head to the server.
+const auditAnchorTimeout = 5 * time.Second
+
+// AuditOptions configures the audit log enabled with WithAuditLog.
+type AuditOptions struct {
+	// Path is the audit log file. Snapshots are appended as JSON lines.
+	Path string
+	// Interval is how often a snapshot is written (default 24h). A final
+	// snapshot is written when the client is closed.
+	Interval time.Duration
+	// SigningKey signs the snapshots. It is required and should be
+	// provisioned by the vendor for each customer, for example delivered
+	// with the license, with the vendor keeping the public key to verify
+	// against. The key is not generated locally: the audited party could
+	// then simply re-sign an edited log.
+	SigningKey ed25519.PrivateKey
+}
+
+// AuditAnchor identifies the record at one end of an audit log: its
+// sequence number and the hex SHA-256 of its line. The client reports the
+// anchor of every snapshot it writes to the server, so the vendor can tell
+// how far the log must reach; see AuditVerifyOptions.
+type AuditAnchor struct {
+	Seq  uint64    `json:"seq"`
+	Hash string    `json:"hash"`
+	Time time.Time `json:"time"`
+}
+
+// WithAuditLog records signed, hash-chained snapshots of license usage
+// (validations, heartbeats, seat occupancy and features used) to a local
+// file for compliance audits and enterprise true-ups. Auditors verify the
+// file offline with VerifyAuditLog or licensectl verify-audit. An existing
+// log is continued. NewClient fails if the log cannot be opened or no
+// signing key is set.
+//
+// After every snapshot the client reports the new head of the log to the
+// server, so records removed from its end are detected when the log is
+// verified against the last anchor the server received.
+func WithAuditLog(opts AuditOptions) Option {
+	return func(c *clientConfig) {
+		c.audit = &opts
+	}
+}
+
+// AuditSnapshot is one record in the audit log. Counters cover the period
+// since the previous snapshot.
+type AuditSnapshot struct {
+	Seq            uint64            `json:"seq"`
+	Time           time.Time         `json:"time"`
+	LicenseID      string            `json:"license_id,omitempty"`
+	ProductID      string            `json:"product_id,omitempty"`
+	InstanceID     string            `json:"instance_id,omitempty"`
+	LicenseValid   bool              `json:"license_valid"`
+	Validations    uint64            `json:"validations"`
+	Heartbeats     uint64            `json:"heartbeats"`
+	ActiveSessions int               `json:"active_sessions"`
+	MaxSessions    int               `json:"max_sessions"`
+	FeaturesUsed   map[string]uint64 `json:"features_used,omitempty"`
+
+	// PrevHash is the hex SHA-256 of the previous record's line, chaining
+	// the records so that removing or reordering one is detected. It is
+	// empty for the first record.
+	PrevHash string `json:"prev_hash"`
+	// PublicKey is the base64 Ed25519 key Signature verifies with.
+	PublicKey string `json:"public_key"`
+	// Signature is the base64 signature over the record encoded without
+	// this field.
+	Signature string `json:"signature,omitempty"`
+}
+
+// auditLog writes the audit snapshots of a client.
+type auditLog struct {
+	path     string
+	interval time.Duration
+	key      ed25519.PrivateKey
+
+	mu              sync.Mutex
+	seq             uint64
+	prevHash        string
+	lastValidations uint64
+	lastHeartbeats  uint64
+	features        map[string]uint64
+
+	once   sync.Once
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// openAuditLog prepares the audit log described by opts, continuing the
+// chain of an existing file.
+func openAuditLog(opts AuditOptions) (*auditLog, error) {
+	if opts.Path == "" {
+		return nil, errors.New("licenseedict: audit log path is empty")
+	}
+	key := opts.SigningKey
+	if key == nil {
+		return nil, errors.New("licenseedict: audit log signing key is required")
+	}
+	a := &auditLog{
+		path:     opts.Path,
+		interval: opts.Interval,
+		key:      key,
+		features: make(map[string]uint64),
+	}
+	if a.interval <= 0 {
+		a.interval = defaultAuditInterval
+	}
+
+	line, err := lastLine(opts.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("licenseedict: read audit log: %w", err)
+	}
+	if len(line) > 0 {
+		var last AuditSnapshot
+		if err := json.Unmarshal(line, &last); err != nil {
+			return nil, fmt.Errorf("licenseedict: audit log %s: last record: %w", opts.Path, err)
+		}
+		if last.PublicKey != a.publicKey() {
+			return nil, fmt.Errorf("licenseedict: audit log %s was signed with a different key", opts.Path)
+		}
+		a.seq = last.Seq + 1
+		a.prevHash = auditHash(line)
+	}
+	return a, nil
+}
+
+// lastLine returns the last non-empty line of the file at path.
+func lastLine(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimRight(data, "\r\n")
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	return bytes.TrimSpace(data), nil
+}
+
+func auditHash(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *auditLog) publicKey() string {
+	return base64.StdEncoding.EncodeToString(a.key.Public().(ed25519.PublicKey))
+}
+
+// noteFeature records a use of feature for the next snapshot.
+func (a *auditLog) noteFeature(feature string) {
+	a.mu.Lock()
+	a.features[feature]++
+	a.mu.Unlock()
+}
+
+// write appends a snapshot of c's usage since the previous snapshot and
+// returns its anchor.
+func (a *auditLog) write(c *Client) (AuditAnchor, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	validations := c.stats.validations.Load()
+	heartbeats := c.stats.heartbeatsSent.Load()
+	snap := AuditSnapshot{
+		Seq:         a.seq,
+		Time:        time.Now().UTC(),
+		Validations: validations - a.lastValidations,
+		Heartbeats:  heartbeats - a.lastHeartbeats,
+		PrevHash:    a.prevHash,
+		PublicKey:   a.publicKey(),
+	}
+	if license := c.License(); license != nil {
+		snap.LicenseID = license.LicenseID
+		snap.ProductID = license.ProductID
+		snap.LicenseValid = license.Valid
+	}
+	c.hb.mu.Lock()
+	snap.InstanceID = c.hb.opts.InstanceID
+	c.hb.mu.Unlock()
+	if snap.InstanceID == "" {
+		snap.InstanceID = c.cfg.instanceID
+	}
+	if hb, _ := c.LastHeartbeat(); hb != nil {
+		snap.ActiveSessions = hb.ActiveSessions
+		snap.MaxSessions = hb.MaxSessions
+	}
+	if len(a.features) > 0 {
+		snap.FeaturesUsed = a.features
+	}
+
+	line, err := signAuditSnapshot(snap, a.key)
+	if err != nil {
+		return AuditAnchor{}, err
+	}
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return AuditAnchor{}, fmt.Errorf("licenseedict: open audit log: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return AuditAnchor{}, fmt.Errorf("licenseedict: write audit log: %w", err)
+	}
+
+	a.seq++
+	a.prevHash = auditHash(line)
+	a.lastValidations = validations
+	a.lastHeartbeats = heartbeats
+	a.features = make(map[string]uint64)
+	return AuditAnchor{Seq: snap.Seq, Hash: a.prevHash, Time: snap.Time}, nil
+}
+
+// writeAudit writes a snapshot and reports the new head of the log to the
+// server.
+func (c *Client) writeAudit() error {
+	anchor, err := c.audit.write(c)
+	if err != nil {
+		return err
+	}
+	c.reportAuditAnchor(anchor)
+	return nil
+}
+
+// reportAuditAnchor sends anchor to the server. Failures are reported to the
+// error hook only: the next snapshot's anchor supersedes this one.
+func (c *Client) reportAuditAnchor(anchor AuditAnchor) {
+	if c.cfg.offlineOnly || c.requireServer() != nil || c.deferForQuietHours("audit log anchor") {
+		return
+	}
+	token := c.currentToken()
+	if token == "" {
+		return
+	}
+	instanceID := c.cfg.instanceID
+	c.hb.mu.Lock()
+	if c.hb.opts.InstanceID != "" {
+		instanceID = c.hb.opts.InstanceID
+	}
+	c.hb.mu.Unlock()
+
+	body := map[string]interface{}{
+		"signed_token": token,
+		"instance_id":  instanceID,
+		"seq":          anchor.Seq,
+		"hash":         anchor.Hash,
+		"time":         anchor.Time,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), auditAnchorTimeout)
+	defer cancel()
+	res, err := c.callServer(ctx, methodPost, c.cfg.endpoints.AuditAnchor, auditAnchorPath, body, nil)
+	if err == nil && res.StatusCode != statusOK {
+		err = fmt.Errorf("status %d", res.StatusCode)
+	}
+	if err != nil {
+		c.hookError(res.annotate(&ValidationError{Code: ServerUnreachable, Message: "audit log anchor report failed", Err: err}))
+	}
+}
+
+// signAuditSnapshot returns the JSON line for snap with its signature set.
+func signAuditSnapshot(snap AuditSnapshot, key ed25519.PrivateKey) ([]byte, error) {
+	snap.Signature = ""
+	unsigned, err := json.Marshal(snap)
+	if err != nil {
+		return nil, err
+	}
+	snap.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, unsigned))
+	return json.Marshal(snap)
+}
+
+func (c *Client) maybeStartAudit() {
+	a := c.audit
+	if a == nil {
+		return
+	}
+	a.once.Do(func() {
+		a.stopCh = make(chan struct{})
+		a.doneCh = make(chan struct{})
+		go c.auditLoop(a.stopCh, a.doneCh)
+	})
+}
+
+// stopAudit stops the snapshot loop and writes a final snapshot.
+func (c *Client) stopAudit() {
+	a := c.audit
+	if a == nil {
+		return
+	}
+	a.once.Do(func() {})
+	if a.stopCh != nil {
+		close(a.stopCh)
+		<-a.doneCh
+	}
+	if err := c.writeAudit(); err != nil {
+		c.hookError(err)
+	}
+}
+
+func (c *Client) auditLoop(stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	ticker := time.NewTicker(c.audit.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			if err := c.writeAudit(); err != nil {
+				c.hookError(err)
+			}
+		}
+	}
+}
+
+// WriteAuditSnapshot appends a snapshot to the audit log immediately, for
+// example at the end of a true-up period. It returns an error if the client
+// was created without WithAuditLog.
+func (c *Client) WriteAuditSnapshot() error {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+	if c.audit == nil {
+		return errors.New("licenseedict: audit log not enabled; see WithAuditLog")
+	}
+	return c.writeAudit()
+}
+
+// UseFeature reports whether the current license includes feature. When the
+// audit log is enabled, a granted use is counted in the next snapshot's
+// FeaturesUsed.
+func (c *Client) UseFeature(feature string) bool {
+	if !c.License().HasFeature(feature) {
+		return false
+	}
+	if c.audit != nil {
+		c.audit.noteFeature(feature)
+	}
+	return true
+}
+
+// AuditReport summarizes an audit log verified by VerifyAuditLog.
+type AuditReport struct {
+	Snapshots int       `json:"snapshots"`
+	First     time.Time `json:"first"`
+	Last      time.Time `json:"last"`
+	PublicKey string    `json:"public_key"`
+	// Head is the anchor of the last record, to compare with the last
+	// anchor the server received.
+	Head AuditAnchor `json:"head"`
+	// Validations, Heartbeats and FeaturesUsed total the counters of all
+	// snapshots; PeakSessions is the highest ActiveSessions recorded.
+	Validations  uint64            `json:"validations"`
+	Heartbeats   uint64            `json:"heartbeats"`
+	PeakSessions int               `json:"peak_sessions"`
+	FeaturesUsed map[string]uint64 `json:"features_used,omitempty"`
+}
+
+// AuditLogError reports where an audit log failed verification.
+type AuditLogError struct {
+	// Line is the 1-based line number of the offending record.
+	Line   int
+	Reason string
+}
+
+func (e *AuditLogError) Error() string {
+	return fmt.Sprintf("licenseedict: audit log line %d: %s", e.Line, e.Reason)
+}
+
+// AuditVerifyOptions anchors the ends of a log checked by VerifyAuditLog.
+type AuditVerifyOptions struct {
+	// After is the anchor of the record preceding the first one read, for
+	// logs whose older records were rotated out. If nil, the log must
+	// start with the first record ever written.
+	After *AuditAnchor
+	// Head is the last anchor the server received from the client. If
+	// set, the log must reach it, and the record with Head's sequence
+	// number must match its hash, so records removed from the end are
+	// detected. Without it, such truncation cannot be detected.
+	Head *AuditAnchor
+}
+
+// VerifyAuditLog checks an audit log written with WithAuditLog against the
+// public key of the signing key provisioned for the licensee: every record
+// must be signed with it, records must be consecutive and chained by hash,
+// and the log must start and end where opts says. It returns ErrNoPublicKey
+// if publicKey is nil, and an *AuditLogError for the first record that
+// fails.
+func VerifyAuditLog(r io.Reader, publicKey ed25519.PublicKey, opts AuditVerifyOptions) (*AuditReport, error) {
+	if publicKey == nil {
+		return nil, ErrNoPublicKey
+	}
+	report := &AuditReport{
+		PublicKey:    base64.StdEncoding.EncodeToString(publicKey),
+		FeaturesUsed: make(map[string]uint64),
+	}
+	var (
+		lineNo   int
+		prevHash string
+		prevSeq  uint64
+		sawHead  bool
+	)
+	if opts.After != nil {
+		prevHash, prevSeq = opts.After.Hash, opts.After.Seq
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		lineNo++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var snap AuditSnapshot
+		if err := json.Unmarshal(line, &snap); err != nil {
+			return report, &AuditLogError{Line: lineNo, Reason: "malformed record: " + err.Error()}
+		}
+
+		if snap.PublicKey != report.PublicKey {
+			return report, &AuditLogError{Line: lineNo, Reason: "signed with a different key"}
+		}
+		sig, err := base64.StdEncoding.DecodeString(snap.Signature)
+		if err != nil {
+			return report, &AuditLogError{Line: lineNo, Reason: "malformed signature"}
+		}
+		unsigned := snap
+		unsigned.Signature = ""
+		payload, err := json.Marshal(unsigned)
+		if err != nil || !ed25519.Verify(publicKey, payload, sig) {
+			return report, &AuditLogError{Line: lineNo, Reason: "signature verification failed"}
+		}
+
+		switch {
+		case report.Snapshots == 0 && opts.After == nil && snap.Seq != 0:
+			return report, &AuditLogError{Line: lineNo, Reason: fmt.Sprintf("log starts at sequence %d; earlier records are missing", snap.Seq)}
+		case (report.Snapshots > 0 || opts.After != nil) && snap.Seq != prevSeq+1:
+			return report, &AuditLogError{Line: lineNo, Reason: fmt.Sprintf("sequence %d follows %d", snap.Seq, prevSeq)}
+		case snap.PrevHash != prevHash:
+			return report, &AuditLogError{Line: lineNo, Reason: "hash chain broken"}
+		}
+		hash := auditHash(line)
+		if opts.Head != nil && snap.Seq == opts.Head.Seq {
+			if hash != opts.Head.Hash {
+				return report, &AuditLogError{Line: lineNo, Reason: "record does not match the anchor reported to the server"}
+			}
+			sawHead = true
+		}
+
+		if report.Snapshots == 0 {
+			report.First = snap.Time
+		}
+		report.Snapshots++
+		report.Last = snap.Time
+		report.Validations += snap.Validations
+		report.Heartbeats += snap.Heartbeats
+		if snap.ActiveSessions > report.PeakSessions {
+			report.PeakSessions = snap.ActiveSessions
+		}
+		for f, n := range snap.FeaturesUsed {
+			report.FeaturesUsed[f] += n
+		}
+		prevHash = hash
+		prevSeq = snap.Seq
+		report.Head = AuditAnchor{Seq: snap.Seq, Hash: hash, Time: snap.Time}
+	}
+	if err := scanner.Err(); err != nil {
+		return report, fmt.Errorf("licenseedict: read audit log: %w", err)
+	}
+	if report.Snapshots == 0 {
+		return report, &AuditLogError{Line: lineNo, Reason: "no records"}
+	}
+	if opts.Head != nil && !sawHead {
+		return report, &AuditLogError{Line: lineNo, Reason: fmt.Sprintf("log ends at sequence %d before the anchor reported to the server at %d", prevSeq, opts.Head.Seq)}
+	}
+	return report, nil
+}
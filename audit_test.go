@@ -0,0 +1,228 @@
+package licenseedict_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
+)
+
+// auditClient returns a client without a server that writes its audit log
+// to path, signed with key.
+func auditClient(t *testing.T, r *raceIssuer, path string, key ed25519.PrivateKey) (*licenseedict.Client, error) {
+	t.Helper()
+	c, err := licenseedict.NewClient(
+		licenseedict.WithPublicKey(r.publicKey),
+		licenseedict.WithToken(r.sign(t, "lic-audit", "")),
+		licenseedict.WithoutCache(),
+		licenseedict.WithAuditLog(licenseedict.AuditOptions{Path: path, SigningKey: key}),
+	)
+	if err == nil {
+		drain(c)
+	}
+	return c, err
+}
+
+// writeAuditLog writes a log of n snapshots, each after one validation and
+// one use of the PRO feature, and returns its lines.
+func writeAuditLog(t *testing.T, r *raceIssuer, path string, key ed25519.PrivateKey, n int) []string {
+	t.Helper()
+	c, err := auditClient(t, r, path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n-1; i++ {
+		if _, err := c.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		c.UseFeature("PRO")
+		if err := c.WriteAuditSnapshot(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Close() // writes the final snapshot
+	return readLines(t, path)
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func verifyLines(lines []string, key ed25519.PublicKey, opts licenseedict.AuditVerifyOptions) (*licenseedict.AuditReport, error) {
+	return licenseedict.VerifyAuditLog(strings.NewReader(strings.Join(lines, "\n")+"\n"), key, opts)
+}
+
+func newAuditKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub, key
+}
+
+func TestAuditLogRoundTrip(t *testing.T) {
+	r := newRaceIssuer(t)
+	pub, key := newAuditKey(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	lines := writeAuditLog(t, r, path, key, 3)
+
+	report, err := verifyLines(lines, pub, licenseedict.AuditVerifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Snapshots != 3 || report.Head.Seq != 2 {
+		t.Fatalf("report: %d snapshots, head %d, want 3 and 2", report.Snapshots, report.Head.Seq)
+	}
+	if report.Validations != 2 || report.FeaturesUsed["PRO"] != 2 {
+		t.Fatalf("report: %d validations, PRO used %d times, want 2 and 2", report.Validations, report.FeaturesUsed["PRO"])
+	}
+
+	// Verifying up to its own head succeeds.
+	head := report.Head
+	if _, err := verifyLines(lines, pub, licenseedict.AuditVerifyOptions{Head: &head}); err != nil {
+		t.Fatalf("verify against head: %v", err)
+	}
+}
+
+func TestAuditLogContinued(t *testing.T) {
+	r := newRaceIssuer(t)
+	pub, key := newAuditKey(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeAuditLog(t, r, path, key, 2)
+	lines := writeAuditLog(t, r, path, key, 2)
+
+	report, err := verifyLines(lines, pub, licenseedict.AuditVerifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Snapshots != 4 || report.Head.Seq != 3 {
+		t.Fatalf("report: %d snapshots, head %d, want 4 and 3", report.Snapshots, report.Head.Seq)
+	}
+
+	_, otherKey := newAuditKey(t)
+	if _, err := auditClient(t, r, path, otherKey); err == nil {
+		t.Fatal("continued the log with a different key")
+	}
+}
+
+func TestAuditLogRejectsChanges(t *testing.T) {
+	r := newRaceIssuer(t)
+	pub, key := newAuditKey(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	lines := writeAuditLog(t, r, path, key, 4)
+	full, err := verifyLines(lines, pub, licenseedict.AuditVerifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := full.Head
+	otherPub, _ := newAuditKey(t)
+
+	edit := func(fn func([]string) []string) []string {
+		return fn(append([]string(nil), lines...))
+	}
+	tampered := edit(func(l []string) []string {
+		l[1] = strings.Replace(l[1], `"validations":1`, `"validations":0`, 1)
+		return l
+	})
+	if tampered[1] == lines[1] {
+		t.Fatal("test record was not changed")
+	}
+	tests := []struct {
+		name  string
+		lines []string
+		key   ed25519.PublicKey
+		opts  licenseedict.AuditVerifyOptions
+		line  int
+	}{
+		{
+			name:  "tampered record",
+			lines: tampered,
+			key:   pub,
+			line:  2,
+		},
+		{
+			name:  "removed record",
+			lines: edit(func(l []string) []string { return append(l[:1], l[2:]...) }),
+			key:   pub,
+			line:  2,
+		},
+		{
+			name:  "removed first record",
+			lines: edit(func(l []string) []string { return l[1:] }),
+			key:   pub,
+			line:  1,
+		},
+		{
+			name:  "reordered records",
+			lines: edit(func(l []string) []string { l[1], l[2] = l[2], l[1]; return l }),
+			key:   pub,
+			line:  2,
+		},
+		{
+			name:  "truncated before head",
+			lines: lines[:2],
+			key:   pub,
+			opts:  licenseedict.AuditVerifyOptions{Head: &head},
+			line:  2,
+		},
+		{
+			name:  "head does not match",
+			lines: lines,
+			key:   pub,
+			opts:  licenseedict.AuditVerifyOptions{Head: &licenseedict.AuditAnchor{Seq: head.Seq, Hash: strings.Repeat("0", 64)}},
+			line:  4,
+		},
+		{
+			name:  "different key",
+			lines: lines,
+			key:   otherPub,
+			line:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifyLines(tt.lines, tt.key, tt.opts)
+			var logErr *licenseedict.AuditLogError
+			if !errors.As(err, &logErr) {
+				t.Fatalf("err = %v, want an *AuditLogError", err)
+			}
+			if logErr.Line != tt.line {
+				t.Fatalf("failed at line %d (%s), want line %d", logErr.Line, logErr.Reason, tt.line)
+			}
+		})
+	}
+}
+
+func TestAuditLogRotated(t *testing.T) {
+	r := newRaceIssuer(t)
+	pub, key := newAuditKey(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	lines := writeAuditLog(t, r, path, key, 3)
+	first, err := verifyLines(lines[:1], pub, licenseedict.AuditVerifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after := first.Head
+	report, err := verifyLines(lines[1:], pub, licenseedict.AuditVerifyOptions{After: &after})
+	if err != nil {
+		t.Fatalf("rotated log: %v", err)
+	}
+	if report.Snapshots != 2 {
+		t.Fatalf("rotated log: %d snapshots, want 2", report.Snapshots)
+	}
+	if report.PublicKey != first.PublicKey {
+		t.Fatal("rotated log reports a different key")
+	}
+}
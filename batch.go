@@ -0,0 +1,83 @@
+package licenseedict
+
+import (
+	"crypto/ed25519"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// VerifyResult is the outcome of verifying one token with VerifyTokens.
+type VerifyResult struct {
+	// License is the decoded license, or nil if the token could not be
+	// verified. License.Valid reports the temporal checks.
+	License *License
+	// Err is the verification error, if any.
+	Err error
+}
+
+// VerifyTokens verifies many tokens against one base64-encoded public key
+// and returns one result per token, in the same order. The key is parsed
+// once and tokens are verified in parallel.
+//
+// Unlike CheckLicense, VerifyTokens never reads or writes the license cache,
+// which makes it suitable for backend services that validate
+// customer-supplied tokens in bulk.
+func VerifyTokens(publicKey string, tokens []string) ([]VerifyResult, error) {
+	if publicKey == "" {
+		return nil, ErrNoPublicKey
+	}
+	pubKey, err := DecodePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return VerifyTokensWithKey(pubKey, tokens), nil
+}
+
+// VerifyTokensWithKey is like VerifyTokens but takes a decoded public key.
+func VerifyTokensWithKey(pubKey ed25519.PublicKey, tokens []string) []VerifyResult {
+	results := make([]VerifyResult, len(tokens))
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(tokens) {
+		workers = len(tokens)
+	}
+
+	now := time.Now()
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i].License, results[i].Err = verifyLicense(pubKey, tokens[i], now)
+			}
+		}()
+	}
+	for i := range tokens {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// verifyLicense verifies token and applies the temporal validity checks as
+// of now, without touching the cache.
+func verifyLicense(pubKey ed25519.PublicKey, token string, now time.Time) (*License, error) {
+	if token == "" {
+		return nil, ErrNoToken
+	}
+	payload, err := verifyToken(pubKey, token, false)
+	if err != nil {
+		return nil, err
+	}
+	license := payloadToLicense(payload, token, true)
+	if !payload.IssuedAt.IsZero() && now.Before(payload.IssuedAt) {
+		license.Valid = false
+	}
+	if !payload.ExpiresAt.IsZero() && now.After(payload.ExpiresAt) {
+		license.Valid = false
+	}
+	return license, nil
+}
@@ -0,0 +1,170 @@
+package licenseedict
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const defaultBudgetBytesPeriod = 24 * time.Hour
+
+// ErrNetworkBudgetExceeded is returned when a request cannot be sent within
+// its context deadline without exceeding the budget set with
+// WithNetworkBudget.
+var ErrNetworkBudgetExceeded = errors.New("licenseedict: network budget exceeded")
+
+// NetworkBudget caps the SDK's network traffic, for hosts on metered or
+// low-bandwidth links. Zero fields mean no limit.
+type NetworkBudget struct {
+	// RequestsPerMinute is the sustained request rate. Up to a minute's
+	// worth of requests may be sent in a burst.
+	RequestsPerMinute int
+	// Bytes is the number of request and response body bytes allowed per
+	// BytesPeriod. Headers are not counted.
+	Bytes int64
+	// BytesPeriod is the period Bytes refills over. The default is 24h.
+	BytesPeriod time.Duration
+}
+
+// WithNetworkBudget limits the client's requests to budget. Requests over
+// budget are queued until it allows them, and each wait emits an
+// EventNetworkThrottled event. A request whose wait would outlast its
+// context's deadline fails at once with an error wrapping
+// ErrNetworkBudgetExceeded; background tasks have no deadline and wait.
+// Response bytes are counted once received, so a large response delays the
+// requests after it.
+func WithNetworkBudget(budget NetworkBudget) Option {
+	return func(c *clientConfig) {
+		c.networkBudget = &budget
+	}
+}
+
+// tokenBucket refills at rate tokens per second up to capacity. Reservations
+// may drive it negative; the deficit is the wait for the next reservation.
+type tokenBucket struct {
+	capacity float64
+	rate     float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(capacity float64, period time.Duration) *tokenBucket {
+	return &tokenBucket{capacity: capacity, rate: capacity / period.Seconds(), tokens: capacity}
+}
+
+// take removes n tokens at now and returns how long until the balance is
+// no longer negative.
+func (b *tokenBucket) take(now time.Time, n float64) time.Duration {
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+	b.last = now
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// networkBudget enforces a NetworkBudget across all of a client's requests.
+type networkBudget struct {
+	mu       sync.Mutex
+	requests *tokenBucket
+	bytes    *tokenBucket
+
+	// onThrottle is called with the wait before a request is held back.
+	onThrottle func(time.Duration)
+}
+
+func newNetworkBudget(cfg NetworkBudget) *networkBudget {
+	b := &networkBudget{}
+	if cfg.RequestsPerMinute > 0 {
+		b.requests = newTokenBucket(float64(cfg.RequestsPerMinute), time.Minute)
+	}
+	if cfg.Bytes > 0 {
+		period := cfg.BytesPeriod
+		if period <= 0 {
+			period = defaultBudgetBytesPeriod
+		}
+		b.bytes = newTokenBucket(float64(cfg.Bytes), period)
+	}
+	return b
+}
+
+// wait reserves one request carrying size body bytes and blocks until the
+// budget allows it. A nil budget never waits.
+func (b *networkBudget) wait(ctx context.Context, size int) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	now := time.Now()
+	var delay time.Duration
+	if b.requests != nil {
+		delay = b.requests.take(now, 1)
+	}
+	if b.bytes != nil {
+		delay = max(delay, b.bytes.take(now, float64(size)))
+	}
+	b.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		b.refund(size)
+		return fmt.Errorf("%w: next request allowed in %s", ErrNetworkBudgetExceeded, delay.Round(time.Millisecond))
+	}
+	if b.onThrottle != nil {
+		b.onThrottle(delay)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.refund(size)
+		return ctx.Err()
+	}
+}
+
+// refund returns a reservation for a request that was not sent.
+func (b *networkBudget) refund(size int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.requests != nil {
+		b.requests.tokens++
+	}
+	if b.bytes != nil {
+		b.bytes.tokens += float64(size)
+	}
+}
+
+// received charges n response bytes to the budget.
+func (b *networkBudget) received(n int64) {
+	if b == nil || b.bytes == nil || n <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bytes.take(time.Now(), float64(n))
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
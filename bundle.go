@@ -0,0 +1,123 @@
+package licenseedict
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// LicenseBundleVersion is the bundle format version produced by the server.
+const LicenseBundleVersion = 1
+
+// LicenseBundle is a signed offline artifact for air-gapped deployments. It
+// carries the license token together with a revocation list snapshot and the
+// set of trusted signing keys, so a single periodically delivered file keeps
+// an offline installation up to date.
+//
+// The file uses the same encoding as a token: base64(signature + JSON), signed
+// with the publisher's root key configured via WithPublicKey.
+type LicenseBundle struct {
+	Version         int       `json:"version"`
+	Token           string    `json:"token"`
+	RevokedLicenses []string  `json:"revoked_licenses,omitempty"`
+	RevocationsAt   time.Time `json:"revocations_issued_at"`
+	Keys            []string  `json:"keys,omitempty"`
+	IssuedAt        time.Time `json:"issued_at"`
+}
+
+// LoadLicenseBundle reads and verifies the bundle at path.
+func LoadLicenseBundle(path string, rootKey ed25519.PublicKey) (*LicenseBundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("licenseedict: read license bundle: %w", err)
+	}
+	return ParseLicenseBundle(data, rootKey)
+}
+
+// ParseLicenseBundle verifies the bundle signature with rootKey and decodes it.
+func ParseLicenseBundle(data []byte, rootKey ed25519.PublicKey) (*LicenseBundle, error) {
+	if rootKey == nil {
+		return nil, ErrNoPublicKey
+	}
+
+	combined, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return nil, &ValidationError{Code: LicenseDecodeError, Message: "failed to base64-decode license bundle", Err: err}
+	}
+	if len(combined) <= ed25519.SignatureSize {
+		return nil, &ValidationError{Code: LicenseDecodeError, Message: "license bundle too short"}
+	}
+
+	signature := combined[:ed25519.SignatureSize]
+	payload := combined[ed25519.SignatureSize:]
+	if !ed25519.Verify(rootKey, payload, signature) {
+		return nil, &ValidationError{Code: InvalidLicenseSignature, Message: "license bundle signature verification failed"}
+	}
+
+	var bundle LicenseBundle
+	if err := json.Unmarshal(payload, &bundle); err != nil {
+		return nil, &ValidationError{Code: LicenseDecodeError, Message: "failed to decode license bundle", Err: err}
+	}
+	if bundle.Version > LicenseBundleVersion {
+		return nil, &ValidationError{Code: LicenseDecodeError, Message: fmt.Sprintf("unsupported license bundle version %d", bundle.Version)}
+	}
+
+	return &bundle, nil
+}
+
+// IsRevoked returns true if the bundle's revocation list contains licenseID.
+func (b *LicenseBundle) IsRevoked(licenseID string) bool {
+	if b == nil {
+		return false
+	}
+	for _, id := range b.RevokedLicenses {
+		if id == licenseID {
+			return true
+		}
+	}
+	return false
+}
+
+// TrustedKeys decodes the bundle's key set. Keys that fail to decode are
+// skipped and reported in the returned error.
+func (b *LicenseBundle) TrustedKeys() ([]ed25519.PublicKey, error) {
+	if b == nil {
+		return nil, nil
+	}
+	keys := make([]ed25519.PublicKey, 0, len(b.Keys))
+	var errs []error
+	for _, k := range b.Keys {
+		key, err := DecodePublicKey(k)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, errors.Join(errs...)
+}
+
+// loadBundle verifies the bundle at path with the configured root key and
+// installs its token, revocation list, and trusted keys.
+func (c *Client) loadBundle(path string) error {
+	bundle, err := LoadLicenseBundle(path, c.cfg.publicKey)
+	if err != nil {
+		return err
+	}
+	keys, err := bundle.TrustedKeys()
+	if err != nil {
+		return &ValidationError{Code: PubKeyDecodeError, Message: "license bundle contains an invalid key", Err: err}
+	}
+
+	c.bundle = bundle
+	c.trustedKeys = keys
+	if c.cfg.token == "" {
+		c.cfg.token = bundle.Token
+	}
+	return nil
+}
@@ -1,21 +1,183 @@
 package licenseedict
 
 import (
+	"bytes"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/adrg/xdg"
 )
 
 // cacheManager handles reading and writing cached license data.
 type cacheManager struct {
-	dir      string
+	store    CacheStore
 	disabled bool
+
+	// mu serializes writes so that state, which is stored in the same file
+	// as the license, is never lost to a concurrent save.
+	mu    sync.Mutex
+	state cacheState
+}
+
+// CacheStore persists the encoded license cache. The default store is a file
+// in the cache directory; WithCacheStore replaces it, for example with
+// WithRegistryStore on Windows.
+type CacheStore interface {
+	// Load returns the stored data, or an error matching os.ErrNotExist if
+	// nothing is stored.
+	Load() ([]byte, error)
+	// Save replaces the stored data.
+	Save(data []byte) error
+	// Delete removes the stored data. Deleting an empty store is not an
+	// error.
+	Delete() error
+}
+
+// WithCacheStore stores the license cache in store instead of a file. It
+// takes precedence over WithCacheDir and WithCacheLocation.
+func WithCacheStore(store CacheStore) Option {
+	return func(c *clientConfig) {
+		c.cacheStore = store
+	}
+}
+
+// fileStore is the default CacheStore: a file in dir.
+type fileStore struct {
+	dir string
+}
+
+func (s *fileStore) path() string {
+	return filepath.Join(s.dir, cacheFileName)
+}
+
+func (s *fileStore) Load() ([]byte, error) {
+	return os.ReadFile(s.path())
+}
+
+func (s *fileStore) Save(data []byte) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(), data, 0600)
+}
+
+func (s *fileStore) Delete() error {
+	if err := os.Remove(s.path()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// modTime returns the cache file's modification time, or the zero time if
+// it cannot be read.
+func (s *fileStore) modTime() time.Time {
+	info, err := os.Stat(s.path())
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// quarantine moves the cache file aside so it is no longer loaded, keeping it
+// for inspection. It returns the quarantined file's path.
+func (s *fileStore) quarantine() (string, error) {
+	src := s.path()
+	dst := fmt.Sprintf("%s.tampered-%d", src, time.Now().Unix())
+	if err := os.Rename(src, dst); err != nil {
+		if removeErr := os.Remove(src); removeErr != nil {
+			return "", err
+		}
+		return "", nil
+	}
+	return dst, nil
+}
+
+// memoryStore is the CacheStore used with WithInMemoryState.
+type memoryStore struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (s *memoryStore) Load() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data == nil {
+		return nil, os.ErrNotExist
+	}
+	return append([]byte(nil), s.data...), nil
+}
+
+func (s *memoryStore) Save(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *memoryStore) Delete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = nil
+	return nil
 }
 
 const cacheFileName = "license_cache.json"
 
+// cacheFormatVersion is the current on-disk cache format. Version 1 is the
+// original format: a bare License object with no version field.
+const cacheFormatVersion = 2
+
+// cacheFile is the versioned on-disk envelope around a cached license.
+type cacheFile struct {
+	Version int       `json:"version"`
+	SavedAt time.Time `json:"saved_at"`
+	License *License  `json:"license"`
+	cacheState
+}
+
+// cacheState is client state stored in the cache file alongside the license.
+// It is kept even when no license is cached.
+type cacheState struct {
+	TelemetryConsent TelemetryConsent `json:"telemetry_consent,omitempty"`
+	// PendingUsage is usage that was not reported before the client closed.
+	PendingUsage []UsageRecord `json:"pending_usage,omitempty"`
+	// LastContact is when a server last responded or, if none has, when
+	// the first attempt to reach one was made. It carries the offline
+	// limit across restarts.
+	LastContact time.Time `json:"last_contact,omitempty"`
+}
+
+func (s cacheState) empty() bool {
+	return s.TelemetryConsent == "" && len(s.PendingUsage) == 0 && s.LastContact.IsZero()
+}
+
+// errCacheVersionUnsupported is returned for caches written by a newer SDK.
+// Such files are left in place rather than treated as corrupted.
+var errCacheVersionUnsupported = errors.New("licenseedict: cache format is newer than supported")
+
+// errCacheCorrupt wraps errors decoding the cache. Only such caches are
+// quarantined; errors reading the store may be transient.
+var errCacheCorrupt = errors.New("licenseedict: cache is corrupt")
+
+// errCacheExpired is returned when the cached license is older than the TTL
+// set via WithCacheTTL.
+var errCacheExpired = errors.New("licenseedict: cached license is older than the cache TTL")
+
+// errCacheMismatch is returned when the cached license belongs to a token
+// other than the one in use.
+var errCacheMismatch = errors.New("licenseedict: cached license is for a different token")
+
+// cacheMigrations upgrades raw cache data from version N to N+1.
+var cacheMigrations = map[int]func([]byte) ([]byte, error){
+	1: migrateCacheV1,
+}
+
 func newCacheManager(appName, appPublisher, overrideDir string, disabled bool) *cacheManager {
 	if disabled {
 		return &cacheManager{disabled: true}
@@ -29,40 +191,393 @@ func newCacheManager(appName, appPublisher, overrideDir string, disabled bool) *
 		dir = filepath.Join(os.TempDir(), "licenseedict")
 	}
 
-	return &cacheManager{dir: dir}
+	cm := &cacheManager{store: &fileStore{dir: dir}}
+	cm.loadState()
+	return cm
+}
+
+// newStoreCacheManager returns a cacheManager backed by store.
+func newStoreCacheManager(store CacheStore) *cacheManager {
+	cm := &cacheManager{store: store}
+	cm.loadState()
+	return cm
 }
 
 func (cm *cacheManager) save(license *License) error {
-	if cm.disabled || cm.dir == "" {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.write(cacheFile{Version: cacheFormatVersion, SavedAt: time.Now(), License: license})
+}
+
+// write stores file with the current state. cm.mu must be held.
+func (cm *cacheManager) write(file cacheFile) error {
+	if cm.disabled || cm.store == nil {
 		return nil
 	}
 
-	if err := os.MkdirAll(cm.dir, 0700); err != nil {
+	file.cacheState = cm.state
+	data, err := json.Marshal(file)
+	if err != nil {
 		return err
 	}
 
-	data, err := json.Marshal(license)
+	return cm.store.Save(data)
+}
+
+// loadState reads the state stored with the cache, if any. It does not
+// depend on a license being cached.
+func (cm *cacheManager) loadState() {
+	if cm.disabled || cm.store == nil {
+		return
+	}
+	data, err := cm.store.Load()
 	if err != nil {
-		return err
+		return
+	}
+	var stored cacheState
+	if json.Unmarshal(data, &stored) != nil {
+		return
 	}
+	if !stored.TelemetryConsent.valid() {
+		stored.TelemetryConsent = ""
+	}
+	cm.state = stored
+}
 
-	return os.WriteFile(filepath.Join(cm.dir, cacheFileName), data, 0600)
+// storedConsent returns the consent set with saveConsent or loaded from the
+// cache, or "" if there is none.
+func (cm *cacheManager) storedConsent() TelemetryConsent {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.state.TelemetryConsent
 }
 
-func (cm *cacheManager) load() (*License, error) {
-	if cm.disabled || cm.dir == "" {
-		return nil, os.ErrNotExist
+// saveConsent records level and rewrites the cache with it.
+func (cm *cacheManager) saveConsent(level TelemetryConsent) error {
+	return cm.updateState(func(s *cacheState) { s.TelemetryConsent = level })
+}
+
+// adoptConsent sets the consent in memory only, without writing the cache.
+func (cm *cacheManager) adoptConsent(level TelemetryConsent) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.state.TelemetryConsent = level
+}
+
+// storedUsage returns the usage stored with saveUsage.
+func (cm *cacheManager) storedUsage() []UsageRecord {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return append([]UsageRecord(nil), cm.state.PendingUsage...)
+}
+
+// saveUsage replaces the stored pending usage. Nothing is written if both
+// the stored and the new usage are empty.
+func (cm *cacheManager) saveUsage(records []UsageRecord) error {
+	cm.mu.Lock()
+	unchanged := len(records) == 0 && len(cm.state.PendingUsage) == 0
+	cm.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+	return cm.updateState(func(s *cacheState) { s.PendingUsage = records })
+}
+
+// storedLastContact returns the contact time stored with saveLastContact,
+// or the zero time if there is none.
+func (cm *cacheManager) storedLastContact() time.Time {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.state.LastContact
+}
+
+// saveLastContact records t as the last server contact.
+func (cm *cacheManager) saveLastContact(t time.Time) error {
+	return cm.updateState(func(s *cacheState) { s.LastContact = t })
+}
+
+// updateState applies fn to the state and rewrites the cache with it,
+// keeping the cached license if there is one.
+func (cm *cacheManager) updateState(fn func(*cacheState)) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	fn(&cm.state)
+	file := cacheFile{Version: cacheFormatVersion}
+	if existing, _, err := cm.read(); err == nil {
+		file = *existing
 	}
+	return cm.write(file)
+}
 
-	data, err := os.ReadFile(filepath.Join(cm.dir, cacheFileName))
+func (cm *cacheManager) load() (*License, error) {
+	file, _, err := cm.read()
 	if err != nil {
 		return nil, err
 	}
+	return file.License, nil
+}
 
+// read loads the cache file, upgrading older formats in memory. It also
+// returns the format version found on disk.
+func (cm *cacheManager) read() (*cacheFile, int, error) {
+	if cm.disabled || cm.store == nil {
+		return nil, 0, os.ErrNotExist
+	}
+
+	data, err := cm.store.Load()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	file, version, err := decodeCache(data)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) && !errors.Is(err, errCacheVersionUnsupported) {
+			err = fmt.Errorf("%w: %w", errCacheCorrupt, err)
+		}
+		return nil, version, err
+	}
+	file.License.indexFeatures()
+	if file.SavedAt.IsZero() {
+		// Files migrated from v1 have no save time; the file's own
+		// modification time is the closest substitute.
+		if fs := cm.file(); fs != nil {
+			file.SavedAt = fs.modTime()
+		}
+	}
+	return file, version, nil
+}
+
+// migrate rewrites the cache file in the current format. It returns the
+// version found on disk and whether the file was rewritten.
+func (cm *cacheManager) migrate() (int, bool, error) {
+	file, version, err := cm.read()
+	if err != nil {
+		return 0, false, err
+	}
+	if version == cacheFormatVersion {
+		return version, false, nil
+	}
+	// Keep the save time so migrating does not restart the cache TTL.
+	cm.mu.Lock()
+	err = cm.write(cacheFile{Version: cacheFormatVersion, SavedAt: file.SavedAt, License: file.License})
+	cm.mu.Unlock()
+	if err != nil {
+		return version, false, err
+	}
+	return version, true, nil
+}
+
+// decodeCache parses cache data of any supported version, applying
+// migrations until it reaches cacheFormatVersion.
+func decodeCache(data []byte) (*cacheFile, int, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, 0, err
+	}
+	original := header.Version
+	if original == 0 {
+		original = 1
+	}
+	if original > cacheFormatVersion {
+		return nil, original, fmt.Errorf("%w: version %d, supported %d", errCacheVersionUnsupported, original, cacheFormatVersion)
+	}
+
+	for v := original; v < cacheFormatVersion; v++ {
+		step, ok := cacheMigrations[v]
+		if !ok {
+			return nil, original, fmt.Errorf("licenseedict: no cache migration from version %d", v)
+		}
+		var err error
+		if data, err = step(data); err != nil {
+			return nil, original, fmt.Errorf("licenseedict: migrate cache from version %d: %w", v, err)
+		}
+	}
+
+	var file cacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, original, err
+	}
+	if file.License == nil {
+		// The file may hold only client state.
+		return nil, original, fmt.Errorf("licenseedict: cache file has no license: %w", os.ErrNotExist)
+	}
+	return &file, original, nil
+}
+
+// migrateCacheV1 wraps a bare v1 License object in the versioned envelope.
+func migrateCacheV1(data []byte) ([]byte, error) {
 	var license License
 	if err := json.Unmarshal(data, &license); err != nil {
 		return nil, err
 	}
+	return json.Marshal(cacheFile{Version: 2, License: &license})
+}
+
+// path returns the cache file path, or "" when caching is disabled or the
+// cache is not stored in a file.
+func (cm *cacheManager) path() string {
+	if fs := cm.file(); fs != nil {
+		return fs.path()
+	}
+	return ""
+}
+
+// file returns the file store, or nil when caching is disabled or another
+// store is in use.
+func (cm *cacheManager) file() *fileStore {
+	if cm.disabled {
+		return nil
+	}
+	fs, _ := cm.store.(*fileStore)
+	return fs
+}
+
+// quarantine takes a corrupted or tampered cache out of use. File caches are
+// moved aside for inspection and the new path is returned; other stores are
+// cleared.
+func (cm *cacheManager) quarantine() (string, error) {
+	if cm.disabled || cm.store == nil {
+		return "", nil
+	}
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	var path string
+	var err error
+	if fs := cm.file(); fs != nil {
+		path, err = fs.quarantine()
+	} else {
+		err = cm.store.Delete()
+	}
+	if err == nil && !cm.state.empty() {
+		// Keep the client state; it was not what failed verification.
+		err = cm.write(cacheFile{Version: cacheFormatVersion})
+	}
+	return path, err
+}
 
-	return &license, nil
+// verifyCachedLicense checks that a cached license is backed by a validly
+// signed token and that none of its decoded fields were altered. With strict,
+// the token must also pass the payload schema.
+func verifyCachedLicense(pubKey ed25519.PublicKey, cached *License, strict bool) error {
+	if cached.SignedToken == "" {
+		return &ValidationError{Code: InvalidLicenseSignature, Message: "cached license has no signed token"}
+	}
+	payload, err := verifyToken(pubKey, cached.SignedToken, strict)
+	if err != nil {
+		return err
+	}
+	return matchCachedLicense(payload, cached)
+}
+
+// matchCachedLicense compares the cached fields with those decoded from the
+// verified token. Valid and Suspended are excluded since they are derived at
+// run time.
+func matchCachedLicense(payload *tokenPayload, cached *License) error {
+	expected := payloadToLicense(payload, cached.SignedToken, cached.Valid)
+	expected.Suspended = cached.Suspended
+	want, err := json.Marshal(expected)
+	if err != nil {
+		return err
+	}
+	got, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(want, got) {
+		return &ValidationError{Code: InvalidLicenseSignature, Message: "cached license does not match its signed token"}
+	}
+	return nil
+}
+
+// loadVerifiedCache loads the cached license and, when a public key is
+// configured, verifies it against its embedded token. Tampered or corrupted
+// cache files are quarantined and reported with EventCacheTampered; a cache
+// that cannot be read is left in place. If token
+// is set, the cached license must belong to it; see cacheMatchesToken.
+func (c *Client) loadVerifiedCache(token string) (*License, error) {
+	file, _, err := c.cache.read()
+	if err != nil {
+		c.stats.cacheMisses.Add(1)
+		if errors.Is(err, errCacheCorrupt) {
+			c.quarantineCache(err)
+		}
+		return nil, err
+	}
+	// A cache whose age is unknown, one migrated from v1 in a store other
+	// than a file, counts as expired.
+	if c.cfg.cacheTTL > 0 && (file.SavedAt.IsZero() || time.Since(file.SavedAt) > c.cfg.cacheTTL) {
+		c.stats.cacheMisses.Add(1)
+		return nil, errCacheExpired
+	}
+	cached := file.License
+	if c.cfg.publicKey == nil {
+		c.stats.cacheHits.Add(1)
+		return cached, nil
+	}
+
+	if cached.SignedToken == "" {
+		err = &ValidationError{Code: InvalidLicenseSignature, Message: "cached license has no signed token"}
+	} else {
+		var payload *tokenPayload
+		if payload, err = c.verify(cached.SignedToken); err == nil {
+			err = matchCachedLicense(payload, cached)
+		}
+	}
+	if err != nil {
+		c.stats.cacheMisses.Add(1)
+		c.quarantineCache(err)
+		return nil, err
+	}
+	if !c.cacheMatchesToken(cached, token) {
+		c.stats.cacheMisses.Add(1)
+		return nil, errCacheMismatch
+	}
+	c.stats.cacheHits.Add(1)
+	return cached, nil
+}
+
+// cacheMatchesToken reports whether cached may stand in for token: it
+// carries token itself, or token is for the same license, as when the cache
+// holds a renewal of the configured token. A token that does not verify, as
+// in a cache fallback, must also carry the cached license key, so a cache
+// shared between clients never serves one client's license to another.
+func (c *Client) cacheMatchesToken(cached *License, token string) bool {
+	if token == "" || cached.SignedToken == token {
+		return true
+	}
+	if c.cfg.publicKey != nil {
+		if payload, err := c.verify(token); err == nil {
+			return payload.LicenseID == cached.LicenseID
+		}
+	}
+	payload, err := decodeTokenPayload(token)
+	return err == nil && payload.LicenseID == cached.LicenseID &&
+		payload.LicenseKey != "" && payload.LicenseKey == cached.LicenseKey
+}
+
+func (c *Client) quarantineCache(cause error) {
+	path, _ := c.cache.quarantine()
+	c.emitEvent(Event{Type: EventCacheTampered, Message: "cached license failed verification: " + cause.Error(), Data: path})
+}
+
+// MigrateCache rewrites the cached license file in the current format.
+// Older caches are readable without migration, but migrating ensures later
+// SDK versions can load them. An EventCacheMigrated event is emitted when the
+// file is rewritten; Data holds the previous format version.
+func (c *Client) MigrateCache() error {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+
+	from, migrated, err := c.cache.migrate()
+	if err != nil {
+		return err
+	}
+	if migrated {
+		c.emitEvent(Event{Type: EventCacheMigrated, Message: fmt.Sprintf("cache migrated from version %d to %d", from, cacheFormatVersion), Data: from})
+	}
+	return nil
 }
@@ -0,0 +1,95 @@
+package licenseedict
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCacheMigrationKeepsAge(t *testing.T) {
+	cm := newCacheManager("", "", t.TempDir(), false)
+	data, err := json.Marshal(&License{LicenseID: "lic-v1", Valid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.store.Save(data); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(cm.path(), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	file, version, err := cm.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 1 || !file.SavedAt.Equal(old) {
+		t.Fatalf("read v1 cache: version %d, saved at %v, want 1 and %v", version, file.SavedAt, old)
+	}
+
+	if _, migrated, err := cm.migrate(); err != nil || !migrated {
+		t.Fatalf("migrate: migrated %v, err %v", migrated, err)
+	}
+	file, version, err = cm.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != cacheFormatVersion || !file.SavedAt.Equal(old) {
+		t.Fatalf("migrated cache: version %d, saved at %v, want %d and %v", version, file.SavedAt, cacheFormatVersion, old)
+	}
+}
+
+// flakyStore is a CacheStore whose Load fails with err, recording deletes.
+type flakyStore struct {
+	data    []byte
+	err     error
+	deleted bool
+}
+
+func (s *flakyStore) Load() ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.data, nil
+}
+
+func (s *flakyStore) Save(data []byte) error { s.data = data; return nil }
+
+func (s *flakyStore) Delete() error { s.deleted = true; s.data = nil; return nil }
+
+func TestCacheQuarantineOnlyWhenCorrupt(t *testing.T) {
+	tests := []struct {
+		name       string
+		store      *flakyStore
+		quarantine bool
+	}{
+		{"read error", &flakyStore{data: []byte(`{"version":2}`), err: errors.New("i/o timeout")}, false},
+		{"not found", &flakyStore{err: os.ErrNotExist}, false},
+		{"newer version", &flakyStore{data: []byte(`{"version":99}`)}, false},
+		{"corrupt", &flakyStore{data: []byte(`{"version":`)}, true},
+		{"bad license", &flakyStore{data: []byte(`{"version":2,"license":{"license_id":7}}`)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(WithCacheStore(tt.store))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			go func() {
+				for range c.Events {
+				}
+			}()
+
+			if _, err := c.loadVerifiedCache(""); err == nil {
+				t.Fatal("loadVerifiedCache succeeded")
+			}
+			if tt.store.deleted != tt.quarantine {
+				t.Fatalf("quarantined = %v, want %v", tt.store.deleted, tt.quarantine)
+			}
+		})
+	}
+}
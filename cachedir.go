@@ -0,0 +1,89 @@
+package licenseedict
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/adrg/xdg"
+)
+
+// CacheLocation selects where the license cache is stored when no directory
+// is set with WithCacheDir. Paths are joined with the publisher and
+// application name from WithAppInfo.
+type CacheLocation int
+
+const (
+	// CacheLocationDefault uses the user cache directory when WithAppInfo is
+	// set and a shared directory under os.TempDir otherwise.
+	CacheLocationDefault CacheLocation = iota
+	// CacheLocationUserCache is the per-user cache directory
+	// ($XDG_CACHE_HOME, ~/Library/Caches, %LOCALAPPDATA%\cache). The OS
+	// may clear it.
+	CacheLocationUserCache
+	// CacheLocationUserState is the per-user state directory
+	// ($XDG_STATE_HOME, ~/Library/Application Support, %LOCALAPPDATA%),
+	// which survives cache cleaning.
+	CacheLocationUserState
+	// CacheLocationUserData is the per-user data directory
+	// ($XDG_DATA_HOME, ~/Library/Application Support, %LOCALAPPDATA%).
+	CacheLocationUserData
+	// CacheLocationSystem is a machine-wide directory for services running
+	// as root or LocalSystem: /var/lib on Linux, /Library/Application
+	// Support on macOS and %ProgramData% on Windows.
+	CacheLocationSystem
+)
+
+// WithCacheLocation selects the cache directory by OS convention. It is
+// ignored when WithCacheDir is set.
+func WithCacheLocation(loc CacheLocation) Option {
+	return func(c *clientConfig) {
+		c.cacheLocation = loc
+	}
+}
+
+// WithSystemScope configures the client for a system service running as root
+// or LocalSystem, storing the license cache in the machine-wide location
+// rather than in the service account's home directory.
+func WithSystemScope() Option {
+	return func(c *clientConfig) {
+		c.cacheLocation = CacheLocationSystem
+	}
+}
+
+// cacheDirFor returns the cache directory for loc, or "" for
+// CacheLocationDefault so that newCacheManager applies its default.
+func cacheDirFor(loc CacheLocation, appName, appPublisher string) string {
+	var base string
+	switch loc {
+	case CacheLocationUserCache:
+		base = xdg.CacheHome
+	case CacheLocationUserState:
+		base = xdg.StateHome
+	case CacheLocationUserData:
+		base = xdg.DataHome
+	case CacheLocationSystem:
+		base = systemDataDir()
+	default:
+		return ""
+	}
+	if appName == "" {
+		appName = "licenseedict"
+	}
+	return filepath.Join(base, appPublisher, appName)
+}
+
+// systemDataDir returns the machine-wide application data directory.
+func systemDataDir() string {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("ProgramData"); dir != "" {
+			return dir
+		}
+		return `C:\ProgramData`
+	case "darwin":
+		return "/Library/Application Support"
+	default:
+		return "/var/lib"
+	}
+}
@@ -0,0 +1,74 @@
+package licenseedict
+
+import (
+	"strings"
+	"sync"
+)
+
+const capabilitiesHeader = "X-LicenseEdict-Capabilities"
+
+// Known server capability names reported in the X-LicenseEdict-Capabilities
+// response header.
+const (
+	CapabilityBorrowing      = "borrowing"
+	CapabilityBatchHeartbeat = "batch-heartbeat"
+)
+
+// ServerCapabilities describes the optional features a server reported in
+// its most recent response.
+type ServerCapabilities struct {
+	// Features lists the capability names advertised by the server.
+	Features []string
+	// APIVersion is the API version in use with the server.
+	APIVersion int
+}
+
+// Has returns true if the server advertised the named capability.
+func (s ServerCapabilities) Has(name string) bool {
+	for _, f := range s.Features {
+		if strings.EqualFold(f, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// capabilityStore holds the last capability set reported by the server.
+type capabilityStore struct {
+	mu       sync.RWMutex
+	features []string
+	known    bool
+}
+
+// update parses a comma-separated capability header. Responses without the
+// header leave the previously reported set in place.
+func (s *capabilityStore) update(header string) {
+	if header == "" {
+		return
+	}
+	var features []string
+	for _, f := range strings.Split(header, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			features = append(features, f)
+		}
+	}
+	s.mu.Lock()
+	s.features = features
+	s.known = true
+	s.mu.Unlock()
+}
+
+func (s *capabilityStore) get() ([]string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.features...), s.known
+}
+
+// ServerCapabilities returns the capabilities reported by the server in its
+// most recent response. The boolean is false until the server has reported
+// any capabilities, which lets callers distinguish an older server from one
+// that supports no optional features.
+func (c *Client) ServerCapabilities() (ServerCapabilities, bool) {
+	features, known := c.http.capabilities.get()
+	return ServerCapabilities{Features: features, APIVersion: c.APIVersion()}, known
+}
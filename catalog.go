@@ -0,0 +1,112 @@
+package licenseedict
+
+import (
+	"context"
+	"fmt"
+)
+
+// ProductInfo is the server's definition of the licensed product and its
+// plans. Applications can render plan comparisons from it instead of
+// hardcoding plan names and feature lists that drift from the entitlements
+// the server actually grants.
+type ProductInfo struct {
+	ProductID string `json:"product_id"`
+	Name      string `json:"name"`
+	// Plans are ordered as the server lists them, usually from the
+	// smallest plan to the largest.
+	Plans []PlanInfo `json:"plans"`
+	// Features describes the features named in the plans.
+	Features []FeatureInfo `json:"features,omitempty"`
+}
+
+// PlanInfo describes a plan of the product.
+type PlanInfo struct {
+	// ID is the plan identifier found in License.Plan.
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Features are the IDs of the features the plan grants.
+	Features []string `json:"features"`
+	// MaxSeats is zero for plans without a seat limit.
+	MaxSeats int `json:"max_seats,omitempty"`
+	// Price is the list price of the plan, if the server reports one.
+	Price *Price `json:"price,omitempty"`
+}
+
+// FeatureInfo describes a feature of the product.
+type FeatureInfo struct {
+	// ID is the feature name found in License.Features.
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Plan returns the plan with the given ID, such as License.Plan.
+func (p *ProductInfo) Plan(id string) (PlanInfo, bool) {
+	for _, plan := range p.Plans {
+		if plan.ID == id {
+			return plan, true
+		}
+	}
+	return PlanInfo{}, false
+}
+
+// Feature returns the description of the feature with the given ID.
+func (p *ProductInfo) Feature(id string) (FeatureInfo, bool) {
+	for _, feature := range p.Features {
+		if feature.ID == id {
+			return feature, true
+		}
+	}
+	return FeatureInfo{}, false
+}
+
+// ProductInfo fetches the plan definitions, feature lists and display names
+// of the current token's product from the server.
+//
+// A token the server rejects yields a ValidationError with code
+// ReactivationRequired, or LicenseRevoked or LicenseSuspended if the server
+// says so; a product the server does not know yields ProductMismatch and
+// any other client error RequestRejected.
+func (c *Client) ProductInfo(ctx context.Context) (*ProductInfo, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if err := c.requireServer(); err != nil {
+		return nil, err
+	}
+	token := c.currentToken()
+	if token == "" {
+		return nil, ErrNoToken
+	}
+
+	body := map[string]string{
+		"signed_token": token,
+	}
+
+	var resp struct {
+		ProductInfo
+		Status string `json:"status,omitempty"`
+		Reason string `json:"reason,omitempty"`
+	}
+	res, err := c.callServer(ctx, methodPost, c.cfg.endpoints.Product, productPath, body, &resp)
+	if err != nil {
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: "product info request failed", Err: err})
+	}
+	switch {
+	case res.StatusCode == statusOK:
+		return &resp.ProductInfo, nil
+	case res.StatusCode == statusForbidden && resp.Status == heartbeatStatusRevoked:
+		return nil, res.annotate(&ValidationError{Code: LicenseRevoked, Message: "server rejected product info request: license revoked"})
+	case res.StatusCode == statusForbidden && resp.Status == heartbeatStatusSuspended:
+		c.setSuspended(res, true, resp.Reason)
+		return nil, res.annotate(suspendedError(resp.Reason))
+	case res.StatusCode == statusUnauthorized, res.StatusCode == statusForbidden:
+		return nil, res.annotate(&ValidationError{Code: ReactivationRequired, Message: fmt.Sprintf("server rejected product info token with status %d", res.StatusCode)})
+	case res.StatusCode == statusNotFound:
+		return nil, res.annotate(&ValidationError{Code: ProductMismatch, Message: "server does not know the license's product"})
+	case res.StatusCode >= 400 && res.StatusCode < 500:
+		return nil, res.annotate(&ValidationError{Code: RequestRejected, Message: fmt.Sprintf("product info returned status %d", res.StatusCode)})
+	}
+	return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: fmt.Sprintf("product info returned status %d", res.StatusCode)})
+}
@@ -1,46 +1,202 @@
 package licenseedict
 
 import (
+	"crypto/ed25519"
+	"errors"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Client is the main SDK entry point for full-featured license management.
 // Use NewClient to create an instance, and defer client.Close().
 type Client struct {
+	// cfg is not modified after NewClient returns. State that changes at
+	// runtime lives in the fields below, guarded by mu or atomic.
 	cfg         clientConfig
 	cache       *cacheManager
 	http        *httpClient
 	license     *License
 	signedToken string
-	mu          sync.RWMutex
-	hb          heartbeatState
-	closed      bool
+	// tokenServerURL is the server URL adopted from the first validated
+	// token when none was configured.
+	tokenServerURL string
+	mu             sync.RWMutex
+	hb             heartbeatState
+	servers        serverPool
+	prober         *latencyProber
+	bundle         *LicenseBundle
+	trustedKeys    []ed25519.PublicKey
+	devLicense     *License
+	closed         atomic.Bool
+	closeMu        sync.Mutex
+	// done is closed when Close starts, releasing emitters blocked on a
+	// full Events channel. eventsMu guards sends on Events against its
+	// close; eventsClosed is set once it is closed.
+	done         chan struct{}
+	eventsMu     sync.RWMutex
+	eventsClosed bool
+
+	droppedEvents eventCounter
+	stats         clientStats
+	renewWindow   atomic.Int64
+	suspended     atomic.Bool
+	lastContact   atomic.Int64
+	contactSaved  atomic.Int64
+	attestation   binaryAttestation
+
+	fingerprintOnce sync.Once
+	hostFP          HostFingerprint
+	site            siteReporter
+	watchdog        watchdog
+	audit           *auditLog
+	model           modelState
+	meter           meterBuffer
+	renewSchedule   *dailyWindow
+	quietHours      quietHours
+	renewTimer      *time.Timer
+	leases          map[*SeatLease]struct{}
+	recentEvents    recentLog[eventRecord]
+	gates           gateRegistry
+	resolution      *Resolution
 
 	// Events receives asynchronous status updates from background operations
-	// such as heartbeats and renewals. Events are delivered non-blocking;
-	// if the channel buffer is full, events are dropped silently.
+	// such as heartbeats and renewals. By default events are delivered
+	// non-blocking; if the channel buffer is full, events are dropped.
+	// See WithEventDeliveryMode, WithEventBufferSize, and WithEventOverflow.
 	Events chan Event
 }
 
 // NewClient creates a new Client configured with the provided options.
 func NewClient(opts ...Option) (*Client, error) {
-	cfg := clientConfig{}
+	cfg := clientConfig{eventBufferSize: eventsChannelSize}
 	for _, opt := range opts {
 		opt(&cfg)
 	}
+	if !networkEnabled && cfg.simulation == nil {
+		// Without network support there is nothing to renew or heartbeat
+		// against; run as WithOfflineOnly.
+		cfg.offlineOnly = true
+	}
+	if cfg.inMemoryState {
+		if cfg.audit != nil {
+			return nil, errors.New("licenseedict: WithAuditLog cannot be used with WithInMemoryState")
+		}
+		cfg.cacheStore = &memoryStore{}
+		cfg.registryScope = RegistryNone
+		if cfg.instanceID == "" {
+			cfg.instanceID = newRequestID()
+		}
+	}
+	if cfg.sharedState != nil {
+		cfg.cacheStore = nil
+		cfg.registryScope = RegistryNone
+	}
+	if cfg.cacheDir == "" {
+		cfg.cacheDir = cacheDirFor(cfg.cacheLocation, cfg.appName, cfg.appPublisher)
+	}
+	if cfg.registryScope != RegistryNone && cfg.cacheStore == nil {
+		store, err := NewRegistryStore(cfg.registryScope, cfg.appPublisher, cfg.appName)
+		if err != nil {
+			return nil, err
+		}
+		cfg.cacheStore = store
+	}
 
 	c := &Client{
 		cfg:    cfg,
 		cache:  newCacheManager(cfg.appName, cfg.appPublisher, cfg.cacheDir, cfg.disableCache),
-		http:   newHTTPClient(cfg.httpClient, cfg.httpTimeout, cfg.userAgent),
-		Events: make(chan Event, eventsChannelSize),
+		http:   newHTTPClient(&cfg),
+		done:   make(chan struct{}),
+		Events: make(chan Event, cfg.eventBufferSize),
+	}
+	if cfg.cacheStore != nil && !cfg.disableCache {
+		c.cache = newStoreCacheManager(cfg.cacheStore)
+	}
+	if cfg.sharedState != nil && !cfg.disableCache {
+		c.cache = newStoreCacheManager(cfg.sharedState.cacheStore(c.sharedCacheID))
+	}
+	c.recentEvents.limit = diagnosticsHistorySize
+	c.restoreUsage()
+	c.restoreLastContact()
+
+	if cfg.audit != nil {
+		audit, err := openAuditLog(*cfg.audit)
+		if err != nil {
+			return nil, err
+		}
+		c.audit = audit
+	}
+
+	if cfg.renewSchedule != "" {
+		schedule, err := parseDailyWindow("renewal schedule", cfg.renewSchedule, cfg.renewScheduleLoc)
+		if err != nil {
+			return nil, err
+		}
+		c.renewSchedule = schedule
+	}
+	for _, spec := range cfg.quietHours {
+		window, err := parseDailyWindow("quiet hours", spec, cfg.quietHoursLoc)
+		if err != nil {
+			return nil, err
+		}
+		c.quietHours = append(c.quietHours, window)
+	}
+
+	if cfg.simulation != nil && len(cfg.serverURLs) == 0 && cfg.serverURL == "" {
+		c.cfg.serverURL = simulatedServerURL
+	}
+	c.http.compress = cfg.compression
+	if cfg.networkBudget != nil {
+		c.http.budget = newNetworkBudget(*cfg.networkBudget)
+		c.http.budget.onThrottle = func(wait time.Duration) {
+			c.emitEvent(Event{Type: EventNetworkThrottled, Message: "request delayed " + wait.Round(time.Millisecond).String() + " by network budget", Data: wait})
+		}
+	}
+	if cfg.serverAuth != "" {
+		c.http.headers["Authorization"] = authorizationValue(cfg.serverAuth)
+	}
+	if cfg.appName != "" {
+		c.http.headers[appNameHeader] = cfg.appName
+	}
+	if cfg.appVersion != "" {
+		c.http.headers[appVersionHeader] = cfg.appVersion
+	}
+
+	if cfg.licenseFile != "" {
+		token, err := LoadLicenseFile(cfg.licenseFile)
+		if err != nil {
+			return nil, err
+		}
+		if c.cfg.token == "" {
+			c.cfg.token = token
+		}
+	}
+	if cfg.resolver != nil && c.cfg.token == "" {
+		res, err := cfg.resolver.Resolve()
+		if err != nil && !errors.Is(err, ErrNoToken) {
+			return nil, err
+		}
+		if res != nil {
+			c.resolution = res
+			c.cfg.token = res.Token
+		}
+	}
+	if cfg.bundlePath != "" {
+		if err := c.loadBundle(cfg.bundlePath); err != nil {
+			return nil, err
+		}
 	}
 
 	// If token is pre-configured, store it for later use by Validate()
-	if cfg.token != "" {
-		c.signedToken = cfg.token
+	if c.cfg.token != "" {
+		c.signedToken = c.cfg.token
 	}
 
+	c.startLatencyProbing()
+	c.maybeStartAudit()
+	c.RunTamperChecks()
+
 	return c, nil
 }
 
@@ -51,27 +207,63 @@ func (c *Client) License() *License {
 	return c.license
 }
 
-// Close releases resources. It stops the heartbeat but does NOT auto-checkout
-// (the seat will expire via TTL on the server).
+// Close releases resources. It stops the heartbeat and latency probing but does NOT auto-checkout
+// (the seat will expire via TTL on the server). Use Shutdown to check out first.
 func (c *Client) Close() error {
-	if c.closed {
+	if !c.onHeartbeatLoop() {
+		c.closeMu.Lock()
+	} else if !c.closeMu.TryLock() {
+		// A hook on the heartbeat loop: the Close in progress elsewhere is
+		// waiting for the loop, so this one must not wait for it in turn.
 		return nil
 	}
+	defer c.closeMu.Unlock()
+	if c.closed.Load() {
+		return nil
+	}
+	close(c.done)
 	c.StopHeartbeat()
-	c.closed = true
+	c.endLeases(ErrClientClosed)
+	c.stopLatencyProbing()
+	c.stopSiteReporting()
+	c.stopMetering()
+	c.stopWatchdog()
+	c.stopAudit()
+	c.mu.Lock()
+	// Set under mu so that scheduleRenewal cannot start another timer.
+	c.closed.Store(true)
+	if c.renewTimer != nil {
+		c.renewTimer.Stop()
+	}
+	c.mu.Unlock()
+	c.closeGates()
+
+	// Producers that outlive the stops above, such as a Validate running
+	// concurrently, see eventsClosed and drop their events.
+	c.eventsMu.Lock()
+	c.eventsClosed = true
 	close(c.Events)
+	c.eventsMu.Unlock()
 	return nil
 }
 
-// resolveServerURL returns the server URL from config or the license token.
+// resolveServerURL returns the preferred server URL from config or the
+// license token.
 func (c *Client) resolveServerURL() string {
-	if c.cfg.serverURL != "" {
-		return c.cfg.serverURL
+	if urls := c.servers.order(c.serverURLs()); len(urls) > 0 {
+		return urls[0]
 	}
+	return ""
+}
+
+// currentToken returns the active signed token, falling back to the token
+// configured via WithToken.
+func (c *Client) currentToken() string {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
-	if c.license != nil {
-		return c.license.ServerURL
+	token := c.signedToken
+	c.mu.RUnlock()
+	if token == "" {
+		token = c.cfg.token
 	}
-	return ""
+	return token
 }
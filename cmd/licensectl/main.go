@@ -0,0 +1,224 @@
+// Command licensectl is a support tool for applications using the
+// LicenseEdict SDK.
+//
+// Usage:
+//
+//	licensectl doctor [flags]
+//	licensectl verify-audit -public-key KEY [flags] <audit-log>
+//
+// The doctor command runs Client.SelfTest and prints the report. It exits
+// with status 1 if any check fails.
+//
+// The verify-audit command checks the signatures and hash chain of an audit
+// log written with licenseedict.WithAuditLog against the public key of the
+// licensee's signing key and prints a usage summary. Pass -head with the
+// last anchor the server received to detect records removed from the end.
+// It exits with status 1 if verification fails.
+//
+// The standard LICENSEEDICT_* environment variables (LICENSEEDICT_TOKEN,
+// LICENSEEDICT_TOKEN_FILE, LICENSEEDICT_PUBLIC_KEY, LICENSEEDICT_SERVER_URL,
+// LICENSEEDICT_CACHE_DIR and LICENSEEDICT_OFFLINE) configure the client;
+// flags override them.
+//
+// Configuration and license errors exit with the status recommended by
+// licenseedict.ExitCode, such as 78 for a malformed variable.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "doctor":
+		os.Exit(doctor(os.Args[2:]))
+	case "verify-audit":
+		os.Exit(verifyAudit(os.Args[2:]))
+	case "help", "-h", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "licensectl: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: licensectl <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  doctor         check key, token, cache, clock and server connectivity")
+	fmt.Fprintln(os.Stderr, "  verify-audit   verify an audit log and summarize recorded usage")
+}
+
+func doctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	pubKey := fs.String("public-key", "", "base64 Ed25519 public key (default $LICENSEEDICT_PUBLIC_KEY or $LICENSE_PUBLIC_KEY)")
+	token := fs.String("token", "", "signed license token (default $LICENSEEDICT_TOKEN or $LICENSE_TOKEN)")
+	server := fs.String("server", "", "comma-separated license server URLs (default $LICENSEEDICT_SERVER_URL)")
+	appName := fs.String("app", "", "application name, used to locate the cache")
+	publisher := fs.String("publisher", "", "application publisher, used to locate the cache")
+	cacheDir := fs.String("cache-dir", "", "cache directory override")
+	timeout := fs.Duration("timeout", 10*time.Second, "overall time limit")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+
+	envOpts, err := licenseedict.OptionsFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "licensectl: %v\n", err)
+		return licenseedict.ExitCode(err)
+	}
+	opts := append(envOpts, licenseedict.WithAppInfo(*appName, *publisher))
+	// Flags override the standard variables, which override the older
+	// LICENSE_* names.
+	if *pubKey == "" {
+		*pubKey = fallbackEnv("LICENSE_PUBLIC_KEY", licenseedict.EnvPublicKey)
+	}
+	if *token == "" {
+		*token = fallbackEnv("LICENSE_TOKEN", licenseedict.EnvToken, licenseedict.EnvTokenFile)
+	}
+	if *pubKey != "" {
+		opts = append(opts, licenseedict.WithPublicKey(*pubKey))
+	}
+	if *token != "" {
+		opts = append(opts, licenseedict.WithToken(*token))
+	}
+	if *server != "" {
+		opts = append(opts, licenseedict.WithServerURLs(strings.Split(*server, ",")...))
+	}
+	if *cacheDir != "" {
+		opts = append(opts, licenseedict.WithCacheDir(*cacheDir))
+	}
+
+	client, err := licenseedict.NewClient(opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "licensectl: %v\n", err)
+		return licenseedict.ExitCode(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	report := client.SelfTest(ctx)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		for _, check := range report.Checks {
+			fmt.Printf("[%-4s] %-10s %s\n", strings.ToUpper(string(check.Status)), check.Name, check.Message)
+		}
+	}
+
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
+
+// fallbackEnv returns the value of the variable name, or "" if any of the
+// variables in preferred is set.
+func fallbackEnv(name string, preferred ...string) string {
+	for _, p := range preferred {
+		if strings.TrimSpace(os.Getenv(p)) != "" {
+			return ""
+		}
+	}
+	return os.Getenv(name)
+}
+
+func verifyAudit(args []string) int {
+	fs := flag.NewFlagSet("verify-audit", flag.ExitOnError)
+	pubKey := fs.String("public-key", "", "base64 Ed25519 public key of the signing key provisioned for the licensee (required)")
+	after := fs.String("after", "", "anchor SEQ:HASH of the record before the first one in the log, for rotated logs")
+	head := fs.String("head", "", "last anchor SEQ:HASH the server received; the log must reach it")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 1 || *pubKey == "" {
+		fmt.Fprintln(os.Stderr, "Usage: licensectl verify-audit -public-key KEY [flags] <audit-log>")
+		return 2
+	}
+
+	key, err := licenseedict.DecodePublicKey(*pubKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "licensectl: -public-key: %v\n", err)
+		return 2
+	}
+	var opts licenseedict.AuditVerifyOptions
+	if opts.After, err = parseAnchor(*after); err != nil {
+		fmt.Fprintf(os.Stderr, "licensectl: -after: %v\n", err)
+		return 2
+	}
+	if opts.Head, err = parseAnchor(*head); err != nil {
+		fmt.Fprintf(os.Stderr, "licensectl: -head: %v\n", err)
+		return 2
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "licensectl: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	report, err := licenseedict.VerifyAuditLog(f, key, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "licensectl: %v\n", err)
+		if report != nil && report.Snapshots > 0 {
+			fmt.Fprintf(os.Stderr, "licensectl: %d records verified before the failure\n", report.Snapshots)
+		}
+		return 1
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+		return 0
+	}
+	fmt.Printf("OK: %d snapshots from %s to %s\n", report.Snapshots, report.First.Format(time.RFC3339), report.Last.Format(time.RFC3339))
+	fmt.Printf("signing key:   %s\n", report.PublicKey)
+	fmt.Printf("head:          %d:%s\n", report.Head.Seq, report.Head.Hash)
+	fmt.Printf("validations:   %d\n", report.Validations)
+	fmt.Printf("heartbeats:    %d\n", report.Heartbeats)
+	fmt.Printf("peak sessions: %d\n", report.PeakSessions)
+	features := make([]string, 0, len(report.FeaturesUsed))
+	for f := range report.FeaturesUsed {
+		features = append(features, f)
+	}
+	sort.Strings(features)
+	for _, f := range features {
+		fmt.Printf("feature %-20s %d\n", f, report.FeaturesUsed[f])
+	}
+	return 0
+}
+
+// parseAnchor parses an audit log anchor given as SEQ:HASH. It returns nil
+// for an empty string.
+func parseAnchor(s string) (*licenseedict.AuditAnchor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	seq, hash, ok := strings.Cut(s, ":")
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if !ok || hash == "" || err != nil {
+		return nil, fmt.Errorf("%q is not SEQ:HASH", s)
+	}
+	return &licenseedict.AuditAnchor{Seq: n, Hash: hash}, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK/issuer"
+)
+
+// writeAuditLog writes an audit log of three snapshots to a temporary file
+// and returns its path, the base64 public key it verifies with and its head.
+func writeAuditLog(t *testing.T) (path, publicKey string, head licenseedict.AuditAnchor) {
+	t.Helper()
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := issuer.NewFromKey(key)
+	token, err := signer.Sign(issuer.Claims{LicenseID: "lic-audit", ProductID: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditPub, auditKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path = filepath.Join(t.TempDir(), "audit.log")
+	c, err := licenseedict.NewClient(
+		licenseedict.WithPublicKey(base64.StdEncoding.EncodeToString(pub)),
+		licenseedict.WithToken(token),
+		licenseedict.WithoutCache(),
+		licenseedict.WithAuditLog(licenseedict.AuditOptions{Path: path, SigningKey: auditKey}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for range c.Events {
+		}
+	}()
+	for i := 0; i < 2; i++ {
+		if err := c.WriteAuditSnapshot(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	report, err := licenseedict.VerifyAuditLog(f, auditPub, licenseedict.AuditVerifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, base64.StdEncoding.EncodeToString(auditPub), report.Head
+}
+
+func TestVerifyAudit(t *testing.T) {
+	path, publicKey, head := writeAuditLog(t)
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	truncated := filepath.Join(t.TempDir(), "truncated.log")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if err := os.WriteFile(truncated, []byte(strings.Join(lines[:2], "")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	headFlag := fmt.Sprintf("%d:%s", head.Seq, head.Hash)
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"valid", []string{"-public-key", publicKey, path}, 0},
+		{"valid as JSON", []string{"-public-key", publicKey, "-json", path}, 0},
+		{"valid to head", []string{"-public-key", publicKey, "-head", headFlag, path}, 0},
+		{"truncated before head", []string{"-public-key", publicKey, "-head", headFlag, truncated}, 1},
+		{"different key", []string{"-public-key", base64.StdEncoding.EncodeToString(otherPub), path}, 1},
+		{"missing file", []string{"-public-key", publicKey, path + ".missing"}, 1},
+		{"no public key", []string{path}, 2},
+		{"bad head", []string{"-public-key", publicKey, "-head", "head", path}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyAudit(tt.args); got != tt.want {
+				t.Fatalf("verifyAudit(%q) = %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAnchor(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *licenseedict.AuditAnchor
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"7:abc", &licenseedict.AuditAnchor{Seq: 7, Hash: "abc"}, false},
+		{"7", nil, true},
+		{"7:", nil, true},
+		{"x:abc", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAnchor(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parseAnchor(%q): err = %v, want error %v", tt.in, err, tt.wantErr)
+		}
+		if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+			t.Fatalf("parseAnchor(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -1,15 +1,30 @@
 package licenseedict
 
 import (
+	"context"
 	"fmt"
-	"net/http"
+	"math/rand/v2"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
 	defaultHeartbeatInterval = 30 * time.Second
 	eventsChannelSize        = 16
+
+	heartbeatStatusRegionRestricted = "region_restricted"
+	heartbeatStatusRevoked          = "revoked"
+	heartbeatStatusSuspended        = "suspended"
+
+	// wakeCheckInterval is how often the heartbeat loop samples the clocks
+	// to detect system suspend; a wall-clock gap of sleepGapThreshold beyond
+	// the monotonic one is treated as a resume from sleep.
+	wakeCheckInterval = 5 * time.Second
+	sleepGapThreshold = 30 * time.Second
 )
 
 // HeartbeatOptions configures the background heartbeat.
@@ -19,16 +34,45 @@ type HeartbeatOptions struct {
 	Hostname   string
 	IP         string
 	UserAgent  string
+
+	// Features lists the feature seat pools this instance occupies in
+	// addition to the base seat. See AcquireFeatureSeat.
+	Features []string
+
+	// ReservationID claims the seat reserved with ReserveSeat. It is set by
+	// Reservation.Activate.
+	ReservationID string
 }
 
 // heartbeatState holds the running heartbeat goroutine's control channels.
 type heartbeatState struct {
-	mu       sync.Mutex
-	running  bool
-	stopCh   chan struct{}
-	doneCh   chan struct{}
+	mu      sync.Mutex
+	running bool
+	cancel  context.CancelFunc
+	doneCh  chan struct{}
+	// kick asks the running loop for an immediate heartbeat; see
+	// requestHeartbeat.
+	kick     chan struct{}
 	opts     HeartbeatOptions
 	interval time.Duration
+	// auto is set while the loop runs on behalf of the license model; an
+	// explicit start adopts it, see startHeartbeat.
+	auto bool
+	// loopID is the goroutine ID of the running loop, or 0 until it has
+	// started; see onHeartbeatLoop.
+	loopID uint64
+
+	// statusMu guards the last heartbeat response independently of mu so
+	// readers never wait on heartbeat start/stop.
+	statusMu sync.Mutex
+	last     *HeartbeatStatus
+	lastAt   time.Time
+	lastErr  error
+	// clientIP is the client address the server last reported seeing.
+	clientIP string
+
+	// offline is set when the last heartbeat could not reach the server.
+	offline atomic.Bool
 }
 
 // StartHeartbeat starts a background goroutine that sends periodic heartbeats.
@@ -38,32 +82,65 @@ type heartbeatState struct {
 // the instance ID from WithInstanceID is used and the heartbeat interval from
 // WithHeartbeatInterval is applied.
 //
-// Events are delivered non-blocking to the returned channel. If the channel
-// buffer is full, events are dropped silently.
+// If no license has been validated yet, the configured token is validated
+// first and its error, if any, is returned.
+//
+// If Validate already started heartbeats for a floating license, the loop is
+// restarted with opts instead of failing with ErrAlreadyRunning.
+//
+// Events are delivered to the returned channel according to the mode set by
+// WithEventDeliveryMode; by default they are dropped if the buffer is full.
 func (c *Client) StartHeartbeat(opts ...HeartbeatOptions) (<-chan Event, error) {
-	if c.closed {
+	return c.StartHeartbeatContext(context.Background(), opts...)
+}
+
+// StartHeartbeatContext is like StartHeartbeat, but the heartbeat loop and its
+// in-flight requests are bound to ctx: cancelling ctx stops the heartbeat just
+// as StopHeartbeat does.
+func (c *Client) StartHeartbeatContext(ctx context.Context, opts ...HeartbeatOptions) (<-chan Event, error) {
+	return c.startHeartbeat(ctx, nil, false, opts...)
+}
+
+// startHeartbeat starts the heartbeat loop. If first is non-nil, the result
+// of the initial heartbeat is sent on it; it must have room for one value.
+// auto marks a loop started for the license model, which a later explicit
+// start stops and replaces so that its options take effect.
+func (c *Client) startHeartbeat(ctx context.Context, first chan<- error, auto bool, opts ...HeartbeatOptions) (<-chan Event, error) {
+	if c.closed.Load() {
 		return nil, ErrClientClosed
 	}
 
+	// An explicit start takes over heartbeats from the license model, so
+	// the validation below does not start a loop of its own. Validate
+	// outside hb.mu: a floating license starts heartbeats from Validate.
+	if !auto {
+		c.model.heartbeatOnce.Do(func() {})
+	}
+	if c.License() == nil && c.currentToken() != "" {
+		if _, err := c.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	c.hb.mu.Lock()
 	defer c.hb.mu.Unlock()
 
+	if c.hb.running && !auto && c.hb.auto {
+		cancel, done := c.hb.cancel, c.hb.doneCh
+		c.hb.mu.Unlock()
+		cancel()
+		<-done
+		c.hb.mu.Lock()
+	}
 	if c.hb.running {
 		return nil, ErrAlreadyRunning
 	}
 
-	serverURL := c.resolveServerURL()
-	if serverURL == "" {
-		return nil, ErrNoServerURL
+	if err := c.requireServer(); err != nil {
+		return nil, err
 	}
 
-	c.mu.RLock()
-	token := c.signedToken
-	c.mu.RUnlock()
-
-	if token == "" {
-		token = c.cfg.token
-	}
+	token := c.currentToken()
 	if token == "" {
 		return nil, ErrNoToken
 	}
@@ -83,126 +160,407 @@ func (c *Client) StartHeartbeat(opts ...HeartbeatOptions) (<-chan Event, error)
 	}
 
 	c.hb.running = true
+	c.hb.auto = auto
 	c.hb.opts = hbOpts
 	c.hb.interval = interval
-	c.hb.stopCh = make(chan struct{})
+	loopCtx, cancel := context.WithCancel(ctx)
+	c.hb.cancel = cancel
 	c.hb.doneCh = make(chan struct{})
+	c.hb.kick = make(chan struct{}, 1)
 
-	go c.heartbeatLoop(serverURL, token, c.hb.stopCh, c.hb.doneCh)
+	go c.heartbeatLoop(loopCtx, c.hb.doneCh, c.hb.kick, hbOpts, first)
 	return c.Events, nil
 }
 
-// StopHeartbeat stops the background heartbeat goroutine.
+// StopHeartbeat stops the background heartbeat goroutine, cancelling any
+// in-flight heartbeat request, and waits for it to exit.
 func (c *Client) StopHeartbeat() {
 	c.hb.mu.Lock()
-	defer c.hb.mu.Unlock()
-
 	if !c.hb.running {
+		c.hb.mu.Unlock()
+		return
+	}
+	cancel, done, loopID := c.hb.cancel, c.hb.doneCh, c.hb.loopID
+	c.hb.mu.Unlock()
+
+	cancel()
+	if loopID != 0 && loopID == goroutineID() {
+		// Called from a hook on the loop itself, which exits once the hook
+		// returns; waiting for it would deadlock.
 		return
 	}
+	// Wait without holding hb.mu: the loop takes it to update the interval.
+	<-done
+}
 
-	close(c.hb.stopCh)
-	<-c.hb.doneCh
-	c.hb.running = false
+// onHeartbeatLoop reports whether the caller runs on the heartbeat loop, as
+// hooks and handlers called by a heartbeat do.
+func (c *Client) onHeartbeatLoop() bool {
+	c.hb.mu.Lock()
+	loopID := c.hb.loopID
+	c.hb.mu.Unlock()
+	return loopID != 0 && loopID == goroutineID()
 }
 
+// goroutineID returns the ID of the calling goroutine, parsed from the
+// "goroutine N [...]" header of its stack trace, or 0 if it cannot be read.
+func goroutineID() uint64 {
+	var buf [64]byte
+	s := strings.TrimPrefix(string(buf[:runtime.Stack(buf[:], false)]), "goroutine ")
+	if i := strings.IndexByte(s, ' '); i > 0 {
+		if id, err := strconv.ParseUint(s[:i], 10, 64); err == nil {
+			return id
+		}
+	}
+	return 0
+}
+
+// CheckoutOptions identifies the seat to release with CheckoutContext.
+type CheckoutOptions struct {
+	// InstanceID is the instance whose seat is released. It defaults to the
+	// instance of the running or last heartbeat, then to WithInstanceID.
+	InstanceID string
+	UserHash   string
+	// Features lists feature seat pools to release along with the seat.
+	Features []string
+}
+
+// CheckoutResult describes the outcome of a checkout.
+type CheckoutResult struct {
+	// Released reports whether the seat is no longer held. It is true both
+	// when this call released it and when the server no longer knew the
+	// seat, for example because a previous, retried checkout already
+	// released it or it timed out.
+	Released bool `json:"released"`
+	// AlreadyReleased is set when the server did not find the seat.
+	AlreadyReleased bool `json:"already_released,omitempty"`
+	// RemainingSessions is the number of seats still in use on the license,
+	// or -1 if the server did not report it.
+	RemainingSessions int `json:"remaining_sessions"`
+	// Message is an optional message from the server.
+	Message string `json:"message,omitempty"`
+}
+
+// checkoutStatusNotFound is the status servers report for an unknown or
+// already released seat.
+const checkoutStatusNotFound = "not_found"
+
 // Checkout releases the seat on the server and stops the heartbeat.
-func (c *Client) Checkout() error {
-	if c.closed {
-		return ErrClientClosed
+func (c *Client) Checkout() (*CheckoutResult, error) {
+	return c.CheckoutContext(context.Background())
+}
+
+// CheckoutContext is like Checkout but takes a context and lets the caller
+// name the seat to release. It does not depend on StartHeartbeat having been
+// called, so a process can release a seat held by another instance.
+//
+// A seat the server does not know (404 or 410) is treated as released rather
+// than as an error, so shutdown paths can retry checkout safely; see
+// CheckoutResult.AlreadyReleased.
+func (c *Client) CheckoutContext(ctx context.Context, opts ...CheckoutOptions) (*CheckoutResult, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
 	}
 
 	// Stop heartbeat first
 	c.StopHeartbeat()
 
-	serverURL := c.resolveServerURL()
-	if serverURL == "" {
-		return ErrNoServerURL
+	if err := c.requireServer(); err != nil {
+		return nil, err
 	}
 
-	c.mu.RLock()
-	token := c.signedToken
-	c.mu.RUnlock()
-
+	token := c.currentToken()
 	if token == "" {
-		return ErrNoToken
+		return nil, ErrNoToken
 	}
 
+	c.hb.mu.Lock()
+	hbOpts := c.hb.opts
+	c.hb.mu.Unlock()
+
+	if len(opts) > 0 {
+		o := opts[0]
+		if o.InstanceID != "" {
+			hbOpts.InstanceID = o.InstanceID
+		}
+		if o.UserHash != "" {
+			hbOpts.UserHash = o.UserHash
+		}
+		if o.Features != nil {
+			hbOpts.Features = o.Features
+		}
+	}
+	if hbOpts.InstanceID == "" {
+		hbOpts.InstanceID = c.cfg.instanceID
+	}
+
+	return c.checkout(ctx, token, hbOpts)
+}
+
+// checkout releases the seat held by the instance described by opts.
+func (c *Client) checkout(ctx context.Context, token string, opts HeartbeatOptions) (*CheckoutResult, error) {
 	body := map[string]interface{}{
 		"signed_token": token,
-		"instance_id":  c.hb.opts.InstanceID,
+		"instance_id":  opts.InstanceID,
+	}
+	if opts.UserHash != "" && !c.cfg.minimalHeartbeat && c.TelemetryConsent() == TelemetryFull {
+		body["user_hash"] = opts.UserHash
 	}
-	if c.hb.opts.UserHash != "" {
-		body["user_hash"] = c.hb.opts.UserHash
+	if len(opts.Features) > 0 {
+		body["features"] = opts.Features
 	}
 
 	var resp struct {
-		Status string `json:"status"`
+		Status            string `json:"status"`
+		Message           string `json:"message"`
+		RemainingSessions *int   `json:"remaining_sessions"`
+	}
+
+	res, err := c.callServer(ctx, methodDelete, c.cfg.endpoints.Checkout, checkoutPath, body, &resp)
+	if err != nil && res.StatusCode == 0 {
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: "checkout request failed", Err: err})
+	}
+
+	result := &CheckoutResult{RemainingSessions: -1, Message: resp.Message}
+	if resp.RemainingSessions != nil {
+		result.RemainingSessions = *resp.RemainingSessions
+	}
+	switch {
+	case res.StatusCode == statusNotFound, res.StatusCode == statusGone,
+		res.StatusCode == statusOK && resp.Status == checkoutStatusNotFound:
+		result.Released = true
+		result.AlreadyReleased = true
+		return result, nil
+	case res.StatusCode != statusOK:
+		return result, res.annotate(&ValidationError{Code: ServerUnreachable, Message: fmt.Sprintf("checkout returned status %d", res.StatusCode), Err: err})
 	}
 
-	url := fmt.Sprintf("%s/api/v1/concurrency/checkout", serverURL)
-	statusCode, err := c.http.deleteJSON(url, body, &resp)
+	result.Released = true
+	c.emitEvent(res.event(Event{Type: EventSeatReleased, Message: "seat released", Data: result}))
+	return result, nil
+}
+
+// SeatUsage queries the server for the license's current seat occupancy.
+// It is read-only: it neither starts a heartbeat nor consumes a seat, so it
+// is suitable for admin UIs that display usage.
+func (c *Client) SeatUsage(ctx context.Context) (*SeatUsage, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+
+	if err := c.requireServer(); err != nil {
+		return nil, err
+	}
+
+	token := c.currentToken()
+	if token == "" {
+		return nil, ErrNoToken
+	}
+
+	body := map[string]string{
+		"signed_token": token,
+	}
+
+	var usage SeatUsage
+	res, err := c.callServer(ctx, methodPost, c.cfg.endpoints.SeatUsage, seatUsagePath, body, &usage)
 	if err != nil {
-		return &ValidationError{Code: ServerUnreachable, Message: "checkout request failed", Err: err}
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: "seat usage request failed", Err: err})
 	}
 
-	if statusCode != http.StatusOK {
-		return &ValidationError{Code: ServerUnreachable, Message: fmt.Sprintf("checkout returned status %d", statusCode)}
+	if res.StatusCode != statusOK {
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: fmt.Sprintf("seat usage returned status %d", res.StatusCode)})
 	}
 
-	c.emitEvent(Event{Type: EventSeatReleased, Message: "seat released"})
-	return nil
+	return &usage, nil
 }
 
-func (c *Client) heartbeatLoop(serverURL, token string, stopCh, doneCh chan struct{}) {
+// heartbeatLoop sends heartbeats for the instance described by opts, a copy
+// of hb.opts taken under hb.mu, until ctx is done.
+func (c *Client) heartbeatLoop(ctx context.Context, doneCh, kick chan struct{}, opts HeartbeatOptions, first chan<- error) {
 	defer close(doneCh)
+	defer func() {
+		c.hb.mu.Lock()
+		if c.hb.doneCh == doneCh {
+			c.hb.running = false
+			c.hb.loopID = 0
+		}
+		c.hb.mu.Unlock()
+	}()
+	c.hb.mu.Lock()
+	if c.hb.doneCh == doneCh {
+		c.hb.loopID = goroutineID()
+	}
+	c.hb.mu.Unlock()
 
 	// Send initial heartbeat immediately
-	c.sendHeartbeat(serverURL, token)
+	_, err := c.sendHeartbeat(ctx, c.heartbeatToken(), opts)
+	if first != nil {
+		first <- err
+	}
 
-	ticker := time.NewTicker(c.hb.interval)
+	// The first heartbeat may have changed the interval.
+	c.hb.mu.Lock()
+	interval := c.hb.interval
+	c.hb.mu.Unlock()
+	ticker := time.NewTicker(c.jitter(interval))
 	defer ticker.Stop()
 
+	// The wall clock keeps advancing while the OS is suspended but the
+	// monotonic clock does not, so the wall clock running ahead of it
+	// between wake checks means the host was asleep. Time spent in slow
+	// calls advances both and is not mistaken for sleep.
+	wake := time.NewTicker(wakeCheckInterval)
+	defer wake.Stop()
+	lastWake := time.Now()
+
+	var netCh <-chan struct{}
+	if w := c.networkWatcher(); w != nil {
+		netCh = w.Watch(ctx)
+	}
+
 	for {
 		select {
-		case <-stopCh:
+		case <-ctx.Done():
 			return
+		case _, ok := <-netCh:
+			if !ok {
+				netCh = nil
+				continue
+			}
+			// Only retry if the last attempt failed; a healthy connection
+			// keeps its regular schedule. A renewal that is due is retried
+			// by the next Validate.
+			if !c.hb.offline.Load() {
+				continue
+			}
+			c.sendHeartbeat(ctx, c.heartbeatToken(), opts)
+		case <-kick:
+			c.sendHeartbeat(ctx, c.heartbeatToken(), opts)
+			c.hb.mu.Lock()
+			currentInterval := c.hb.interval
+			c.hb.mu.Unlock()
+			ticker.Reset(c.jitter(currentInterval))
+		case <-wake.C:
+			now := time.Now()
+			slept := now.Round(0).Sub(lastWake.Round(0)) - now.Sub(lastWake)
+			lastWake = now
+			if slept < sleepGapThreshold {
+				continue
+			}
+			c.emitEvent(Event{Type: EventResumedFromSleep, Message: fmt.Sprintf("resumed after %s asleep", slept.Round(time.Second)), Data: slept})
+			_, _ = c.Validate()
+			c.sendHeartbeat(ctx, c.heartbeatToken(), opts)
+			c.hb.mu.Lock()
+			currentInterval := c.hb.interval
+			c.hb.mu.Unlock()
+			ticker.Reset(c.jitter(currentInterval))
 		case <-ticker.C:
-			c.sendHeartbeat(serverURL, token)
+			c.sendHeartbeat(ctx, c.heartbeatToken(), opts)
 
 			// Adapt ticker if interval changed
 			c.hb.mu.Lock()
 			currentInterval := c.hb.interval
 			c.hb.mu.Unlock()
-			ticker.Reset(currentInterval)
+			ticker.Reset(c.jitter(currentInterval))
 		}
 	}
 }
 
-func (c *Client) sendHeartbeat(serverURL, token string) {
-	body := map[string]interface{}{
-		"signed_token": token,
-		"instance_id":  c.hb.opts.InstanceID,
-		"metadata": map[string]string{
-			"hostname":   c.hb.opts.Hostname,
-			"ip":         c.hb.opts.IP,
-			"user_agent": c.hb.opts.UserAgent,
-			"user_hash":  c.hb.opts.UserHash,
-		},
+// requestHeartbeat asks the running heartbeat loop to send a heartbeat now.
+// The loop sends it in turn with its scheduled ones, so heartbeats never
+// overlap; requests made while one is pending are merged. It is a no-op if
+// no loop is running.
+func (c *Client) requestHeartbeat() {
+	c.hb.mu.Lock()
+	defer c.hb.mu.Unlock()
+	if !c.hb.running {
+		return
 	}
+	select {
+	case c.hb.kick <- struct{}{}:
+	default:
+	}
+}
 
-	var resp HeartbeatStatus
-	url := fmt.Sprintf("%s/api/v1/concurrency/heartbeat", serverURL)
-	statusCode, err := c.http.postJSON(url, body, &resp)
+// heartbeatToken returns the token the heartbeat loop should send. It is read
+// on every tick so tokens installed by renewal, SetToken or reactivation take
+// effect without restarting the loop.
+func (c *Client) heartbeatToken() string {
+	return c.currentToken()
+}
 
-	if err != nil {
-		c.emitEvent(Event{Type: EventHeartbeatError, Message: err.Error()})
-		return
+// networkWatcher returns the NetworkWatcher set with WithNetworkWatcher, or
+// nil if the network is not watched.
+func (c *Client) networkWatcher() NetworkWatcher {
+	if c.cfg.offlineOnly {
+		return nil
+	}
+	return c.cfg.networkWatcher
+}
+
+// jitter randomizes d by up to ±heartbeatJitter so instances started at the
+// same time do not heartbeat in lockstep.
+func (c *Client) jitter(d time.Duration) time.Duration {
+	f := c.cfg.heartbeatJitter
+	if f <= 0 {
+		return d
 	}
+	if f > 1 {
+		f = 1
+	}
+	delta := time.Duration((rand.Float64()*2 - 1) * f * float64(d))
+	if d+delta <= 0 {
+		return d
+	}
+	return d + delta
+}
 
-	switch statusCode {
-	case http.StatusOK:
-		c.emitEvent(Event{Type: EventHeartbeatOK, Message: "heartbeat accepted", Data: resp})
+// sendHeartbeat sends a single heartbeat for the instance described by opts
+// and records its outcome for health reporting.
+func (c *Client) sendHeartbeat(ctx context.Context, token string, opts HeartbeatOptions) (*HeartbeatStatus, error) {
+	status, err := c.heartbeatOnce(ctx, token, opts)
+	if ctx.Err() == nil {
+		c.stats.heartbeatsSent.Add(1)
+		if err != nil {
+			c.stats.heartbeatsFailed.Add(1)
+			c.enforceOfflineLimit()
+		}
+		c.hb.statusMu.Lock()
+		c.hb.lastErr = err
+		c.hb.statusMu.Unlock()
+	}
+	return status, err
+}
+
+// heartbeatOnce sends a single heartbeat, emits the matching event, and
+// records the server's response for LastHeartbeat.
+func (c *Client) heartbeatOnce(ctx context.Context, token string, opts HeartbeatOptions) (*HeartbeatStatus, error) {
+	resp, res, err := c.postHeartbeat(ctx, token, opts)
+
+	if err != nil && ctx.Err() != nil {
+		// Cancelled by StopHeartbeat or the caller; not a server failure.
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		c.hb.offline.Store(true)
+		c.emitEvent(res.event(Event{Type: EventHeartbeatError, Message: err.Error()}))
+		hbErr := res.annotate(&ValidationError{Code: ServerUnreachable, Message: "heartbeat request failed", Err: err})
+		c.hookError(hbErr)
+		return nil, hbErr
+	}
+	c.hb.offline.Store(false)
+
+	c.hb.statusMu.Lock()
+	c.hb.last = &resp
+	c.hb.lastAt = time.Now()
+	c.hb.statusMu.Unlock()
+
+	switch res.StatusCode {
+	case statusOK:
+		c.emitEvent(res.event(Event{Type: EventHeartbeatOK, Message: "heartbeat accepted", Data: resp}))
+		c.hookHeartbeat(resp)
+		c.setSuspended(res, false, "")
+		c.clearSharedRevocation(c.currentToken())
 		// Adapt interval from server response
 		if resp.HeartbeatInterval > 0 {
 			newInterval := time.Duration(resp.HeartbeatInterval) * time.Second
@@ -210,17 +568,215 @@ func (c *Client) sendHeartbeat(serverURL, token string) {
 			c.hb.interval = newInterval
 			c.hb.mu.Unlock()
 		}
-	case http.StatusTooManyRequests:
-		c.emitEvent(Event{Type: EventHeartbeatRejected, Message: "seat limit reached", Data: resp})
-	default:
-		c.emitEvent(Event{Type: EventHeartbeatError, Message: fmt.Sprintf("heartbeat returned status %d", statusCode), Data: resp})
+		return &resp, nil
+	case statusTooManyRequests:
+		c.emitEvent(res.event(Event{Type: EventHeartbeatRejected, Message: "seat limit reached", Data: resp}))
+		c.hookSeatLost(resp)
+		return &resp, res.annotate(&ValidationError{Code: SeatLimitReached, Message: "seat limit reached"})
+	case statusForbidden:
+		if resp.Status == heartbeatStatusRevoked {
+			c.emitEvent(res.event(Event{Type: EventLicenseRevoked, Message: "license has been revoked", Data: resp}))
+			hbErr := res.annotate(&ValidationError{Code: LicenseRevoked, Message: "server reports the license as revoked"})
+			c.shareRevocation()
+			c.hookError(hbErr)
+			c.reactivate(ctx, res, hbErr)
+			return &resp, hbErr
+		}
+		if resp.Status == heartbeatStatusRegionRestricted {
+			c.emitEvent(res.event(Event{Type: EventRegionRestricted, Message: "license is restricted to other regions", Data: resp}))
+			hbErr := res.annotate(&ValidationError{Code: RegionRestricted, Message: "server rejected heartbeat: license is restricted to other regions"})
+			c.hookError(hbErr)
+			return &resp, hbErr
+		}
+		if resp.Status == heartbeatStatusAttestationRejected {
+			c.emitEvent(res.event(Event{Type: EventAttestationRejected, Message: "server rejected the binary attestation", Data: resp}))
+			hbErr := res.annotate(&ValidationError{Code: AttestationRejected, Message: "server rejected the binary attestation"})
+			c.hookError(hbErr)
+			return &resp, hbErr
+		}
+		if resp.Status == heartbeatStatusSuspended {
+			c.setSuspended(res, true, resp.Reason)
+			return &resp, res.annotate(suspendedError(resp.Reason))
+		}
+		fallthrough
+	case statusUnauthorized:
+		hbErr := res.annotate(&ValidationError{Code: ReactivationRequired, Message: fmt.Sprintf("server rejected heartbeat token with status %d", res.StatusCode)})
+		c.hookError(hbErr)
+		c.reactivate(ctx, res, hbErr)
+		return &resp, hbErr
 	}
+
+	c.emitEvent(res.event(Event{Type: EventHeartbeatError, Message: fmt.Sprintf("heartbeat returned status %d", res.StatusCode), Data: resp}))
+	hbErr := res.annotate(&ValidationError{Code: ServerUnreachable, Message: fmt.Sprintf("heartbeat returned status %d", res.StatusCode)})
+	c.hookError(hbErr)
+	return &resp, hbErr
 }
 
+// postHeartbeat sends one heartbeat for the instance described by opts and
+// returns the decoded response without interpreting it.
+func (c *Client) postHeartbeat(ctx context.Context, token string, opts HeartbeatOptions) (HeartbeatStatus, apiResponse, error) {
+	body := map[string]interface{}{
+		"signed_token": token,
+		"instance_id":  opts.InstanceID,
+	}
+	if !c.cfg.minimalHeartbeat {
+		consent := c.TelemetryConsent()
+		body["metadata"] = c.heartbeatMetadataFor(opts, consent)
+		body["telemetry_consent"] = consent
+	}
+	if len(opts.Features) > 0 {
+		body["features"] = opts.Features
+	}
+	if opts.ReservationID != "" {
+		body["reservation_id"] = opts.ReservationID
+	}
+
+	var resp HeartbeatStatus
+	res, err := c.callServer(ctx, methodPost, c.cfg.endpoints.Heartbeat, heartbeatPath, body, &resp)
+	if err == nil && resp.ClientIP != "" {
+		c.hb.statusMu.Lock()
+		c.hb.clientIP = resp.ClientIP
+		c.hb.statusMu.Unlock()
+	}
+	return resp, res, err
+}
+
+// heartbeatMetadataFor returns the heartbeat metadata allowed by consent.
+// Empty fields are left out rather than sent as empty strings.
+func (c *Client) heartbeatMetadataFor(opts HeartbeatOptions, consent TelemetryConsent) map[string]string {
+	metadata := map[string]string{}
+	if consent == TelemetryFull {
+		if c.cfg.heartbeatMetadata != nil {
+			c.safeCall("HeartbeatMetadata", func() {
+				for k, v := range c.cfg.heartbeatMetadata() {
+					if !builtinMetadata[k] {
+						metadata[k] = v
+					}
+				}
+			})
+		}
+		userAgent := opts.UserAgent
+		if userAgent == "" {
+			userAgent = c.cfg.effectiveUserAgent()
+		}
+		setMetadata(metadata, "hostname", opts.Hostname)
+		setMetadata(metadata, "ip", opts.IP)
+		setMetadata(metadata, "user_agent", userAgent)
+		setMetadata(metadata, "user_hash", opts.UserHash)
+		environmentMetadata(metadata)
+	}
+	if consent != TelemetryNone {
+		if c.cfg.appName != "" {
+			metadata["app_name"] = c.cfg.appName
+		}
+		if c.cfg.appVersion != "" {
+			metadata["app_version"] = c.cfg.appVersion
+		}
+		setMetadata(metadata, "region", c.cfg.region)
+	}
+	c.attestationMetadata(metadata)
+	return metadata
+}
+
+// builtinMetadata lists the heartbeat metadata keys set by the SDK. Custom
+// metadata cannot supply them, even when the SDK leaves them out.
+var builtinMetadata = map[string]bool{
+	"hostname":          true,
+	"ip":                true,
+	"user_agent":        true,
+	"user_hash":         true,
+	"region":            true,
+	"app_name":          true,
+	"app_version":       true,
+	"vm":                true,
+	"hypervisor":        true,
+	"container":         true,
+	"container_runtime": true,
+	"binary_sha256":     true,
+}
+
+// setMetadata sets key to v, or removes it when v is empty.
+func setMetadata(metadata map[string]string, key, v string) {
+	if v == "" {
+		delete(metadata, key)
+		return
+	}
+	metadata[key] = v
+}
+
+// HeartbeatNow sends an out-of-band heartbeat immediately, for example after
+// the host resumes from sleep. The regular heartbeat schedule is unaffected.
+// It returns ErrNotRunning if the heartbeat has not been started.
+func (c *Client) HeartbeatNow(ctx context.Context) (*HeartbeatStatus, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+
+	c.hb.mu.Lock()
+	running, opts := c.hb.running, c.hb.opts
+	c.hb.mu.Unlock()
+
+	if !running {
+		return nil, ErrNotRunning
+	}
+	return c.sendHeartbeat(ctx, c.heartbeatToken(), opts)
+}
+
+// LastHeartbeat returns the most recent heartbeat response received from the
+// server and the time it was received. It returns nil and the zero time if no
+// heartbeat response has been received yet.
+func (c *Client) LastHeartbeat() (*HeartbeatStatus, time.Time) {
+	c.hb.statusMu.Lock()
+	defer c.hb.statusMu.Unlock()
+	if c.hb.last == nil {
+		return nil, time.Time{}
+	}
+	status := *c.hb.last
+	return &status, c.hb.lastAt
+}
+
+// emitEvent delivers e on the Events channel according to the delivery
+// mode. In EventDeliveryBlock mode it waits at most eventBlockTimeout, and
+// not past Close; events that cannot be delivered are dropped and counted.
 func (c *Client) emitEvent(e Event) {
+	c.recentEvents.add(eventRecord{
+		Time:            time.Now(),
+		Type:            e.Type.String(),
+		Message:         e.Message,
+		RequestID:       e.RequestID,
+		ServerRequestID: e.ServerRequestID,
+	})
+
+	c.eventsMu.RLock()
+	defer c.eventsMu.RUnlock()
+	if c.eventsClosed {
+		c.droppedEvents.record(e.Type)
+		return
+	}
 	select {
 	case c.Events <- e:
+		return
 	default:
-		// Drop event if channel is full (non-blocking)
+	}
+	if c.cfg.eventDelivery == EventDeliveryBlock {
+		timer := time.NewTimer(eventBlockTimeout)
+		defer timer.Stop()
+		select {
+		case c.Events <- e:
+			return
+		case <-c.done:
+		case <-timer.C:
+		}
+	}
+
+	// Drop event if channel is full (non-blocking)
+	dropped := c.droppedEvents.record(e.Type)
+	if fn := c.cfg.onEventOverflow; fn != nil {
+		func() {
+			// Not safeCall: reporting the panic as an event would
+			// overflow the full channel again.
+			defer func() { _ = recover() }()
+			fn(e, dropped)
+		}()
 	}
 }
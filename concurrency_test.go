@@ -0,0 +1,54 @@
+package licenseedict
+
+import "testing"
+
+func TestHeartbeatMetadataProtectsBuiltinFields(t *testing.T) {
+	custom := map[string]string{
+		"app_name":    "spoofed",
+		"app_version": "spoofed",
+		"region":      "spoofed",
+		"vm":          "true",
+		"module":      "reports",
+	}
+	tests := []struct {
+		name string
+		opts []Option
+		want map[string]string
+	}{
+		{
+			name: "built-in fields set",
+			opts: []Option{WithAppInfo("app", "pub"), WithAppVersion("1.2.3"), WithRegion("eu")},
+			want: map[string]string{"app_name": "app", "app_version": "1.2.3", "region": "eu"},
+		},
+		{
+			name: "built-in fields unset",
+			want: map[string]string{"app_name": "", "app_version": "", "region": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{
+				WithoutCache(),
+				WithHeartbeatMetadata(func() map[string]string { return custom }),
+			}, tt.opts...)
+			c, err := NewClient(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			metadata := c.heartbeatMetadataFor(HeartbeatOptions{}, TelemetryFull)
+			for k, want := range tt.want {
+				if got := metadata[k]; got != want {
+					t.Errorf("metadata[%q] = %q, want %q", k, got, want)
+				}
+			}
+			if metadata["module"] != "reports" {
+				t.Errorf("custom metadata dropped: %v", metadata)
+			}
+			if !DetectEnvironment().VM && metadata["vm"] != "" {
+				t.Errorf("custom metadata set vm on a physical host: %v", metadata)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package licenseedict
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// clientStats holds the counters reported by DebugSnapshot.
+type clientStats struct {
+	validations      atomic.Uint64
+	heartbeatsSent   atomic.Uint64
+	heartbeatsFailed atomic.Uint64
+	renewals         atomic.Uint64
+	renewalsFailed   atomic.Uint64
+	cacheHits        atomic.Uint64
+	cacheMisses      atomic.Uint64
+}
+
+// DebugSnapshot is a point-in-time view of a Client's internal counters and
+// state, intended for diagnosing production incidents.
+type DebugSnapshot struct {
+	Validations      uint64 `json:"validations"`
+	HeartbeatsSent   uint64 `json:"heartbeats_sent"`
+	HeartbeatsFailed uint64 `json:"heartbeats_failed"`
+	Renewals         uint64 `json:"renewals"`
+	RenewalsFailed   uint64 `json:"renewals_failed"`
+	CacheHits        uint64 `json:"cache_hits"`
+	CacheMisses      uint64 `json:"cache_misses"`
+	// DroppedEvents counts events dropped because the Events channel was
+	// full, keyed by event type name.
+	DroppedEvents map[string]uint64 `json:"dropped_events"`
+
+	LicenseID        string    `json:"license_id,omitempty"`
+	LicenseValid     bool      `json:"license_valid"`
+	LicenseExpiresAt time.Time `json:"license_expires_at,omitempty"`
+	HeartbeatRunning bool      `json:"heartbeat_running"`
+	Offline          bool      `json:"offline"`
+	LastHeartbeatAt  time.Time `json:"last_heartbeat_at,omitempty"`
+	LastHeartbeatErr string    `json:"last_heartbeat_error,omitempty"`
+	ServerURL        string    `json:"server_url,omitempty"`
+	APIVersion       int       `json:"api_version"`
+	Closed           bool      `json:"closed"`
+}
+
+// DebugSnapshot returns the client's current counters and state. It is safe
+// to call concurrently with other client methods.
+func (c *Client) DebugSnapshot() DebugSnapshot {
+	snap := DebugSnapshot{
+		Validations:      c.stats.validations.Load(),
+		HeartbeatsSent:   c.stats.heartbeatsSent.Load(),
+		HeartbeatsFailed: c.stats.heartbeatsFailed.Load(),
+		Renewals:         c.stats.renewals.Load(),
+		RenewalsFailed:   c.stats.renewalsFailed.Load(),
+		CacheHits:        c.stats.cacheHits.Load(),
+		CacheMisses:      c.stats.cacheMisses.Load(),
+		DroppedEvents:    make(map[string]uint64),
+		Offline:          c.hb.offline.Load(),
+		ServerURL:        c.resolveServerURL(),
+		APIVersion:       c.APIVersion(),
+		Closed:           c.closed.Load(),
+	}
+	for t, n := range c.DroppedEvents() {
+		snap.DroppedEvents[t.String()] = n
+	}
+
+	if license := c.License(); license != nil {
+		snap.LicenseID = license.LicenseID
+		snap.LicenseValid = license.Valid
+		snap.LicenseExpiresAt = license.ExpiresAt
+	}
+
+	c.hb.mu.Lock()
+	snap.HeartbeatRunning = c.hb.running
+	c.hb.mu.Unlock()
+
+	c.hb.statusMu.Lock()
+	snap.LastHeartbeatAt = c.hb.lastAt
+	if c.hb.lastErr != nil {
+		snap.LastHeartbeatErr = c.hb.lastErr.Error()
+	}
+	c.hb.statusMu.Unlock()
+
+	return snap
+}
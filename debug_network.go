@@ -0,0 +1,21 @@
+//go:build !licenseedict_nonetwork
+
+package licenseedict
+
+import (
+	"expvar"
+	"fmt"
+)
+
+// PublishExpvar publishes the client's DebugSnapshot under name in the
+// expvar registry, so it is served at /debug/vars alongside the runtime's
+// variables. It returns an error if name is already published. The expvar
+// package depends on net/http, so PublishExpvar is not available in builds
+// with the licenseedict_nonetwork tag.
+func (c *Client) PublishExpvar(name string) error {
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("licenseedict: expvar %q already published", name)
+	}
+	expvar.Publish(name, expvar.Func(func() interface{} { return c.DebugSnapshot() }))
+	return nil
+}
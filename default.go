@@ -0,0 +1,98 @@
+package licenseedict
+
+import "sync"
+
+// The default client backs the package-level Validate, HasFeature and
+// heartbeat functions, in the way http.DefaultClient backs http.Get.
+var (
+	defaultMu     sync.RWMutex
+	defaultClient *Client
+)
+
+// Configure creates the default client from opts, closing any client
+// previously configured. Small applications can call it once at startup and
+// then use the package-level functions without passing a *Client around.
+func Configure(opts ...Option) error {
+	c, err := NewClient(opts...)
+	if err != nil {
+		return err
+	}
+	SetDefault(c)
+	return nil
+}
+
+// SetDefault makes c the default client, closing the previous one. Passing
+// nil clears the default client.
+func SetDefault(c *Client) {
+	defaultMu.Lock()
+	prev := defaultClient
+	defaultClient = c
+	defaultMu.Unlock()
+
+	if prev != nil && prev != c {
+		_ = prev.Close()
+	}
+}
+
+// Default returns the default client, or nil if Configure has not been
+// called.
+func Default() *Client {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultClient
+}
+
+// Validate validates a token with the default client. See Client.Validate.
+func Validate(signedToken ...string) (*License, error) {
+	c := Default()
+	if c == nil {
+		return &License{}, ErrNotConfigured
+	}
+	return c.Validate(signedToken...)
+}
+
+// CurrentLicense returns the default client's current license, or nil if
+// none has been validated.
+func CurrentLicense() *License {
+	c := Default()
+	if c == nil {
+		return nil
+	}
+	return c.License()
+}
+
+// HasFeature reports whether the default client's current license includes
+// feature. It returns false if no license has been validated.
+func HasFeature(feature string) bool {
+	return CurrentLicense().HasFeature(feature)
+}
+
+// StartHeartbeat starts heartbeats on the default client. See
+// Client.StartHeartbeat.
+func StartHeartbeat(opts ...HeartbeatOptions) (<-chan Event, error) {
+	c := Default()
+	if c == nil {
+		return nil, ErrNotConfigured
+	}
+	return c.StartHeartbeat(opts...)
+}
+
+// StopHeartbeat stops heartbeats on the default client, if any.
+func StopHeartbeat() {
+	if c := Default(); c != nil {
+		c.StopHeartbeat()
+	}
+}
+
+// Close closes and clears the default client.
+func Close() error {
+	defaultMu.Lock()
+	c := defaultClient
+	defaultClient = nil
+	defaultMu.Unlock()
+
+	if c == nil {
+		return nil
+	}
+	return c.Close()
+}
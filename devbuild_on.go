@@ -0,0 +1,6 @@
+//go:build licenseedict_dev
+
+package licenseedict
+
+// devBuild reports whether the binary was built with the licenseedict_dev tag.
+const devBuild = true
@@ -0,0 +1,64 @@
+package licenseedict
+
+import (
+	"errors"
+	"os"
+)
+
+// devClientEnv enables NewDevClient at runtime in builds without the
+// licenseedict_dev build tag.
+const devClientEnv = "LICENSEEDICT_DEV"
+
+// devSignedToken stands in for a real token on development clients so that
+// heartbeat and renewal calls reach the simulated server.
+const devSignedToken = "licenseedict-dev-token"
+
+// ErrDevClientDisabled is returned by NewDevClient unless the binary was
+// built with the licenseedict_dev tag or LICENSEEDICT_DEV=1 is set.
+var ErrDevClientDisabled = errors.New("licenseedict: development client disabled (build with -tags licenseedict_dev or set LICENSEEDICT_DEV=1)")
+
+// NewDevClient returns a client that reports the supplied license from
+// Validate without any token or signature, and answers heartbeats, renewals,
+// and checkouts from an in-process simulated server. It lets development and
+// CI builds exercise real feature-gating code without real tokens.
+//
+// It is only available in binaries built with -tags licenseedict_dev or when
+// the LICENSEEDICT_DEV environment variable is set to 1; otherwise it returns
+// ErrDevClientDisabled. Additional options are applied after the defaults, so
+// WithSimulatedServer can be used to script other scenarios.
+func NewDevClient(license License, opts ...Option) (*Client, error) {
+	if !devBuild && os.Getenv(devClientEnv) != "1" {
+		return nil, ErrDevClientDisabled
+	}
+
+	defaults := []Option{
+		WithoutCache(),
+		WithSimulatedServer(SimulationScenario{MaxSeats: license.MaxSeats}),
+	}
+	c, err := NewClient(append(defaults, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if license.SignedToken == "" {
+		license.SignedToken = devSignedToken
+	}
+	if license.Features == nil {
+		license.Features = []string{}
+	}
+	c.devLicense = &license
+	c.signedToken = license.SignedToken
+	return c, nil
+}
+
+// validateDev returns a copy of the development license in place of token
+// verification.
+func (c *Client) validateDev() (*License, error) {
+	license := *c.devLicense
+	c.mu.Lock()
+	c.license = &license
+	c.mu.Unlock()
+	c.updateGates(&license)
+	c.hookValidate(&license, nil)
+	return &license, nil
+}
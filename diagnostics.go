@@ -0,0 +1,257 @@
+package licenseedict
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// diagnosticsHistorySize is the number of recent events and HTTP exchanges
+// kept for WriteDiagnostics.
+const diagnosticsHistorySize = 50
+
+// recentLog is a bounded, concurrency-safe log that keeps the newest entries.
+type recentLog[T any] struct {
+	mu      sync.Mutex
+	limit   int
+	entries []T
+}
+
+func (l *recentLog[T]) add(v T) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit <= 0 {
+		return
+	}
+	if len(l.entries) >= l.limit {
+		l.entries = append(l.entries[:0], l.entries[1:]...)
+	}
+	l.entries = append(l.entries, v)
+}
+
+func (l *recentLog[T]) snapshot() []T {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]T, len(l.entries))
+	copy(out, l.entries)
+	return out
+}
+
+// httpExchange records one API call for diagnostics.
+type httpExchange struct {
+	Time            time.Time     `json:"time"`
+	Method          string        `json:"method"`
+	URL             string        `json:"url"`
+	StatusCode      int           `json:"status_code"`
+	Duration        time.Duration `json:"duration_ns"`
+	RequestID       string        `json:"request_id,omitempty"`
+	ServerRequestID string        `json:"server_request_id,omitempty"`
+	Error           string        `json:"error,omitempty"`
+}
+
+// eventRecord records one emitted event for diagnostics.
+type eventRecord struct {
+	Time            time.Time `json:"time"`
+	Type            string    `json:"type"`
+	Message         string    `json:"message"`
+	RequestID       string    `json:"request_id,omitempty"`
+	ServerRequestID string    `json:"server_request_id,omitempty"`
+}
+
+// diagnosticsReport is the document written by WriteDiagnostics.
+type diagnosticsReport struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	Fingerprint string            `json:"fingerprint"`
+	Environment diagnosticsEnv    `json:"environment"`
+	License     *licenseSummary   `json:"license"`
+	Cache       diagnosticsCache  `json:"cache"`
+	State       DebugSnapshot     `json:"state"`
+	Events      []eventRecord     `json:"recent_events"`
+	HTTP        []httpExchange    `json:"recent_http"`
+	Config      diagnosticsConfig `json:"config"`
+}
+
+type diagnosticsEnv struct {
+	SDKVersion string `json:"sdk_version"`
+	GoVersion  string `json:"go_version"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	NumCPU     int    `json:"num_cpu"`
+	AppName    string `json:"app_name,omitempty"`
+	AppVersion string `json:"app_version,omitempty"`
+
+	Virtualization Environment `json:"virtualization"`
+}
+
+// licenseSummary is a License with secrets redacted.
+type licenseSummary struct {
+	Valid            bool      `json:"valid"`
+	LicenseID        string    `json:"license_id"`
+	ProductID        string    `json:"product_id"`
+	LicenseKey       string    `json:"license_key"`
+	Plan             string    `json:"plan"`
+	Features         []string  `json:"features"`
+	MaxSeats         int       `json:"max_seats"`
+	IssuedAt         time.Time `json:"issued_at"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	MaintenanceUntil time.Time `json:"maintenance_until"`
+	ServerURL        string    `json:"server_url,omitempty"`
+}
+
+type diagnosticsCache struct {
+	Path    string    `json:"path,omitempty"`
+	Exists  bool      `json:"exists"`
+	Size    int64     `json:"size,omitempty"`
+	ModTime time.Time `json:"mod_time,omitempty"`
+	Version int       `json:"version,omitempty"`
+	SavedAt time.Time `json:"saved_at,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+type diagnosticsConfig struct {
+	ServerURLs        []string         `json:"server_urls"`
+	OfflineOnly       bool             `json:"offline_only"`
+	CacheDisabled     bool             `json:"cache_disabled"`
+	CacheTTL          time.Duration    `json:"cache_ttl_ns"`
+	HeartbeatInterval time.Duration    `json:"heartbeat_interval_ns"`
+	AutoRenew         bool             `json:"auto_renew"`
+	APIVersion        int              `json:"api_version"`
+	PublicKeySet      bool             `json:"public_key_set"`
+	BundleLoaded      bool             `json:"bundle_loaded"`
+	TelemetryConsent  TelemetryConsent `json:"telemetry_consent"`
+}
+
+// WriteDiagnostics writes a JSON diagnostic report for support tickets. It
+// includes a license summary, cache metadata, recent events and HTTP
+// exchanges, environment information, and a hashed host fingerprint.
+// License keys and signed tokens are redacted; no credentials are included.
+func (c *Client) WriteDiagnostics(w io.Writer) error {
+	report := diagnosticsReport{
+		GeneratedAt: time.Now().UTC(),
+		Fingerprint: c.fingerprint(),
+		Environment: diagnosticsEnv{
+			SDKVersion: Version,
+			GoVersion:  runtime.Version(),
+			OS:         runtime.GOOS,
+			Arch:       runtime.GOARCH,
+			NumCPU:     runtime.NumCPU(),
+			AppName:    c.cfg.appName,
+			AppVersion: c.cfg.appVersion,
+
+			Virtualization: DetectEnvironment(),
+		},
+		License: summarizeLicense(c.License()),
+		Cache:   c.cacheDiagnostics(),
+		State:   c.DebugSnapshot(),
+		Events:  c.recentEvents.snapshot(),
+		HTTP:    c.http.history.snapshot(),
+		Config: diagnosticsConfig{
+			OfflineOnly:       c.cfg.offlineOnly,
+			CacheDisabled:     c.cfg.disableCache,
+			CacheTTL:          c.cfg.cacheTTL,
+			HeartbeatInterval: c.cfg.heartbeatInterval,
+			AutoRenew:         !c.cfg.disableAutoRenew,
+			APIVersion:        c.APIVersion(),
+			PublicKeySet:      c.cfg.publicKey != nil,
+			BundleLoaded:      c.bundle != nil,
+			TelemetryConsent:  c.TelemetryConsent(),
+		},
+	}
+	for _, u := range c.serverURLs() {
+		report.Config.ServerURLs = append(report.Config.ServerURLs, redactURL(u))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func summarizeLicense(l *License) *licenseSummary {
+	if l == nil {
+		return nil
+	}
+	return &licenseSummary{
+		Valid:            l.Valid,
+		LicenseID:        l.LicenseID,
+		ProductID:        l.ProductID,
+		LicenseKey:       redactSecret(l.LicenseKey),
+		Plan:             l.Plan,
+		Features:         l.Features,
+		MaxSeats:         l.MaxSeats,
+		IssuedAt:         l.IssuedAt,
+		ExpiresAt:        l.ExpiresAt,
+		MaintenanceUntil: l.MaintenanceUntil,
+		ServerURL:        redactURL(l.ServerURL),
+	}
+}
+
+func (c *Client) cacheDiagnostics() diagnosticsCache {
+	d := diagnosticsCache{Path: c.cache.path()}
+	if d.Path == "" {
+		return d
+	}
+	info, err := os.Stat(d.Path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			d.Error = err.Error()
+		}
+		return d
+	}
+	d.Exists = true
+	d.Size = info.Size()
+	d.ModTime = info.ModTime()
+
+	file, version, err := c.cache.read()
+	if err != nil {
+		d.Error = err.Error()
+		return d
+	}
+	d.Version = version
+	d.SavedAt = file.SavedAt
+	return d
+}
+
+// fingerprint returns a stable, non-reversible identifier for this host and
+// instance. It identifies the host to the server and in diagnostic reports
+// without revealing the hostname.
+func (c *Client) fingerprint() string {
+	host, _ := os.Hostname()
+	env := DetectEnvironment()
+	sum := sha256.Sum256([]byte(strings.Join([]string{host, runtime.GOOS, runtime.GOARCH, c.cfg.instanceID, env.Hypervisor, env.Runtime}, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// redactSecret keeps only the last four characters of s.
+func redactSecret(s string) string {
+	if len(s) <= 4 {
+		return strings.Repeat("*", len(s))
+	}
+	return strings.Repeat("*", len(s)-4) + s[len(s)-4:]
+}
+
+// redactURL removes credentials and the query string from a URL.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
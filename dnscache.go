@@ -0,0 +1,111 @@
+//go:build !licenseedict_nonetwork
+
+package licenseedict
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDNSCacheTTL    = 5 * time.Minute
+	defaultDNSNegativeTTL = 30 * time.Second
+)
+
+// dnsCache resolves hostnames through a resolver and caches both successful
+// lookups (for ttl) and failures (for negativeTTL), so flaky DNS does not add
+// a resolution delay to every heartbeat.
+type dnsCache struct {
+	resolver    *net.Resolver
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+type dnsEntry struct {
+	addrs   []string
+	err     error
+	expires time.Time
+}
+
+func newDNSCache(resolver *net.Resolver, ttl, negativeTTL time.Duration) *dnsCache {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &dnsCache{
+		resolver:    resolver,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		entries:     make(map[string]dnsEntry),
+	}
+}
+
+func (d *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	now := time.Now()
+
+	d.mu.Lock()
+	entry, ok := d.entries[host]
+	d.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.addrs, entry.err
+	}
+
+	addrs, err := d.resolver.LookupHost(ctx, host)
+	if err != nil && ctx.Err() != nil {
+		// Don't cache failures caused by the caller giving up.
+		return nil, err
+	}
+
+	entry = dnsEntry{addrs: addrs, err: err, expires: now.Add(d.ttl)}
+	if err != nil {
+		entry.expires = now.Add(d.negativeTTL)
+	}
+	if (err == nil && d.ttl > 0) || (err != nil && d.negativeTTL > 0) {
+		d.mu.Lock()
+		d.entries[host] = entry
+		d.mu.Unlock()
+	}
+	return addrs, err
+}
+
+func (d *dnsCache) forget(host string) {
+	d.mu.Lock()
+	delete(d.entries, host)
+	d.mu.Unlock()
+}
+
+// dialContext returns a DialContext function that resolves through the cache
+// and tries each cached address in turn. If every address fails, the entry is
+// dropped so the next attempt resolves afresh.
+func (d *dnsCache) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		ips, err := d.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var errs []error
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			errs = append(errs, err)
+		}
+		d.forget(host)
+		if len(errs) == 0 {
+			return nil, &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+		}
+		return nil, errors.Join(errs...)
+	}
+}
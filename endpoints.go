@@ -0,0 +1,84 @@
+package licenseedict
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// defaultAPIVersion is the API version every LicenseEdict server supports.
+	defaultAPIVersion = 1
+
+	apiVersionHeader = "X-LicenseEdict-API-Version"
+)
+
+// Endpoints overrides the paths used for individual API calls. Each path is
+// appended to the server URL as-is; empty fields keep the default path under
+// the API prefix.
+type Endpoints struct {
+	Heartbeat string
+	Checkout  string
+	SeatUsage string
+	Renew     string
+	Health    string
+
+	RenewWithKey   string
+	RenewalPreview string
+	RenewalQuote   string
+	Rebind         string
+	SiteReport     string
+	SiteUsage      string
+	Usage          string
+	Reserve        string
+	CancelReserve  string
+	Product        string
+	Redeem         string
+	AuditAnchor    string
+}
+
+// Default endpoint paths, relative to the API prefix.
+const (
+	heartbeatPath = "/concurrency/heartbeat"
+	checkoutPath  = "/concurrency/checkout"
+	seatUsagePath = "/concurrency/usage"
+	renewPath     = "/licenses/renew"
+
+	renewWithKeyPath   = "/licenses/renew-by-key"
+	renewalPreviewPath = "/licenses/renew/preview"
+	renewalQuotePath   = "/licenses/renew/quote"
+	rebindPath         = "/licenses/rebind"
+	siteReportPath     = "/licenses/site/report"
+	siteUsagePath      = "/licenses/site/usage"
+	usagePath          = "/licenses/usage"
+	reservePath        = "/concurrency/reserve"
+	cancelReservePath  = "/concurrency/reserve/cancel"
+	productPath        = "/licenses/product"
+	redeemPath         = "/licenses/redeem"
+	auditAnchorPath    = "/licenses/audit/anchor"
+)
+
+// endpointURL builds the URL for an API call. override is the matching field
+// from WithEndpoints and path is the default path relative to the API prefix.
+func (c *Client) endpointURL(serverURL, override, path string) string {
+	serverURL = strings.TrimRight(serverURL, "/")
+	if override != "" {
+		return serverURL + override
+	}
+	return serverURL + c.apiPrefix() + path
+}
+
+// apiPrefix returns the configured API prefix, or /api/v{N} for the version
+// negotiated with the server.
+func (c *Client) apiPrefix() string {
+	if c.cfg.apiPrefix != "" {
+		return strings.TrimRight(c.cfg.apiPrefix, "/")
+	}
+	return fmt.Sprintf("/api/v%d", c.APIVersion())
+}
+
+// APIVersion returns the API version currently used to talk to the server.
+// The client starts at version 1 and upgrades to the preferred version set by
+// WithAPIVersion once the server advertises support for it.
+func (c *Client) APIVersion() int {
+	return c.http.apiVersion()
+}
@@ -0,0 +1,32 @@
+package licenseedict
+
+import "encoding/json"
+
+// Entitlement decodes the named entitlement of l into a T. It reports false
+// if l is nil, the entitlement is absent, or its value is not a T.
+//
+//	maxUsers, ok := licenseedict.Entitlement[int](license, "max_users")
+func Entitlement[T any](l *License, name string) (T, bool) {
+	var v T
+	if l == nil {
+		return v, false
+	}
+	raw, ok := l.Entitlements[name]
+	if !ok {
+		return v, false
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		var zero T
+		return zero, false
+	}
+	return v, true
+}
+
+// EntitlementOr is like Entitlement but returns def when the entitlement is
+// absent or not a T.
+func EntitlementOr[T any](l *License, name string, def T) T {
+	if v, ok := Entitlement[T](l, name); ok {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,187 @@
+package licenseedict
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Standard environment variables honored by NewClientFromEnv and licensectl.
+const (
+	// EnvToken holds a signed license token.
+	EnvToken = "LICENSEEDICT_TOKEN"
+	// EnvTokenFile names a file holding an armored or bare license token.
+	// It may not be combined with EnvToken.
+	EnvTokenFile = "LICENSEEDICT_TOKEN_FILE"
+	// EnvPublicKey holds the base64 Ed25519 public key.
+	EnvPublicKey = "LICENSEEDICT_PUBLIC_KEY"
+	// EnvServerURL holds one or more comma-separated license server URLs.
+	EnvServerURL = "LICENSEEDICT_SERVER_URL"
+	// EnvCacheDir overrides the cache directory.
+	EnvCacheDir = "LICENSEEDICT_CACHE_DIR"
+	// EnvOffline disables all server communication when set to a true value
+	// ("1", "true", "yes", "on").
+	EnvOffline = "LICENSEEDICT_OFFLINE"
+)
+
+// EnvError reports a malformed environment variable. It names the variable
+// and the expected format so the mistake can be fixed without reading the
+// SDK source.
+type EnvError struct {
+	Var   string
+	Value string
+	// Hint describes the expected format.
+	Hint string
+	Err  error
+}
+
+func (e *EnvError) Error() string {
+	msg := fmt.Sprintf("licenseedict: invalid %s", e.Var)
+	if e.Value != "" {
+		msg += fmt.Sprintf(" %q", redactEnvValue(e.Var, e.Value))
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Hint != "" {
+		msg += " (" + e.Hint + ")"
+	}
+	return msg
+}
+
+func (e *EnvError) Unwrap() error {
+	return e.Err
+}
+
+// redactEnvValue shortens token values in error messages so that a license
+// token does not end up in logs verbatim.
+func redactEnvValue(name, value string) string {
+	if name == EnvToken && len(value) > 12 {
+		return value[:8] + "…"
+	}
+	return value
+}
+
+// OptionsFromEnv returns the client options described by the standard
+// LICENSEEDICT_* environment variables. Unset or empty variables are
+// ignored. Values are checked up front, so a misconfiguration is reported
+// when the client is created rather than on the first validation; the first
+// malformed variable is returned as an *EnvError.
+func OptionsFromEnv() ([]Option, error) {
+	get := func(name string) string {
+		return strings.TrimSpace(os.Getenv(name))
+	}
+
+	var opts []Option
+
+	token, tokenFile := get(EnvToken), get(EnvTokenFile)
+	switch {
+	case token != "" && tokenFile != "":
+		return nil, &EnvError{
+			Var:  EnvTokenFile,
+			Hint: "set either " + EnvToken + " or " + EnvTokenFile + ", not both",
+			Err:  errors.New("conflicts with " + EnvToken),
+		}
+	case token != "":
+		if _, err := decodeTokenPayload(token); err != nil {
+			return nil, &EnvError{Var: EnvToken, Value: token, Hint: "expected a signed license token", Err: err}
+		}
+		opts = append(opts, WithToken(token))
+	case tokenFile != "":
+		info, err := os.Stat(tokenFile)
+		if err != nil {
+			return nil, &EnvError{Var: EnvTokenFile, Value: tokenFile, Hint: "expected the path of a license file", Err: err}
+		}
+		if info.IsDir() {
+			return nil, &EnvError{Var: EnvTokenFile, Value: tokenFile, Hint: "expected the path of a license file", Err: errors.New("is a directory")}
+		}
+		opts = append(opts, WithLicenseFile(tokenFile))
+	}
+
+	if key := get(EnvPublicKey); key != "" {
+		if _, err := DecodePublicKey(key); err != nil {
+			return nil, &EnvError{Var: EnvPublicKey, Value: key, Hint: "expected a base64-encoded 32-byte Ed25519 public key", Err: err}
+		}
+		opts = append(opts, WithPublicKey(key))
+	}
+
+	if raw := get(EnvServerURL); raw != "" {
+		var urls []string
+		for _, u := range strings.Split(raw, ",") {
+			u = strings.TrimSpace(u)
+			if u == "" {
+				continue
+			}
+			if err := checkServerURL(u); err != nil {
+				return nil, &EnvError{Var: EnvServerURL, Value: raw, Hint: "expected comma-separated http(s) URLs such as https://license.example.com", Err: err}
+			}
+			urls = append(urls, u)
+		}
+		if len(urls) > 0 {
+			opts = append(opts, WithServerURLs(urls...))
+		}
+	}
+
+	if dir := get(EnvCacheDir); dir != "" {
+		if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+			return nil, &EnvError{Var: EnvCacheDir, Value: dir, Hint: "expected a directory path", Err: errors.New("is not a directory")}
+		}
+		opts = append(opts, WithCacheDir(dir))
+	}
+
+	if raw := get(EnvOffline); raw != "" {
+		offline, err := parseEnvBool(raw)
+		if err != nil {
+			return nil, &EnvError{Var: EnvOffline, Value: raw, Hint: "expected true/false, 1/0, yes/no or on/off", Err: err}
+		}
+		if offline {
+			opts = append(opts, WithOfflineOnly())
+		}
+	}
+
+	return opts, nil
+}
+
+// checkServerURL reports whether u is an absolute http or https URL.
+func checkServerURL(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("%q has no http or https scheme", u)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("%q has no host", u)
+	}
+	return nil
+}
+
+func parseEnvBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "yes", "on":
+		return true, nil
+	case "no", "off":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, errors.New("not a boolean")
+	}
+	return b, nil
+}
+
+// NewClientFromEnv creates a client configured from the standard
+// LICENSEEDICT_* environment variables (see OptionsFromEnv). opts are applied
+// after the environment, so options set in code take precedence. It returns
+// an *EnvError if a variable is malformed.
+func NewClientFromEnv(opts ...Option) (*Client, error) {
+	envOpts, err := OptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(append(envOpts, opts...)...)
+}
@@ -0,0 +1,53 @@
+package licenseedict
+
+import "sync"
+
+// Environment describes the virtualization environment the process runs in.
+// Detection is best-effort: a false result does not prove bare metal.
+type Environment struct {
+	// VM is true when running under a hypervisor; Hypervisor names it when
+	// known, such as "KVM", "VMware", "Microsoft Hyper-V", or "Xen".
+	VM         bool   `json:"vm"`
+	Hypervisor string `json:"hypervisor,omitempty"`
+	// Container is true when running in a container; Runtime names it when
+	// known, such as "docker", "podman", "kubernetes", or "lxc".
+	Container bool   `json:"container"`
+	Runtime   string `json:"runtime,omitempty"`
+}
+
+var (
+	environmentOnce sync.Once
+	environment     Environment
+)
+
+// DetectEnvironment returns the detected virtualization environment. The
+// result is computed once per process.
+func DetectEnvironment() Environment {
+	environmentOnce.Do(func() {
+		environment = detectEnvironment()
+	})
+	return environment
+}
+
+// IsVM reports whether the process appears to run in a virtual machine.
+func IsVM() bool {
+	return DetectEnvironment().VM
+}
+
+// IsContainer reports whether the process appears to run in a container.
+func IsContainer() bool {
+	return DetectEnvironment().Container
+}
+
+// environmentMetadata adds the detected environment to heartbeat metadata.
+func environmentMetadata(metadata map[string]string) {
+	env := DetectEnvironment()
+	if env.VM {
+		metadata["vm"] = "true"
+		setMetadata(metadata, "hypervisor", env.Hypervisor)
+	}
+	if env.Container {
+		metadata["container"] = "true"
+		setMetadata(metadata, "container_runtime", env.Runtime)
+	}
+}
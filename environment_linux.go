@@ -0,0 +1,99 @@
+package licenseedict
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// dmiHypervisors maps DMI vendor and product substrings to hypervisor names.
+var dmiHypervisors = []struct{ match, name string }{
+	{"kvm", "KVM"},
+	{"qemu", "QEMU"},
+	{"vmware", "VMware"},
+	{"virtualbox", "VirtualBox"},
+	{"microsoft corporation", "Microsoft Hyper-V"},
+	{"xen", "Xen"},
+	{"amazon ec2", "Amazon EC2"},
+	{"google compute engine", "Google Compute Engine"},
+	{"parallels", "Parallels"},
+	{"bochs", "Bochs"},
+}
+
+func detectEnvironment() Environment {
+	var env Environment
+
+	for _, f := range []string{"/sys/class/dmi/id/sys_vendor", "/sys/class/dmi/id/product_name"} {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		value := strings.ToLower(string(data))
+		for _, h := range dmiHypervisors {
+			if strings.Contains(value, h.match) {
+				env.VM, env.Hypervisor = true, h.name
+				break
+			}
+		}
+		if env.VM {
+			break
+		}
+	}
+	if !env.VM && cpuHasHypervisorFlag() {
+		env.VM = true
+	}
+
+	switch {
+	case os.Getenv("KUBERNETES_SERVICE_HOST") != "":
+		env.Container, env.Runtime = true, "kubernetes"
+	case fileExists("/.dockerenv"):
+		env.Container, env.Runtime = true, "docker"
+	case fileExists("/run/.containerenv"):
+		env.Container, env.Runtime = true, "podman"
+	default:
+		env.Container, env.Runtime = cgroupRuntime()
+	}
+	return env
+}
+
+// cpuHasHypervisorFlag reports whether /proc/cpuinfo lists the hypervisor
+// CPU flag, which is set by most hypervisors.
+func cpuHasHypervisorFlag() bool {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "flags") {
+			return strings.Contains(line, " hypervisor")
+		}
+	}
+	return false
+}
+
+// cgroupRuntime inspects the init process's cgroups for container runtimes.
+func cgroupRuntime() (bool, string) {
+	data, err := os.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return false, ""
+	}
+	content := string(data)
+	for _, rt := range []string{"kubepods", "docker", "containerd", "lxc", "libpod"} {
+		if strings.Contains(content, rt) {
+			if rt == "kubepods" {
+				rt = "kubernetes"
+			}
+			return true, rt
+		}
+	}
+	return false, ""
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package licenseedict
+
+// detectEnvironment is not implemented on this platform and reports bare
+// metal.
+func detectEnvironment() Environment {
+	return Environment{}
+}
@@ -3,6 +3,8 @@ package licenseedict
 import (
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 )
 
 // Failure codes for license validation errors.
@@ -17,21 +19,84 @@ const (
 	ServerUnreachable       = "SERVER_UNREACHABLE"
 	SeatLimitReached        = "SEAT_LIMIT_REACHED"
 	RenewalFailed           = "RENEWAL_FAILED"
+	MaintenanceExpired      = "MAINTENANCE_EXPIRED"
+	ProductMismatch         = "PRODUCT_MISMATCH"
+	AppMismatch             = "APP_MISMATCH"
+	RegionRestricted        = "REGION_RESTRICTED"
+	ReactivationRequired    = "REACTIVATION_REQUIRED"
+	LicenseSuspended        = "LICENSE_SUSPENDED"
+	AttestationRejected     = "ATTESTATION_REJECTED"
+	VirtualizedEnvironment  = "VIRTUALIZED_ENVIRONMENT"
+	FingerprintMismatch     = "FINGERPRINT_MISMATCH"
+	TenantMismatch          = "TENANT_MISMATCH"
+	RequestRejected         = "REQUEST_REJECTED"
 )
 
 // ValidationError is returned when license validation fails.
 // It includes a machine-readable Code field for programmatic handling.
+//
+// Err may aggregate several causes (for example, a verification failure and
+// the reason the cache fallback also failed) as produced by errors.Join;
+// use Causes to inspect them individually.
 type ValidationError struct {
 	Code    string
 	Message string
 	Err     error
+
+	// RequestID and ServerRequestID identify the HTTP request that produced
+	// the error, for correlating client and server logs. They are empty for
+	// errors that did not involve a server call.
+	RequestID       string
+	ServerRequestID string
 }
 
 func (e *ValidationError) Error() string {
-	if e.Err != nil {
-		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	msg := fmt.Sprintf("%s: %s", e.Code, e.Message)
+	if causes := e.Causes(); len(causes) > 0 {
+		msgs := make([]string, len(causes))
+		for i, c := range causes {
+			msgs[i] = c.Error()
+		}
+		msg += ": " + strings.Join(msgs, "; ")
+	}
+	if e.RequestID != "" {
+		msg += " (request_id=" + e.RequestID
+		if e.ServerRequestID != "" {
+			msg += ", server_request_id=" + e.ServerRequestID
+		}
+		msg += ")"
+	}
+	return msg
+}
+
+// Causes returns the underlying errors that led to this failure, unpacking
+// an aggregated Err into its parts. It returns nil if there is no cause.
+func (e *ValidationError) Causes() []error {
+	if e.Err == nil {
+		return nil
+	}
+	if joined, ok := e.Err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{e.Err}
+}
+
+// withCacheFallbackError returns err annotated with the reason the cache
+// fallback failed, preserving err's code. A missing cache is not reported.
+func withCacheFallbackError(err, cacheErr error) error {
+	if cacheErr == nil || errors.Is(cacheErr, os.ErrNotExist) {
+		return err
+	}
+	code := LicenseDecodeError
+	var vErr *ValidationError
+	if errors.As(err, &vErr) {
+		code = vErr.Code
+	}
+	return &ValidationError{
+		Code:    code,
+		Message: "token verification failed and cache fallback was unavailable",
+		Err:     errors.Join(err, fmt.Errorf("cache fallback: %w", cacheErr)),
 	}
-	return fmt.Sprintf("%s: %s", e.Code, e.Message)
 }
 
 func (e *ValidationError) Unwrap() error {
@@ -57,25 +122,140 @@ func (e *ValidationError) Is(target error) bool {
 		return e.Code == SeatLimitReached
 	case ErrRenewalDenied:
 		return e.Code == RenewalFailed
+	case ErrMaintenanceExpired:
+		return e.Code == MaintenanceExpired
+	case ErrProductMismatch:
+		return e.Code == ProductMismatch
+	case ErrAppMismatch:
+		return e.Code == AppMismatch
+	case ErrRegionRestricted:
+		return e.Code == RegionRestricted
+	case ErrFingerprintMismatch:
+		return e.Code == FingerprintMismatch
+	case ErrLicenseSuspended:
+		return e.Code == LicenseSuspended
+	case ErrReactivationRequired:
+		return e.Code == ReactivationRequired || e.Code == LicenseRevoked
+	case ErrRequestRejected:
+		return e.Code == RequestRejected
+	case ErrAttestationRejected:
+		return e.Code == AttestationRejected
+	case ErrVirtualizedEnvironment:
+		return e.Code == VirtualizedEnvironment
+	case ErrTenantMismatch:
+		return e.Code == TenantMismatch
 	}
 	return false
 }
 
+// PayloadSchemaError describes why a token payload failed strict schema
+// validation (see WithStrictPayload). It is wrapped by a ValidationError with
+// Code LicenseDecodeError and can be retrieved with errors.As.
+type PayloadSchemaError struct {
+	// Missing lists required fields that are absent or empty.
+	Missing []string
+	// Malformed lists fields whose values could not be parsed.
+	Malformed []string
+}
+
+func (e *PayloadSchemaError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing fields: "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Malformed) > 0 {
+		parts = append(parts, "malformed fields: "+strings.Join(e.Malformed, ", "))
+	}
+	return "invalid payload schema: " + strings.Join(parts, "; ")
+}
+
+// RenewalValidationError is returned by Renew and RenewResult when the server
+// accepted the renewal but the token it returned could not be verified or is
+// not valid. The client keeps its previous token and license. Result holds
+// the server's response for diagnosis.
+type RenewalValidationError struct {
+	Result RenewalResult
+	Err    error
+}
+
+func (e *RenewalValidationError) Error() string {
+	return "licenseedict: renewed token failed validation: " + e.Err.Error()
+}
+
+func (e *RenewalValidationError) Unwrap() error {
+	return e.Err
+}
+
+// RedemptionValidationError is returned by RedeemCode when the server
+// redeemed the code but the token it returned could not be installed. The
+// code is consumed and the client keeps its previous license; Result holds
+// the server's response, including the new token, so it can be passed to
+// SetToken once the cause is resolved.
+type RedemptionValidationError struct {
+	Result Redemption
+	Err    error
+}
+
+func (e *RedemptionValidationError) Error() string {
+	return "licenseedict: redeemed token failed validation: " + e.Err.Error()
+}
+
+func (e *RedemptionValidationError) Unwrap() error {
+	return e.Err
+}
+
+// MissingFeaturesError is returned by RequireFeatures when the license lacks
+// required features. It matches ErrMissingFeatures with errors.Is.
+type MissingFeaturesError struct {
+	// Missing lists the required features the license does not include, in
+	// the order they were required.
+	Missing []string
+}
+
+func (e *MissingFeaturesError) Error() string {
+	return "licenseedict: license is missing required features: " + strings.Join(e.Missing, ", ")
+}
+
+func (e *MissingFeaturesError) Is(target error) bool {
+	return target == ErrMissingFeatures
+}
+
 // Sentinel errors for common failure cases.
 var (
-	ErrNoPublicKey    = errors.New("licenseedict: no public key configured")
-	ErrNoToken        = errors.New("licenseedict: no signed token provided")
-	ErrNoServerURL    = errors.New("licenseedict: no server URL available")
-	ErrClientClosed   = errors.New("licenseedict: client is closed")
-	ErrAlreadyRunning = errors.New("licenseedict: heartbeat already running")
-	ErrNotRunning     = errors.New("licenseedict: heartbeat not running")
+	ErrNoPublicKey     = errors.New("licenseedict: no public key configured")
+	ErrNoToken         = errors.New("licenseedict: no signed token provided")
+	ErrNoServerURL     = errors.New("licenseedict: no server URL available")
+	ErrClientClosed    = errors.New("licenseedict: client is closed")
+	ErrAlreadyRunning  = errors.New("licenseedict: heartbeat already running")
+	ErrNotRunning      = errors.New("licenseedict: heartbeat not running")
+	ErrInvalidQRCode   = errors.New("licenseedict: not a license QR payload")
+	ErrNotConfigured   = errors.New("licenseedict: default client not configured; call Configure")
+	ErrMissingFeatures = errors.New("licenseedict: license is missing required features")
+	ErrLicenseInvalid  = errors.New("licenseedict: license is not valid")
+	ErrNetworkDisabled = errors.New("licenseedict: network access is disabled in this build (licenseedict_nonetwork)")
+	ErrCodeRejected    = errors.New("licenseedict: code rejected")
+
+	// Causes of a *CodeRejectedError, for errors.Is matching.
+	ErrCodeNotFound        = errors.New("licenseedict: code not found")
+	ErrCodeAlreadyRedeemed = errors.New("licenseedict: code already redeemed")
 
 	// Documented sentinel errors for errors.Is matching against ValidationError codes.
-	ErrInvalidSignature  = errors.New("licenseedict: invalid license signature")
-	ErrTokenMalformed    = errors.New("licenseedict: token could not be decoded")
-	ErrLicenseExpired    = errors.New("licenseedict: license has expired")
-	ErrLicenseRevoked    = errors.New("licenseedict: license has been revoked")
-	ErrServerUnreachable = errors.New("licenseedict: server unreachable")
-	ErrSeatLimitReached  = errors.New("licenseedict: seat limit reached")
-	ErrRenewalDenied     = errors.New("licenseedict: renewal denied")
+	ErrInvalidSignature       = errors.New("licenseedict: invalid license signature")
+	ErrTokenMalformed         = errors.New("licenseedict: token could not be decoded")
+	ErrLicenseExpired         = errors.New("licenseedict: license has expired")
+	ErrLicenseRevoked         = errors.New("licenseedict: license has been revoked")
+	ErrServerUnreachable      = errors.New("licenseedict: server unreachable")
+	ErrSeatLimitReached       = errors.New("licenseedict: seat limit reached")
+	ErrRenewalDenied          = errors.New("licenseedict: renewal denied")
+	ErrMaintenanceExpired     = errors.New("licenseedict: build is newer than the maintenance window")
+	ErrProductMismatch        = errors.New("licenseedict: license is for a different product")
+	ErrAppMismatch            = errors.New("licenseedict: license does not allow this application")
+	ErrRegionRestricted       = errors.New("licenseedict: license is restricted to other regions")
+	ErrReactivationRequired   = errors.New("licenseedict: server rejected the token; reactivation required")
+	ErrLicenseSuspended       = errors.New("licenseedict: license is suspended")
+	ErrFingerprintMismatch    = errors.New("licenseedict: license is bound to a different host")
+	ErrRequestRejected        = errors.New("licenseedict: server rejected the request")
+	ErrAttestationRejected    = errors.New("licenseedict: server rejected the binary attestation")
+	ErrVirtualizedEnvironment = errors.New("licenseedict: activation is not allowed in a virtual machine or container")
+	ErrTenantMismatch         = errors.New("licenseedict: license is for a different tenant")
 )
@@ -0,0 +1,28 @@
+package licenseedict
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidationErrorIs(t *testing.T) {
+	tests := []struct {
+		code     string
+		sentinel error
+	}{
+		{AttestationRejected, ErrAttestationRejected},
+		{VirtualizedEnvironment, ErrVirtualizedEnvironment},
+		{TenantMismatch, ErrTenantMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			err := error(&ValidationError{Code: tt.code})
+			if !errors.Is(err, tt.sentinel) {
+				t.Fatalf("errors.Is(%s, %v) = false", tt.code, tt.sentinel)
+			}
+			if errors.Is(&ValidationError{Code: RequestRejected}, tt.sentinel) {
+				t.Fatalf("errors.Is(%s, %v) = true", RequestRejected, tt.sentinel)
+			}
+		})
+	}
+}
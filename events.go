@@ -1,5 +1,11 @@
 package licenseedict
 
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
 // EventType identifies the kind of asynchronous event.
 type EventType int
 
@@ -10,19 +16,173 @@ const (
 	EventHeartbeatRejected
 	// EventHeartbeatError indicates a heartbeat network or server error.
 	EventHeartbeatError
-	// EventSeatReleased indicates a seat was released via checkout.
+	// EventSeatReleased indicates a seat was released via checkout. Data
+	// holds the *CheckoutResult.
 	EventSeatReleased
 	// EventLicenseRenewed indicates a license was successfully renewed.
 	EventLicenseRenewed
 	// EventServerUnreachable indicates the server could not be reached.
 	EventServerUnreachable
+	// EventRegionRestricted indicates the server rejected the heartbeat because
+	// the instance is outside the license's allowed regions or IP ranges.
+	EventRegionRestricted
+	// EventResumedFromSleep indicates the host resumed from suspend. The client
+	// revalidates and heartbeats immediately; Data holds the time.Duration slept.
+	EventResumedFromSleep
+	// EventEndpointSwitched indicates latency probing selected a different
+	// preferred server. Data holds the new server URL.
+	EventEndpointSwitched
+	// EventCacheMigrated indicates the license cache was rewritten in the
+	// current format. Data holds the previous format version.
+	EventCacheMigrated
+	// EventCacheTampered indicates the cached license was corrupted or failed
+	// signature verification and has been quarantined. Data holds the path of
+	// the quarantined file, if it could be kept.
+	EventCacheTampered
+	// EventLicenseChanged indicates a background revalidation produced a
+	// different result than the cached license that was served. Data holds
+	// the fresh *License.
+	EventLicenseChanged
+	// EventCallbackPanicked indicates a user-provided callback panicked and
+	// was recovered. Data holds a CallbackPanic.
+	EventCallbackPanicked
+	// EventLicenseRevoked indicates the server reported the license as revoked.
+	EventLicenseRevoked
+	// EventReactivationRequired indicates the server rejected the current
+	// token with 401 or 403, for example because it was superseded or
+	// revoked. Data holds the *ValidationError. See WithReactivationHandler.
+	EventReactivationRequired
+	// EventLicenseSuspended indicates the server reported the license as
+	// suspended, for example during a payment dispute or compliance hold.
+	// Data holds the suspension reason, if given.
+	EventLicenseSuspended
+	// EventLicenseReinstated indicates a suspension has been lifted.
+	EventLicenseReinstated
+	// EventTamperDetected indicates an integrity check enabled by
+	// WithTamperChecks failed. Data holds a TamperFinding.
+	EventTamperDetected
+	// EventAttestationRejected indicates the server rejected the binary hash
+	// or app version sent with WithBinaryAttestation. Data holds the
+	// HeartbeatStatus.
+	EventAttestationRejected
+	// EventReservationConfirmed indicates the server confirmed a seat
+	// reservation made with ReserveSeat. Data holds the *Reservation.
+	EventReservationConfirmed
+	// EventReservationExpired indicates a reservation's window ended before
+	// it was activated. Data holds the *Reservation.
+	EventReservationExpired
+	// EventActivationProgress indicates Client.Activate started a step.
+	// Data holds the ActivationStep.
+	EventActivationProgress
+	// EventNetworkDeferred indicates a background network call was put off
+	// because of WithQuietHours. Data holds the time.Time the quiet hours
+	// end.
+	EventNetworkDeferred
+	// EventNetworkThrottled indicates a request was queued because of
+	// WithNetworkBudget. Data holds the time.Duration it waits.
+	EventNetworkThrottled
+	// EventCodeRedeemed indicates Client.RedeemCode applied a code and
+	// installed the updated token. Data holds the Redemption.
+	EventCodeRedeemed
+)
+
+var eventTypeNames = [...]string{
+	EventHeartbeatOK:          "heartbeat_ok",
+	EventHeartbeatRejected:    "heartbeat_rejected",
+	EventHeartbeatError:       "heartbeat_error",
+	EventSeatReleased:         "seat_released",
+	EventLicenseRenewed:       "license_renewed",
+	EventServerUnreachable:    "server_unreachable",
+	EventRegionRestricted:     "region_restricted",
+	EventResumedFromSleep:     "resumed_from_sleep",
+	EventEndpointSwitched:     "endpoint_switched",
+	EventCacheMigrated:        "cache_migrated",
+	EventCacheTampered:        "cache_tampered",
+	EventLicenseChanged:       "license_changed",
+	EventCallbackPanicked:     "callback_panicked",
+	EventLicenseRevoked:       "license_revoked",
+	EventReactivationRequired: "reactivation_required",
+	EventLicenseSuspended:     "license_suspended",
+	EventLicenseReinstated:    "license_reinstated",
+	EventTamperDetected:       "tamper_detected",
+	EventAttestationRejected:  "attestation_rejected",
+	EventReservationConfirmed: "reservation_confirmed",
+	EventReservationExpired:   "reservation_expired",
+	EventActivationProgress:   "activation_progress",
+	EventNetworkDeferred:      "network_deferred",
+	EventNetworkThrottled:     "network_throttled",
+	EventCodeRedeemed:         "code_redeemed",
+}
+
+// String returns the snake_case name of the event type.
+func (t EventType) String() string {
+	if t >= 0 && int(t) < len(eventTypeNames) {
+		return eventTypeNames[t]
+	}
+	return fmt.Sprintf("event_type(%d)", int(t))
+}
+
+// EventDeliveryMode controls what happens when the Events channel is full.
+type EventDeliveryMode int
+
+const (
+	// EventDeliveryDrop drops events when the channel buffer is full. Drops
+	// are counted and reported to the callback set via WithEventOverflow.
+	EventDeliveryDrop EventDeliveryMode = iota
+	// EventDeliveryBlock blocks the emitting goroutine until the event is
+	// received, for up to five seconds; an event that is still not received
+	// then, or when the client is closed, is dropped as with
+	// EventDeliveryDrop. The application must drain the Events channel
+	// continuously, or background operations such as heartbeats will stall.
+	EventDeliveryBlock
 )
 
+// eventBlockTimeout bounds how long EventDeliveryBlock waits for a reader.
+const eventBlockTimeout = 5 * time.Second
+
+// eventCounter counts dropped events per type.
+type eventCounter struct {
+	mu     sync.Mutex
+	counts map[EventType]uint64
+}
+
+// record increments and returns the drop count for t.
+func (ec *eventCounter) record(t EventType) uint64 {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	if ec.counts == nil {
+		ec.counts = make(map[EventType]uint64)
+	}
+	ec.counts[t]++
+	return ec.counts[t]
+}
+
+func (ec *eventCounter) snapshot() map[EventType]uint64 {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	out := make(map[EventType]uint64, len(ec.counts))
+	for t, n := range ec.counts {
+		out[t] = n
+	}
+	return out
+}
+
+// DroppedEvents returns the number of events dropped so far because the
+// Events channel was full, by event type.
+func (c *Client) DroppedEvents() map[EventType]uint64 {
+	return c.droppedEvents.snapshot()
+}
+
 // Event carries information about an asynchronous SDK operation.
 type Event struct {
 	Type    EventType
 	Message string
 	Data    interface{}
+
+	// RequestID and ServerRequestID identify the HTTP request behind the
+	// event, when there was one.
+	RequestID       string
+	ServerRequestID string
 }
 
 // HeartbeatStatus contains the server's response to a heartbeat.
@@ -35,6 +195,46 @@ type HeartbeatStatus struct {
 	GracePeriod       int    `json:"grace_period"`
 	LicenseID         string `json:"license_id"`
 	ProductID         string `json:"product_id"`
+
+	// Pools reports occupancy of feature seat pools, if the license has any.
+	// On a seat-limit rejection, the exhausted pool is included.
+	Pools []SeatPool `json:"pools,omitempty"`
+
+	// Reason explains a rejection such as a suspension, if the server gives one.
+	Reason string `json:"reason,omitempty"`
+
+	// ClientIP is the client's address as seen by the server, if reported.
+	// It is checked against the license's allowed IP ranges.
+	ClientIP string `json:"client_ip,omitempty"`
+}
+
+// SeatPool is the occupancy of a feature-scoped seat pool.
+type SeatPool struct {
+	Feature           string `json:"feature"`
+	ActiveSessions    int    `json:"active_sessions"`
+	MaxSessions       int    `json:"max_sessions"`
+	RemainingSessions int    `json:"remaining_sessions"`
+}
+
+// Pool returns the occupancy of the named feature pool, if reported.
+func (s HeartbeatStatus) Pool(feature string) (SeatPool, bool) {
+	for _, p := range s.Pools {
+		if p.Feature == feature {
+			return p, true
+		}
+	}
+	return SeatPool{}, false
+}
+
+// SeatUsage contains the server's current seat occupancy for a license.
+type SeatUsage struct {
+	LicenseID         string `json:"license_id"`
+	ActiveSessions    int    `json:"active_sessions"`
+	MaxSessions       int    `json:"max_sessions"`
+	RemainingSessions int    `json:"remaining_sessions"`
+
+	// Pools reports occupancy of feature seat pools, if the license has any.
+	Pools []SeatPool `json:"pools,omitempty"`
 }
 
 // RenewalResult contains the server's response to a renewal request.
@@ -44,4 +244,9 @@ type RenewalResult struct {
 	IssuedAt          string `json:"issued_at"`
 	ExpiresAt         string `json:"expires_at"`
 	PreviousExpiresAt string `json:"previous_expires_at"`
+	// RenewalWindow is how long before expiry, in seconds, the server accepts
+	// renewals. It is 0 if the server does not report a window.
+	RenewalWindow int `json:"renewal_window,omitempty"`
+	// Reason explains a refused renewal, if the server gives one.
+	Reason string `json:"reason,omitempty"`
 }
@@ -1,11 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
 
 	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
 )
@@ -60,13 +59,9 @@ func main() {
 		}
 	}()
 
-	// Wait for interrupt
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
-	<-sig
-
-	fmt.Println("\nChecking out...")
-	if err := client.Checkout(); err != nil {
+	// Wait for Ctrl+C or SIGTERM, then check out and close the client
+	if err := client.RunUntilSignal(context.Background()); err != nil {
 		log.Printf("Checkout failed: %v", err)
 	}
+	fmt.Println("\nSeat checked out.")
 }
@@ -0,0 +1,42 @@
+package licenseedict
+
+// RequireFeatures checks that the license includes every named feature,
+// validating the configured token first if no license has been validated.
+// It is meant to be called once at startup.
+//
+// If validation fails, the validation error is returned; an expired license
+// yields a LicenseNotValidAfter error and any other invalid license
+// ErrLicenseInvalid. If features are missing, a *MissingFeaturesError lists them.
+func (c *Client) RequireFeatures(features ...string) error {
+	license := c.License()
+	if license == nil {
+		var err error
+		if license, err = c.Validate(); err != nil {
+			return err
+		}
+	}
+	switch {
+	case license.IsExpired():
+		return &ValidationError{Code: LicenseNotValidAfter, Message: "license has expired"}
+	case !license.Valid:
+		return ErrLicenseInvalid
+	}
+
+	if missing := license.FeatureSet().Missing(features...); len(missing) > 0 {
+		return &MissingFeaturesError{Missing: missing}
+	}
+	if c.audit != nil {
+		for _, f := range features {
+			c.audit.noteFeature(f)
+		}
+	}
+	return nil
+}
+
+// MustRequireFeatures is like RequireFeatures but panics if the requirement
+// is not met, for programs that cannot run at all without the features.
+func (c *Client) MustRequireFeatures(features ...string) {
+	if err := c.RequireFeatures(features...); err != nil {
+		panic(err)
+	}
+}
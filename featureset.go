@@ -0,0 +1,108 @@
+package licenseedict
+
+import "sort"
+
+// FeatureSet is a set of feature names.
+type FeatureSet map[string]struct{}
+
+// NewFeatureSet returns a set containing features.
+func NewFeatureSet(features ...string) FeatureSet {
+	s := make(FeatureSet, len(features))
+	for _, f := range features {
+		s[f] = struct{}{}
+	}
+	return s
+}
+
+// Has reports whether feature is in the set.
+func (s FeatureSet) Has(feature string) bool {
+	_, ok := s[feature]
+	return ok
+}
+
+// Intersect returns the features present in both s and other.
+func (s FeatureSet) Intersect(other FeatureSet) FeatureSet {
+	small, large := s, other
+	if len(large) < len(small) {
+		small, large = large, small
+	}
+	out := make(FeatureSet, len(small))
+	for f := range small {
+		if large.Has(f) {
+			out[f] = struct{}{}
+		}
+	}
+	return out
+}
+
+// Union returns the features present in either s or other.
+func (s FeatureSet) Union(other FeatureSet) FeatureSet {
+	out := make(FeatureSet, len(s)+len(other))
+	for f := range s {
+		out[f] = struct{}{}
+	}
+	for f := range other {
+		out[f] = struct{}{}
+	}
+	return out
+}
+
+// Missing returns the required features that are not in the set, in the
+// order given.
+func (s FeatureSet) Missing(required ...string) []string {
+	var missing []string
+	for _, f := range required {
+		if !s.Has(f) {
+			missing = append(missing, f)
+		}
+	}
+	return missing
+}
+
+// Slice returns the features in sorted order.
+func (s FeatureSet) Slice() []string {
+	out := make([]string, 0, len(s))
+	for f := range s {
+		out = append(out, f)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// featureIndex is a set built from a License's Features slice, letting
+// HasFeature answer in constant time. It records the slice it was built from
+// and is ignored once Features is reassigned or resized.
+type featureIndex struct {
+	set FeatureSet
+	src []string
+}
+
+// indexFeatures builds the feature index. It must be called before the
+// license is shared, since HasFeature reads the index without locking.
+func (l *License) indexFeatures() {
+	l.features = featureIndex{set: NewFeatureSet(l.Features...), src: l.Features}
+}
+
+// featureSet returns the index if it still describes l.Features.
+func (l *License) featureSet() (FeatureSet, bool) {
+	idx := l.features
+	if idx.set == nil || len(idx.src) != len(l.Features) {
+		return nil, false
+	}
+	if len(l.Features) > 0 && &idx.src[0] != &l.Features[0] {
+		return nil, false
+	}
+	return idx.set, true
+}
+
+// FeatureSet returns the license's features as a new set that the caller may
+// modify, for bulk operations such as intersecting with a required set.
+func (l *License) FeatureSet() FeatureSet {
+	if l == nil {
+		return FeatureSet{}
+	}
+	if set, ok := l.featureSet(); ok {
+		return set.Union(nil)
+	}
+	return NewFeatureSet(l.Features...)
+}
@@ -0,0 +1,157 @@
+package licenseedict
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Fingerprint component names.
+const (
+	FingerprintMachineID = "machine_id"
+	FingerprintHostname  = "hostname"
+	FingerprintMAC       = "mac"
+	FingerprintCPU       = "cpu"
+	FingerprintOS        = "os"
+)
+
+// HostFingerprint identifies a host by several independently hashed
+// components, so a license bound to it tolerates some hardware changes.
+// Values are hex hashes; the raw identifiers are never sent or stored.
+type HostFingerprint map[string]string
+
+// ComputeFingerprint returns the fingerprint of the current host.
+// Components that cannot be determined are omitted.
+func ComputeFingerprint() HostFingerprint {
+	fp := HostFingerprint{}
+	add := func(name, value string) {
+		if value == "" {
+			return
+		}
+		sum := sha256.Sum256([]byte(name + ":" + value))
+		fp[name] = hex.EncodeToString(sum[:16])
+	}
+
+	for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if data, err := os.ReadFile(path); err == nil {
+			add(FingerprintMachineID, strings.TrimSpace(string(data)))
+			break
+		}
+	}
+	host, _ := os.Hostname()
+	add(FingerprintHostname, host)
+	add(FingerprintMAC, hardwareAddrs())
+	add(FingerprintCPU, fmt.Sprintf("%s/%d", runtime.GOARCH, runtime.NumCPU()))
+	add(FingerprintOS, runtime.GOOS)
+	return fp
+}
+
+// hardwareAddrs returns the sorted MAC addresses of physical-looking
+// interfaces, joined by commas.
+func hardwareAddrs() string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+	var macs []string
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) == 0 {
+			continue
+		}
+		macs = append(macs, iface.HardwareAddr.String())
+	}
+	sort.Strings(macs)
+	return strings.Join(macs, ",")
+}
+
+// Matches returns how many of the components in bound are present with the
+// same value in fp.
+func (fp HostFingerprint) Matches(bound HostFingerprint) int {
+	n := 0
+	for name, value := range bound {
+		if fp[name] == value {
+			n++
+		}
+	}
+	return n
+}
+
+// checkFingerprint enforces the license's host binding, if it has one. At
+// least the tolerance set by WithFingerprintTolerance (default: all) of the
+// bound components must match this host.
+func (c *Client) checkFingerprint(license *License) error {
+	if len(license.Fingerprint) == 0 || !c.fingerprintChecksEnabled(license.LicenseModel) {
+		return nil
+	}
+	required := len(license.Fingerprint)
+	if t := c.cfg.fingerprintTolerance; t > 0 && t < required {
+		required = t
+	}
+	matched := c.hostFingerprint().Matches(license.Fingerprint)
+	if matched >= required {
+		return nil
+	}
+	return &ValidationError{
+		Code:    FingerprintMismatch,
+		Message: fmt.Sprintf("host fingerprint matches %d of %d bound components, %d required; call Rebind", matched, len(license.Fingerprint), required),
+	}
+}
+
+// hostFingerprint returns the current host's fingerprint, computed once.
+func (c *Client) hostFingerprint() HostFingerprint {
+	c.fingerprintOnce.Do(func() {
+		c.hostFP = ComputeFingerprint()
+	})
+	return c.hostFP
+}
+
+// Rebind asks the server to move the license's host binding to the current
+// host after hardware changes exceeded the fingerprint tolerance. The server
+// decides whether to allow it, typically subject to a rebind limit. On
+// success the new token is installed as with SetToken.
+func (c *Client) Rebind(ctx context.Context) (*License, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if err := c.requireServer(); err != nil {
+		return nil, err
+	}
+	token := c.currentToken()
+	if token == "" {
+		return nil, ErrNoToken
+	}
+	if c.cfg.denyVirtualized {
+		if env := DetectEnvironment(); env.VM || env.Container {
+			return nil, &ValidationError{Code: VirtualizedEnvironment, Message: "node-locked activation is not allowed in a virtual machine or container"}
+		}
+	}
+
+	body := map[string]interface{}{
+		"signed_token": token,
+		"fingerprint":  c.hostFingerprint(),
+		"instance_id":  c.cfg.instanceID,
+	}
+
+	var result RenewalResult
+	res, err := c.callServer(ctx, methodPost, c.cfg.endpoints.Rebind, rebindPath, body, &result)
+	if err != nil {
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: "rebind request failed", Err: err})
+	}
+	if res.StatusCode != statusOK {
+		msg := fmt.Sprintf("rebind returned status %d", res.StatusCode)
+		if result.Reason != "" {
+			msg += ": " + result.Reason
+		}
+		return nil, res.annotate(&ValidationError{Code: FingerprintMismatch, Message: msg})
+	}
+	if result.SignedToken == "" {
+		return nil, res.annotate(&ValidationError{Code: FingerprintMismatch, Message: "rebind returned no token"})
+	}
+	return c.SetToken(result.SignedToken)
+}
@@ -0,0 +1,110 @@
+package licenseedict
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Gate reports whether one feature is available under the current license.
+// Enabled is a single atomic load, suitable for hot paths; the value is
+// recomputed whenever the client's license changes, such as after a renewal
+// or SetToken.
+type Gate struct {
+	feature string
+	enabled atomic.Bool
+
+	mu      sync.Mutex
+	changes chan bool
+	closed  bool
+}
+
+// gateRegistry holds the gates created by FeatureGate.
+type gateRegistry struct {
+	mu    sync.Mutex
+	gates map[string]*Gate
+}
+
+// FeatureGate returns the gate for feature. Repeated calls with the same
+// feature return the same Gate.
+func (c *Client) FeatureGate(feature string) *Gate {
+	c.gates.mu.Lock()
+	defer c.gates.mu.Unlock()
+	if g, ok := c.gates.gates[feature]; ok {
+		return g
+	}
+	if c.gates.gates == nil {
+		c.gates.gates = make(map[string]*Gate)
+	}
+	g := &Gate{feature: feature, changes: make(chan bool, 1)}
+	g.enabled.Store(gateEnabled(c.License(), feature))
+	c.gates.gates[feature] = g
+	return g
+}
+
+// Feature returns the name of the gated feature.
+func (g *Gate) Feature() string {
+	return g.feature
+}
+
+// Enabled reports whether the current license is valid and includes the
+// feature.
+func (g *Gate) Enabled() bool {
+	return g.enabled.Load()
+}
+
+// Changes returns a channel that receives the new value each time the gate
+// flips. Only the latest value is buffered, so a slow reader sees the
+// current state rather than every transition. The channel is closed when the
+// client is closed. All callers share the same channel.
+func (g *Gate) Changes() <-chan bool {
+	return g.changes
+}
+
+// set updates the gate and notifies Changes if the value flipped.
+func (g *Gate) set(enabled bool) {
+	if g.enabled.Swap(enabled) == enabled {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return
+	}
+	select {
+	case <-g.changes:
+	default:
+	}
+	g.changes <- enabled
+}
+
+func (g *Gate) close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.closed {
+		g.closed = true
+		close(g.changes)
+	}
+}
+
+func gateEnabled(license *License, feature string) bool {
+	return license != nil && license.Valid && license.HasFeature(feature)
+}
+
+// updateGates recomputes every gate for license. It is called whenever the
+// client's current license is replaced.
+func (c *Client) updateGates(license *License) {
+	c.gates.mu.Lock()
+	defer c.gates.mu.Unlock()
+	for feature, g := range c.gates.gates {
+		g.set(gateEnabled(license, feature))
+	}
+}
+
+// closeGates closes every gate's Changes channel.
+func (c *Client) closeGates() {
+	c.gates.mu.Lock()
+	defer c.gates.mu.Unlock()
+	for _, g := range c.gates.gates {
+		g.close()
+	}
+}
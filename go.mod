@@ -2,6 +2,7 @@ module github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK
 
 go 1.22.0
 
-require github.com/adrg/xdg v0.5.3
-
-require golang.org/x/sys v0.26.0 // indirect
+require (
+	github.com/adrg/xdg v0.5.3
+	golang.org/x/sys v0.26.0
+)
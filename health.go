@@ -0,0 +1,122 @@
+package licenseedict
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// HealthState is the overall license health reported by HealthCheck.
+type HealthState string
+
+const (
+	// HealthHealthy means the license is valid and the server is reachable
+	// (or no server communication is expected).
+	HealthHealthy HealthState = "healthy"
+	// HealthDegraded means the license is valid but the client is offline or
+	// the license is close to expiry and awaiting renewal.
+	HealthDegraded HealthState = "degraded"
+	// HealthUnhealthy means there is no valid license, or the server has
+	// rejected or revoked it.
+	HealthUnhealthy HealthState = "unhealthy"
+)
+
+// HealthStatus is the result of a license health check.
+type HealthStatus struct {
+	State   HealthState `json:"state"`
+	Message string      `json:"message"`
+	// Code is the failure code behind a degraded or unhealthy state, if any.
+	Code      string    `json:"code,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// ErrLicenseUnhealthy and ErrLicenseDegraded are returned by the function
+// from HealthChecker; the HealthStatus message is wrapped in the error text.
+var (
+	ErrLicenseUnhealthy = errors.New("licenseedict: license unhealthy")
+	ErrLicenseDegraded  = errors.New("licenseedict: license degraded")
+)
+
+// HealthCheck reports the client's license health from its current state. It
+// makes no network calls: the heartbeat provides server-side confirmation.
+func (c *Client) HealthCheck(ctx context.Context) HealthStatus {
+	status := HealthStatus{State: HealthHealthy, Message: "license valid", CheckedAt: time.Now()}
+	if err := ctx.Err(); err != nil {
+		return c.unhealthy(status, "", "health check cancelled: "+err.Error())
+	}
+
+	license := c.License()
+	switch {
+	case c.closed.Load():
+		return c.unhealthy(status, "", "client is closed")
+	case license == nil:
+		return c.unhealthy(status, "", "no license has been validated")
+	case license.IsExpired():
+		return c.unhealthy(status, LicenseNotValidAfter, "license has expired")
+	case !license.Valid:
+		return c.unhealthy(status, "", "license is not valid")
+	}
+
+	c.hb.statusMu.Lock()
+	hbErr := c.hb.lastErr
+	c.hb.statusMu.Unlock()
+
+	var vErr *ValidationError
+	if errors.As(hbErr, &vErr) {
+		switch vErr.Code {
+		case SeatLimitReached, LicenseRevoked, RegionRestricted, ReactivationRequired, AttestationRejected:
+			return c.unhealthy(status, vErr.Code, vErr.Message)
+		case LicenseSuspended:
+			status.State = HealthDegraded
+			status.Code = LicenseSuspended
+			status.Message = vErr.Message
+			return status
+		case ServerUnreachable:
+			status.State = HealthDegraded
+			status.Code = ServerUnreachable
+			status.Message = "license valid but server unreachable: " + vErr.Message
+			return status
+		}
+	}
+
+	threshold := c.renewThreshold()
+	if !license.ExpiresAt.IsZero() && time.Until(license.ExpiresAt) < threshold && !c.cfg.disableAutoRenew {
+		status.State = HealthDegraded
+		status.Message = "license expires soon and has not been renewed"
+	}
+	return status
+}
+
+func (c *Client) unhealthy(status HealthStatus, code, message string) HealthStatus {
+	status.State = HealthUnhealthy
+	status.Code = code
+	status.Message = message
+	return status
+}
+
+// HealthChecker adapts HealthCheck to the func(context.Context) error shape
+// used by most health-check libraries. It returns nil when healthy, an error
+// wrapping ErrLicenseUnhealthy when unhealthy, and, if failOnDegraded is
+// true, an error wrapping ErrLicenseDegraded when degraded.
+func (c *Client) HealthChecker(failOnDegraded bool) func(context.Context) error {
+	return func(ctx context.Context) error {
+		status := c.HealthCheck(ctx)
+		switch status.State {
+		case HealthUnhealthy:
+			return &healthError{sentinel: ErrLicenseUnhealthy, status: status}
+		case HealthDegraded:
+			if failOnDegraded {
+				return &healthError{sentinel: ErrLicenseDegraded, status: status}
+			}
+		}
+		return nil
+	}
+}
+
+type healthError struct {
+	sentinel error
+	status   HealthStatus
+}
+
+func (e *healthError) Error() string { return e.sentinel.Error() + ": " + e.status.Message }
+func (e *healthError) Unwrap() error { return e.sentinel }
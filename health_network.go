@@ -0,0 +1,25 @@
+//go:build !licenseedict_nonetwork
+
+package licenseedict
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HealthHandler returns an http.Handler for readiness or liveness probes. It
+// writes the HealthStatus as JSON with 200 for healthy and degraded states and
+// 503 for unhealthy. It is not available in builds with the
+// licenseedict_nonetwork tag.
+func (c *Client) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := c.HealthCheck(r.Context())
+		code := http.StatusOK
+		if status.State == HealthUnhealthy {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(status)
+	})
+}
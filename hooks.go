@@ -0,0 +1,91 @@
+package licenseedict
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Hooks are callbacks invoked synchronously at points in the client
+// lifecycle. They are a structured alternative to draining the Events
+// channel. Any field may be nil. Hooks run on the goroutine performing the
+// operation, so they should return quickly; a panicking hook is recovered,
+// logged, and reported as EventCallbackPanicked.
+type Hooks struct {
+	// OnValidate is called after every validation with the resulting license.
+	OnValidate func(*License)
+	// OnHeartbeat is called after each accepted heartbeat.
+	OnHeartbeat func(HeartbeatStatus)
+	// OnRenew is called after the license is successfully renewed.
+	OnRenew func(*License)
+	// OnSeatLost is called when the server rejects a heartbeat because the
+	// seat limit was reached.
+	OnSeatLost func(HeartbeatStatus)
+	// OnInvalid is called when validation produces a license that is not
+	// valid. err is the policy violation, if any.
+	OnInvalid func(license *License, err error)
+	// OnError is called when a background operation such as a heartbeat or
+	// automatic renewal fails.
+	OnError func(error)
+}
+
+// CallbackPanic describes a panic recovered from a user-provided callback.
+// It is delivered as the Data of an EventCallbackPanicked event.
+type CallbackPanic struct {
+	// Callback names the callback that panicked, e.g. "Hooks.OnRenew".
+	Callback string
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the goroutine stack trace at the time of the panic.
+	Stack []byte
+}
+
+// safeCall runs a user-provided callback, recovering from any panic so a
+// faulty callback cannot kill the calling goroutine (such as the heartbeat
+// loop). Recovered panics are logged and reported as EventCallbackPanicked.
+func (c *Client) safeCall(name string, fn func()) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		p := CallbackPanic{Callback: name, Value: r, Stack: debug.Stack()}
+		if c.cfg.logger != nil {
+			c.cfg.logger.Error("licenseedict: callback panicked", "callback", name, "panic", r, "stack", string(p.Stack))
+		}
+		c.emitEvent(Event{Type: EventCallbackPanicked, Message: fmt.Sprintf("%s panicked: %v", name, r), Data: p})
+	}()
+	fn()
+}
+
+func (c *Client) hookValidate(license *License, err error) {
+	if h := c.cfg.hooks.OnValidate; h != nil {
+		c.safeCall("Hooks.OnValidate", func() { h(license) })
+	}
+	if h := c.cfg.hooks.OnInvalid; h != nil && !license.Valid {
+		c.safeCall("Hooks.OnInvalid", func() { h(license, err) })
+	}
+}
+
+func (c *Client) hookHeartbeat(status HeartbeatStatus) {
+	if h := c.cfg.hooks.OnHeartbeat; h != nil {
+		c.safeCall("Hooks.OnHeartbeat", func() { h(status) })
+	}
+}
+
+func (c *Client) hookRenew(license *License) {
+	if h := c.cfg.hooks.OnRenew; h != nil {
+		c.safeCall("Hooks.OnRenew", func() { h(license) })
+	}
+}
+
+func (c *Client) hookSeatLost(status HeartbeatStatus) {
+	if h := c.cfg.hooks.OnSeatLost; h != nil {
+		c.safeCall("Hooks.OnSeatLost", func() { h(status) })
+	}
+}
+
+func (c *Client) hookError(err error) {
+	if h := c.cfg.hooks.OnError; h != nil {
+		c.safeCall("Hooks.OnError", func() { h(err) })
+	}
+}
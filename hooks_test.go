@@ -0,0 +1,44 @@
+package licenseedict_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
+)
+
+func TestHookStopsHeartbeatFromLoop(t *testing.T) {
+	stops := map[string]func(*licenseedict.Client){
+		"StopHeartbeat": func(c *licenseedict.Client) { c.StopHeartbeat() },
+		"Close":         func(c *licenseedict.Client) { c.Close() },
+	}
+	for name, stop := range stops {
+		t.Run(name, func(t *testing.T) {
+			r := newRaceIssuer(t)
+			returned := make(chan struct{})
+			var c *licenseedict.Client
+			c = r.client(t, r.sign(t, "lic-hook", ""), licenseedict.WithHooks(licenseedict.Hooks{
+				OnHeartbeat: func(licenseedict.HeartbeatStatus) {
+					select {
+					case <-returned:
+						return
+					default:
+					}
+					stop(c)
+					close(returned)
+				},
+			}))
+			defer c.Close()
+			drain(c)
+
+			if _, err := c.StartHeartbeat(); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-returned:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s called from a heartbeat hook did not return", name)
+			}
+		})
+	}
+}
@@ -2,104 +2,248 @@ package licenseedict
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// Version is the SDK version reported to the server.
+const Version = "1.0"
+
 const (
 	defaultTimeout   = 10 * time.Second
-	defaultUserAgent = "LicenseEdictSDK-Go/1.0"
+	defaultUserAgent = "LicenseEdictSDK-Go/" + Version
+
+	sdkVersionHeader = "X-LicenseEdict-SDK-Version"
+	appNameHeader    = "X-LicenseEdict-App-Name"
+	appVersionHeader = "X-LicenseEdict-App-Version"
+
+	requestIDHeader       = "X-Request-ID"
+	serverRequestIDHeader = "X-LicenseEdict-Request-ID"
+
+	// compressMinSize is the smallest request body worth compressing.
+	compressMinSize = 1024
+
+	// maxResponseSize caps a response body after decompression, so a
+	// small gzip response cannot expand without bound. API responses are
+	// far smaller.
+	maxResponseSize = 8 << 20
 )
 
-// httpClient wraps an *http.Client with SDK-specific defaults.
+// Request methods and response status codes of the API. They equal the
+// net/http constants, which only the files built with network support use,
+// so that licenseedict_nonetwork builds do not link net/http.
+const (
+	methodHead   = "HEAD"
+	methodPost   = "POST"
+	methodDelete = "DELETE"
+
+	statusOK                  = 200
+	statusBadRequest          = 400
+	statusUnauthorized        = 401
+	statusPaymentRequired     = 402
+	statusForbidden           = 403
+	statusNotFound            = 404
+	statusConflict            = 409
+	statusGone                = 410
+	statusTooManyRequests     = 429
+	statusInternalServerError = 500
+	statusBadGateway          = 502
+	statusServiceUnavailable  = 503
+)
+
+// httpClient sends the SDK's API requests. The transport it uses depends on
+// the build: see http_network.go and http_nonetwork.go.
 type httpClient struct {
-	client    *http.Client
+	httpTransport
+
 	userAgent string
-}
 
-func newHTTPClient(customClient *http.Client, timeout time.Duration, userAgent string) *httpClient {
-	c := customClient
-	if c == nil {
-		t := timeout
-		if t == 0 {
-			t = defaultTimeout
-		}
-		c = &http.Client{Timeout: t}
-	}
+	// preferredVersion is advertised to the server; negotiatedVersion is the
+	// version in use, raised once the server reports support for it.
+	preferredVersion  int
+	negotiatedVersion atomic.Int32
+
+	// headers are sent with every request, keyed by canonical header name.
+	headers      map[string]string
+	capabilities capabilityStore
 
-	ua := userAgent
+	// compress enables gzip request bodies and responses.
+	compress bool
+
+	// sim, if set, answers every request in-process; see
+	// WithSimulatedServer.
+	sim *simulatedServer
+
+	// history keeps the most recent API exchanges for diagnostics.
+	history recentLog[httpExchange]
+
+	// budget, if set, limits requests and bytes sent.
+	budget *networkBudget
+}
+
+// newHTTPClientBase returns an httpClient with the settings shared by all
+// builds; newHTTPClient adds the transport.
+func newHTTPClientBase(cfg *clientConfig) *httpClient {
+	ua := cfg.effectiveUserAgent()
 	if ua == "" {
 		ua = defaultUserAgent
 	}
 
-	return &httpClient{client: c, userAgent: ua}
-}
-
-func (h *httpClient) postJSON(url string, body interface{}, result interface{}) (int, error) {
-	data, err := json.Marshal(body)
-	if err != nil {
-		return 0, fmt.Errorf("marshal request: %w", err)
+	apiVersion := cfg.apiVersion
+	if apiVersion <= 0 {
+		apiVersion = defaultAPIVersion
 	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
-	if err != nil {
-		return 0, fmt.Errorf("create request: %w", err)
+	h := &httpClient{userAgent: ua, preferredVersion: apiVersion, headers: map[string]string{}}
+	h.history.limit = diagnosticsHistorySize
+	h.headers[sdkVersionHeader] = Version
+	h.negotiatedVersion.Store(defaultAPIVersion)
+	if cfg.simulation != nil {
+		h.sim = newSimulatedServer(*cfg.simulation, cfg.endpoints)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", h.userAgent)
+	return h
+}
 
-	resp, err := h.client.Do(req)
-	if err != nil {
-		return 0, err
+func (h *httpClient) apiVersion() int {
+	return int(h.negotiatedVersion.Load())
+}
+
+// negotiate records the API version advertised by the server in the API
+// version header and switches to min(preferred, server) for subsequent
+// requests.
+func (h *httpClient) negotiate(header string) {
+	v, err := strconv.Atoi(header)
+	if err != nil || v <= 0 {
+		return
 	}
-	defer resp.Body.Close()
+	if v > h.preferredVersion {
+		v = h.preferredVersion
+	}
+	h.negotiatedVersion.Store(int32(v))
+}
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return resp.StatusCode, fmt.Errorf("read response: %w", err)
+// apiResponse describes a completed API call for error and event reporting.
+type apiResponse struct {
+	StatusCode int
+	// RequestID is generated by the client and sent in the request.
+	RequestID string
+	// ServerRequestID is the server's own ID for the request, if reported.
+	ServerRequestID string
+}
+
+// annotate attaches the request IDs to a ValidationError.
+func (r apiResponse) annotate(e *ValidationError) *ValidationError {
+	e.RequestID = r.RequestID
+	e.ServerRequestID = r.ServerRequestID
+	return e
+}
+
+// event attaches the request IDs to an Event.
+func (r apiResponse) event(e Event) Event {
+	e.RequestID = r.RequestID
+	e.ServerRequestID = r.ServerRequestID
+	return e
+}
+
+// authorizationValue returns the Authorization header value for a credential
+// passed to WithServerAuth.
+func authorizationValue(credential string) string {
+	if strings.Contains(credential, " ") {
+		return credential
 	}
+	return "Bearer " + credential
+}
 
-	if result != nil && len(respBody) > 0 {
-		if err := json.Unmarshal(respBody, result); err != nil {
-			return resp.StatusCode, fmt.Errorf("decode response: %w", err)
-		}
+// newRequestID returns a random 128-bit hex identifier.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
 	}
+	return hex.EncodeToString(b[:])
+}
 
-	return resp.StatusCode, nil
+// doJSON sends body as a JSON request and decodes the JSON response into
+// result. It returns the HTTP status code even when decoding fails. Each call
+// is recorded in the exchange history used by WriteDiagnostics.
+func (h *httpClient) doJSON(ctx context.Context, method, url string, body interface{}, result interface{}) (apiResponse, error) {
+	start := time.Now()
+	res, err := h.do(ctx, method, url, body, result)
+	h.history.add(httpExchange{
+		Time:            start,
+		Method:          method,
+		URL:             redactURL(url),
+		StatusCode:      res.StatusCode,
+		Duration:        time.Since(start),
+		RequestID:       res.RequestID,
+		ServerRequestID: res.ServerRequestID,
+		Error:           errorString(err),
+	})
+	return res, err
 }
 
-func (h *httpClient) deleteJSON(url string, body interface{}, result interface{}) (int, error) {
-	data, err := json.Marshal(body)
-	if err != nil {
-		return 0, fmt.Errorf("marshal request: %w", err)
+// encodeBody returns body as JSON, compressed with gzip if compression is
+// enabled and the body is large enough.
+func (h *httpClient) encodeBody(body interface{}) (data []byte, compressed bool, err error) {
+	if data, err = json.Marshal(body); err != nil {
+		return nil, false, fmt.Errorf("marshal request: %w", err)
 	}
-
-	req, err := http.NewRequest(http.MethodDelete, url, bytes.NewReader(data))
-	if err != nil {
-		return 0, fmt.Errorf("create request: %w", err)
+	if h.compress && len(data) >= compressMinSize {
+		if data, err = gzipBytes(data); err != nil {
+			return nil, false, fmt.Errorf("compress request: %w", err)
+		}
+		compressed = true
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", h.userAgent)
+	return data, compressed, nil
+}
 
-	resp, err := h.client.Do(req)
-	if err != nil {
-		return 0, err
+// decodeBody reads a response body, gzip-compressed if gzipped is set, and
+// decodes it as JSON into result. Bodies over maxResponseSize, once
+// decompressed, are rejected.
+func decodeBody(r io.Reader, gzipped bool, result interface{}) error {
+	if gzipped {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("decompress response: %w", err)
+		}
+		defer gz.Close()
+		r = gz
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(io.LimitReader(r, maxResponseSize+1))
 	if err != nil {
-		return resp.StatusCode, fmt.Errorf("read response: %w", err)
+		return fmt.Errorf("read response: %w", err)
+	}
+	if len(respBody) > maxResponseSize {
+		return fmt.Errorf("read response: body exceeds %d bytes", maxResponseSize)
 	}
 
 	if result != nil && len(respBody) > 0 {
 		if err := json.Unmarshal(respBody, result); err != nil {
-			return resp.StatusCode, fmt.Errorf("decode response: %w", err)
+			return fmt.Errorf("decode response: %w", err)
 		}
 	}
+	return nil
+}
 
-	return resp.StatusCode, nil
+// gzipBytes returns data compressed with gzip.
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
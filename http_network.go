@@ -0,0 +1,131 @@
+//go:build !licenseedict_nonetwork
+
+package licenseedict
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpTransport is the part of httpClient that uses net/http.
+type httpTransport struct {
+	client *http.Client
+
+	// injectHeaders is called on every outgoing request after the SDK's own
+	// headers are set.
+	injectHeaders func(*http.Request)
+}
+
+// newHTTPClient returns the httpClient for cfg: the client supplied with
+// WithHTTPClient or one using the SDK's transport, or the simulated server,
+// wrapped in the configured middleware.
+func newHTTPClient(cfg *clientConfig) *httpClient {
+	h := newHTTPClientBase(cfg)
+
+	c := cfg.httpClient
+	if c == nil {
+		t := cfg.httpTimeout
+		if t == 0 {
+			t = defaultTimeout
+		}
+		c = &http.Client{Timeout: t, Transport: newTransport(cfg.transport)}
+	}
+	if h.sim != nil {
+		c = &http.Client{Transport: h.sim}
+	}
+	h.client = applyMiddleware(c, cfg.middleware)
+	h.injectHeaders = cfg.headerInjector
+	return h
+}
+
+// newRequest builds a request carrying the SDK's headers.
+func (h *httpClient) newRequest(ctx context.Context, method, url string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", h.userAgent)
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+func (h *httpClient) do(ctx context.Context, method, url string, body interface{}, result interface{}) (apiResponse, error) {
+	res := apiResponse{RequestID: newRequestID()}
+
+	data, compressed, err := h.encodeBody(body)
+	if err != nil {
+		return res, err
+	}
+
+	req, err := h.newRequest(ctx, method, url, data)
+	if err != nil {
+		return res, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if h.compress {
+		// Setting Accept-Encoding explicitly disables the transport's
+		// transparent decompression, so responses are decoded below.
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	req.Header.Set(requestIDHeader, res.RequestID)
+	req.Header.Set(apiVersionHeader, strconv.Itoa(h.preferredVersion))
+	if h.injectHeaders != nil {
+		h.injectHeaders(req)
+	}
+
+	if err := h.budget.wait(ctx, len(data)); err != nil {
+		return res, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return res, err
+	}
+	defer resp.Body.Close()
+	counter := &countingReader{r: resp.Body}
+	defer func() { h.budget.received(counter.n) }()
+	res.StatusCode = resp.StatusCode
+	res.ServerRequestID = resp.Header.Get(serverRequestIDHeader)
+	h.negotiate(resp.Header.Get(apiVersionHeader))
+	h.capabilities.update(resp.Header.Get(capabilitiesHeader))
+
+	gzipped := strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip")
+	return res, decodeBody(counter, gzipped, result)
+}
+
+// probe sends a HEAD request to url and returns the round-trip time. Any
+// response below 500 counts as reachable.
+func (h *httpClient) probe(ctx context.Context, url string) (time.Duration, error) {
+	req, err := h.newRequest(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	if h.injectHeaders != nil {
+		h.injectHeaders(req)
+	}
+
+	if err := h.budget.wait(ctx, 0); err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	rtt := time.Since(start)
+
+	if resp.StatusCode >= 500 {
+		return rtt, &ValidationError{Code: ServerUnreachable, Message: "health probe returned status " + resp.Status}
+	}
+	return rtt, nil
+}
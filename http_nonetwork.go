@@ -0,0 +1,71 @@
+//go:build licenseedict_nonetwork
+
+package licenseedict
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// networkConfig holds the options that take net/http types, which builds
+// without network support do not have.
+type networkConfig struct{}
+
+// httpTransport is empty in builds without network support: requests are
+// answered by the simulated server or fail with ErrNetworkDisabled.
+type httpTransport struct{}
+
+// newHTTPClient returns the httpClient for cfg. Without network support it
+// can only reach the simulated server.
+func newHTTPClient(cfg *clientConfig) *httpClient {
+	return newHTTPClientBase(cfg)
+}
+
+func (h *httpClient) do(ctx context.Context, method, rawURL string, body interface{}, result interface{}) (apiResponse, error) {
+	res := apiResponse{RequestID: newRequestID()}
+	if h.sim == nil {
+		return res, ErrNetworkDisabled
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return res, fmt.Errorf("marshal request: %w", err)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return res, fmt.Errorf("create request: %w", err)
+	}
+	status, resp, err := h.sim.serve(ctx, u.Path, data)
+	if err != nil {
+		return res, err
+	}
+	res.StatusCode = status
+	if resp == nil {
+		return res, nil
+	}
+	if data, err = json.Marshal(resp); err != nil {
+		return res, fmt.Errorf("read response: %w", err)
+	}
+	return res, decodeBody(bytes.NewReader(data), false, result)
+}
+
+// probe checks that the simulated server answers url and returns the
+// round-trip time.
+func (h *httpClient) probe(ctx context.Context, rawURL string) (time.Duration, error) {
+	if h.sim == nil {
+		return 0, ErrNetworkDisabled
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	if _, _, err := h.sim.serve(ctx, u.Path, nil); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
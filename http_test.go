@@ -0,0 +1,28 @@
+package licenseedict
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeBodyLimitsDecompressedSize(t *testing.T) {
+	gzipped := func(n int) []byte {
+		data := append(append([]byte(`{"status":"`), bytes.Repeat([]byte("a"), n-len(`{"status":""}`))...), `"}`...)
+		out, err := gzipBytes(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	var result struct{ Status string }
+	if err := decodeBody(bytes.NewReader(gzipped(maxResponseSize)), true, &result); err != nil {
+		t.Fatalf("body at the limit: %v", err)
+	}
+	if len(result.Status) == 0 {
+		t.Fatal("body at the limit was not decoded")
+	}
+	if err := decodeBody(bytes.NewReader(gzipped(maxResponseSize+1)), true, &result); err == nil {
+		t.Fatal("body over the limit was accepted")
+	}
+}
@@ -0,0 +1,207 @@
+// Package issuer signs LicenseEdict license tokens. It is intended for
+// self-hosted license servers and internal tooling; applications that only
+// verify licenses do not need it.
+//
+// Tokens use the same wire format the server issues and the licenseedict
+// package verifies: base64(Ed25519 signature + JSON payload).
+//
+//	iss, _ := issuer.New(privateKeyB64)
+//	token, _ := iss.Sign(issuer.Claims{
+//	    LicenseID: "lic_123",
+//	    ProductID: "prod_1",
+//	    Plan:      "pro",
+//	    ExpiresAt: time.Now().AddDate(1, 0, 0),
+//	})
+package issuer
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
+)
+
+// Claims is the signed token payload. Field names and JSON tags match the
+// payload verified by licenseedict.CheckLicense and Client.Validate.
+type Claims struct {
+	LicenseID        string    `json:"license_id"`
+	ProductID        string    `json:"product_id"`
+	LicenseKey       string    `json:"license_key"`
+	Licensee         string    `json:"licensee,omitempty"`
+	Plan             string    `json:"plan"`
+	Features         []string  `json:"features,omitempty"`
+	MaxSeats         int       `json:"max_seats"`
+	IssuedAt         time.Time `json:"issued_at"`
+	ExpiresAt        time.Time `json:"expires_at,omitempty"`
+	ServerURL        string    `json:"server_url,omitempty"`
+	MaintenanceUntil time.Time `json:"maintenance_until,omitempty"`
+	AllowedApps      []string  `json:"allowed_apps,omitempty"`
+	AllowedRegions   []string  `json:"allowed_regions,omitempty"`
+	AllowedIPRanges  []string  `json:"allowed_ip_ranges,omitempty"`
+	ServerURLs       []string  `json:"server_urls,omitempty"`
+
+	Fingerprint  licenseedict.HostFingerprint `json:"fingerprint,omitempty"`
+	LicenseModel licenseedict.LicenseModel    `json:"license_model,omitempty"`
+	OrgID        string                       `json:"org_id,omitempty"`
+	TenantID     string                       `json:"tenant_id,omitempty"`
+	ContractID   string                       `json:"contract_id,omitempty"`
+
+	// DelegationKey permits the licensee to issue child licenses signed
+	// with the matching private key; see Delegate.
+	DelegationKey string `json:"delegation_key,omitempty"`
+
+	// Entitlements values may be of any JSON-encodable type; clients read
+	// them with licenseedict.Entitlement.
+	Entitlements map[string]any `json:"entitlements,omitempty"`
+}
+
+// ErrMissingClaim is returned by Sign when a required claim is empty.
+var ErrMissingClaim = errors.New("issuer: required claim missing")
+
+// Issuer signs tokens with an Ed25519 private key. It is safe for concurrent
+// use.
+type Issuer struct {
+	key ed25519.PrivateKey
+	now func() time.Time
+}
+
+// New returns an Issuer for a base64-encoded Ed25519 private key. Both the
+// 64-byte private key and its 32-byte seed are accepted.
+func New(privateKey string) (*Issuer, error) {
+	key, err := ParsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromKey(key), nil
+}
+
+// NewFromKey returns an Issuer for key.
+func NewFromKey(key ed25519.PrivateKey) *Issuer {
+	return &Issuer{key: key, now: time.Now}
+}
+
+// ParsePrivateKey decodes a base64-encoded Ed25519 private key or seed.
+func ParsePrivateKey(encoded string) (ed25519.PrivateKey, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("issuer: failed to base64-decode private key: %w", err)
+	}
+	switch len(decoded) {
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(decoded), nil
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(decoded), nil
+	default:
+		return nil, fmt.Errorf("issuer: invalid private key length %d", len(decoded))
+	}
+}
+
+// GenerateKey creates a new key pair and returns both halves base64-encoded.
+// The public key is in the form accepted by licenseedict.WithPublicKey.
+func GenerateKey() (publicKey, privateKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(priv), nil
+}
+
+// PublicKey returns the base64-encoded public key matching the issuer's
+// private key.
+func (i *Issuer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(i.key.Public().(ed25519.PublicKey))
+}
+
+// Sign returns a signed token for claims. LicenseID and ProductID are
+// required; IssuedAt defaults to the current time. Timestamps are encoded in
+// UTC.
+func (i *Issuer) Sign(claims Claims) (string, error) {
+	if claims.LicenseID == "" {
+		return "", fmt.Errorf("%w: license_id", ErrMissingClaim)
+	}
+	if claims.ProductID == "" {
+		return "", fmt.Errorf("%w: product_id", ErrMissingClaim)
+	}
+	if claims.IssuedAt.IsZero() {
+		claims.IssuedAt = i.now()
+	}
+	claims.IssuedAt = claims.IssuedAt.UTC()
+	claims.ExpiresAt = claims.ExpiresAt.UTC()
+	claims.MaintenanceUntil = claims.MaintenanceUntil.UTC()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("issuer: encode claims: %w", err)
+	}
+	return i.SignPayload(payload), nil
+}
+
+// ErrDelegationNotPermitted is returned by Delegate when the parent token does
+// not carry this issuer's public key as its delegation key.
+var ErrDelegationNotPermitted = errors.New("issuer: parent license does not delegate to this key")
+
+// Delegate issues a child license under parentToken, for resellers whose
+// license carries a delegation key. The issuer must hold the delegation
+// private key. The returned token embeds the parent and is verified by the
+// licenseedict package against the vendor's public key; the child is limited
+// to the parent's product, features, seats and expiry, inherits the parent's
+// app, region, IP-range, fingerprint, tenant and entitlement restrictions,
+// and must be issued while the parent is valid. Its license ID, server URLs
+// and delegation key must be left empty or match the parent's; an empty
+// license ID or product ID is filled in from the parent.
+func (i *Issuer) Delegate(parentToken string, claims Claims) (string, error) {
+	parent, err := decodeClaims(parentToken)
+	if err != nil {
+		return "", err
+	}
+	if parent.DelegationKey != i.PublicKey() {
+		return "", ErrDelegationNotPermitted
+	}
+	if claims.LicenseID == "" {
+		claims.LicenseID = parent.LicenseID
+	}
+	if claims.ProductID == "" {
+		claims.ProductID = parent.ProductID
+	}
+	child, err := i.Sign(claims)
+	if err != nil {
+		return "", err
+	}
+	return parentToken + "." + child, nil
+}
+
+// decodeClaims reads the claims of the last token in a delegation chain
+// without verifying its signature.
+func decodeClaims(token string) (*Claims, error) {
+	if i := strings.LastIndex(token, "."); i >= 0 {
+		token = token[i+1:]
+	}
+	combined, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("issuer: failed to base64-decode token: %w", err)
+	}
+	if len(combined) <= ed25519.SignatureSize {
+		return nil, errors.New("issuer: token too short")
+	}
+	var claims Claims
+	if err := json.Unmarshal(combined[ed25519.SignatureSize:], &claims); err != nil {
+		return nil, fmt.Errorf("issuer: decode claims: %w", err)
+	}
+	return &claims, nil
+}
+
+// SignPayload signs a raw JSON payload. Use it to issue tokens carrying
+// claims that Claims does not model; the payload is signed as given.
+func (i *Issuer) SignPayload(payload []byte) string {
+	sig := ed25519.Sign(i.key, payload)
+	combined := make([]byte, 0, len(sig)+len(payload))
+	combined = append(combined, sig...)
+	combined = append(combined, payload...)
+	return base64.StdEncoding.EncodeToString(combined)
+}
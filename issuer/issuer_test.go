@@ -0,0 +1,236 @@
+package issuer
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
+)
+
+func newTestIssuer(t *testing.T) *Issuer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewFromKey(key)
+}
+
+func newValidator(t *testing.T, i *Issuer, strict bool) *licenseedict.Validator {
+	t.Helper()
+	var opts []licenseedict.ValidatorOption
+	if strict {
+		opts = append(opts, licenseedict.WithValidatorStrict())
+	}
+	v, err := licenseedict.NewValidator(i.PublicKey(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestSignRoundTrip(t *testing.T) {
+	iss := newTestIssuer(t)
+	now := time.Now().Truncate(time.Second)
+	claims := Claims{
+		LicenseID:    "lic_1",
+		ProductID:    "prod_1",
+		LicenseKey:   "KEY-1",
+		Plan:         "pro",
+		Features:     []string{"PRO", "SSO"},
+		MaxSeats:     10,
+		IssuedAt:     now.Add(-time.Hour),
+		ExpiresAt:    now.AddDate(1, 0, 0),
+		TenantID:     "tenant-a",
+		Entitlements: map[string]any{"max_users": 25},
+	}
+	token, err := iss.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, strict := range []bool{false, true} {
+		license, err := newValidator(t, iss, strict).Validate(token)
+		if err != nil {
+			t.Fatalf("strict %v: %v", strict, err)
+		}
+		if !license.Valid || license.LicenseID != claims.LicenseID || license.ProductID != claims.ProductID ||
+			license.Plan != claims.Plan || license.MaxSeats != claims.MaxSeats || license.TenantID != claims.TenantID {
+			t.Fatalf("strict %v: license %+v does not match the claims", strict, license)
+		}
+		if !reflect.DeepEqual(license.Features, claims.Features) {
+			t.Fatalf("strict %v: features %v, want %v", strict, license.Features, claims.Features)
+		}
+		if !license.IssuedAt.Equal(claims.IssuedAt) || !license.ExpiresAt.Equal(claims.ExpiresAt) {
+			t.Fatalf("strict %v: valid %v to %v, want %v to %v", strict, license.IssuedAt, license.ExpiresAt, claims.IssuedAt, claims.ExpiresAt)
+		}
+		if n, ok := licenseedict.Entitlement[int](license, "max_users"); !ok || n != 25 {
+			t.Fatalf("strict %v: max_users = %d, %v, want 25", strict, n, ok)
+		}
+	}
+
+	if _, err := newValidator(t, newTestIssuer(t), false).Validate(token); !errors.Is(err, licenseedict.ErrInvalidSignature) {
+		t.Fatalf("validate with another key: err = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestSignDefaults(t *testing.T) {
+	iss := newTestIssuer(t)
+	issued := time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("", 3600))
+	iss.now = func() time.Time { return issued }
+
+	token, err := iss.Sign(Claims{LicenseID: "lic_1", ProductID: "prod_1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := decodeClaims(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.IssuedAt.Equal(issued) || claims.IssuedAt.Location() != time.UTC {
+		t.Fatalf("issued_at = %v, want %v in UTC", claims.IssuedAt, issued)
+	}
+
+	for _, c := range []Claims{{ProductID: "prod_1"}, {LicenseID: "lic_1"}} {
+		if _, err := iss.Sign(c); !errors.Is(err, ErrMissingClaim) {
+			t.Fatalf("Sign(%+v): err = %v, want ErrMissingClaim", c, err)
+		}
+	}
+}
+
+func TestSignPayloadStrict(t *testing.T) {
+	iss := newTestIssuer(t)
+	token := iss.SignPayload([]byte(`{"license_id":"lic_1","product_id":"prod_1"}`))
+
+	if _, err := newValidator(t, iss, false).Validate(token); err != nil {
+		t.Fatalf("lenient: %v", err)
+	}
+	_, err := newValidator(t, iss, true).Validate(token)
+	var schemaErr *licenseedict.PayloadSchemaError
+	if !errors.As(err, &schemaErr) || !reflect.DeepEqual(schemaErr.Missing, []string{"issued_at"}) {
+		t.Fatalf("strict: err = %v, want a PayloadSchemaError for issued_at", err)
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := base64.StdEncoding.EncodeToString(pub)
+	for name, encoded := range map[string]string{
+		"private key": base64.StdEncoding.EncodeToString(key),
+		"seed":        base64.StdEncoding.EncodeToString(key.Seed()),
+	} {
+		iss, err := New(encoded)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if iss.PublicKey() != want {
+			t.Fatalf("%s: public key %s, want %s", name, iss.PublicKey(), want)
+		}
+	}
+	for _, encoded := range []string{"not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := New(encoded); err == nil {
+			t.Fatalf("New(%q) succeeded", encoded)
+		}
+	}
+
+	pubB64, privB64, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss, err := New(privB64)
+	if err != nil || iss.PublicKey() != pubB64 {
+		t.Fatalf("GenerateKey: public key does not match the private key (%v)", err)
+	}
+}
+
+func TestDelegate(t *testing.T) {
+	vendor := newTestIssuer(t)
+	reseller := newTestIssuer(t)
+	now := time.Now()
+	parent, err := vendor.Sign(Claims{
+		LicenseID:     "lic_parent",
+		ProductID:     "prod_1",
+		Plan:          "enterprise",
+		Features:      []string{"PRO", "SSO"},
+		MaxSeats:      10,
+		IssuedAt:      now.Add(-time.Hour),
+		ExpiresAt:     now.Add(30 * 24 * time.Hour),
+		TenantID:      "tenant-a",
+		DelegationKey: reseller.PublicKey(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child, err := reseller.Delegate(parent, Claims{
+		Plan:      "pro",
+		Features:  []string{"PRO", "AUDIT"},
+		MaxSeats:  50,
+		ExpiresAt: now.Add(365 * 24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, strict := range []bool{false, true} {
+		license, err := newValidator(t, vendor, strict).Validate(child)
+		if err != nil {
+			t.Fatalf("strict %v: %v", strict, err)
+		}
+		if license.LicenseID != "lic_parent" || license.ProductID != "prod_1" || license.TenantID != "tenant-a" || license.Plan != "pro" {
+			t.Fatalf("strict %v: child %+v does not inherit the parent's identity", strict, license)
+		}
+		if !reflect.DeepEqual(license.Features, []string{"PRO"}) || license.MaxSeats != 10 {
+			t.Fatalf("strict %v: features %v and %d seats, want [PRO] and 10", strict, license.Features, license.MaxSeats)
+		}
+		if license.ExpiresAt.After(now.Add(30 * 24 * time.Hour)) {
+			t.Fatalf("strict %v: child expires %v, after its parent", strict, license.ExpiresAt)
+		}
+	}
+
+	// The child must not be accepted by the vendor key alone or detached
+	// from its parent.
+	if _, err := newValidator(t, reseller, false).Validate(child); err == nil {
+		t.Fatal("delegated token verified against the reseller key")
+	}
+	if _, err := newValidator(t, vendor, false).Validate(child[len(parent)+1:]); !errors.Is(err, licenseedict.ErrInvalidSignature) {
+		t.Fatalf("detached child: err = %v, want ErrInvalidSignature", err)
+	}
+
+	// The license ID is bound to the parent's.
+	other, err := reseller.Delegate(parent, Claims{LicenseID: "lic_other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newValidator(t, vendor, false).Validate(other); !errors.Is(err, licenseedict.ErrInvalidSignature) {
+		t.Fatalf("child with another license ID: err = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestDelegateNotPermitted(t *testing.T) {
+	vendor := newTestIssuer(t)
+	reseller := newTestIssuer(t)
+	withoutKey, err := vendor.Sign(Claims{LicenseID: "lic_1", ProductID: "prod_1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	withOtherKey, err := vendor.Sign(Claims{LicenseID: "lic_2", ProductID: "prod_1", DelegationKey: newTestIssuer(t).PublicKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, parent := range []string{withoutKey, withOtherKey} {
+		if _, err := reseller.Delegate(parent, Claims{}); !errors.Is(err, ErrDelegationNotPermitted) {
+			t.Fatalf("Delegate: err = %v, want ErrDelegationNotPermitted", err)
+		}
+	}
+	if _, err := reseller.Delegate("not a token!", Claims{}); err == nil {
+		t.Fatal("Delegate accepted a malformed parent")
+	}
+}
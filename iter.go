@@ -0,0 +1,45 @@
+//go:build go1.23
+
+package licenseedict
+
+import (
+	"encoding/json"
+	"iter"
+	"sort"
+)
+
+// FeaturesSeq returns an iterator over the license's features without
+// copying them.
+func (l *License) FeaturesSeq() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		if l == nil {
+			return
+		}
+		for _, f := range l.Features {
+			if !yield(f) {
+				return
+			}
+		}
+	}
+}
+
+// EntitlementsSeq returns an iterator over the license's entitlements in
+// name order, yielding each name with its raw JSON value. Use Entitlement to
+// decode a value.
+func (l *License) EntitlementsSeq() iter.Seq2[string, json.RawMessage] {
+	return func(yield func(string, json.RawMessage) bool) {
+		if l == nil {
+			return
+		}
+		names := make([]string, 0, len(l.Entitlements))
+		for name := range l.Entitlements {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !yield(name, l.Entitlements[name]) {
+				return
+			}
+		}
+	}
+}
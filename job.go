@@ -0,0 +1,57 @@
+package licenseedict
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// jobReleaseTimeout bounds the seat release after a job returns.
+const jobReleaseTimeout = 10 * time.Second
+
+// RunWithSeat runs fn while holding a seat: it acquires a lease with opts,
+// keeps it alive for the duration of fn, and releases it when fn returns or
+// panics. It is meant for batch and render-farm jobs that need a seat only
+// while they run.
+//
+// The context passed to fn is cancelled if the lease is lost, for example
+// because the license was revoked; in that case RunWithSeat returns the
+// lease error unless fn returned an error of its own that is not a context
+// error. If the seat cannot be acquired, fn is not called.
+func RunWithSeat(ctx context.Context, c *Client, fn func(ctx context.Context) error, opts ...HeartbeatOptions) (err error) {
+	var o HeartbeatOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	lease, err := c.AcquireSeat(ctx, o)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Release even if ctx is already done, so the seat is not held
+		// until the server-side TTL expires.
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jobReleaseTimeout)
+		defer cancel()
+		if releaseErr := lease.Release(releaseCtx); releaseErr != nil {
+			c.hookError(releaseErr)
+		}
+	}()
+
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		select {
+		case <-lease.Done():
+			cancel(lease.Err())
+		case <-jobCtx.Done():
+		}
+	}()
+
+	err = fn(jobCtx)
+	if cause := context.Cause(jobCtx); cause != nil && ctx.Err() == nil && !errors.Is(cause, context.Canceled) {
+		if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return cause
+		}
+	}
+	return err
+}
@@ -0,0 +1,164 @@
+// Package keyformat generates, normalizes and validates human-entered
+// LicenseEdict license keys.
+//
+// A key is five dash-separated groups of five characters from the Crockford
+// base32 alphabet, such as "7K3Q0-0M9XH-R2D4B-HV8TN-ABCDJ". The last
+// character of each group is a check character over the group's other four
+// characters and its position, so a mistyped character or two swapped groups
+// are caught before the key is sent to the server, and the error points at
+// the group to fix.
+//
+//	key := keyformat.Normalize(input) // "7k3qo 0m9xh ..." -> "7K3Q0-0M9XH-..."
+//	if err := keyformat.Validate(key); err != nil {
+//	    var kerr *keyformat.Error
+//	    errors.As(err, &kerr) // kerr.Group is the group to highlight
+//	}
+package keyformat
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Key layout.
+const (
+	// Alphabet is the Crockford base32 alphabet. It omits I, L, O and U,
+	// which Normalize maps to their look-alikes where unambiguous.
+	Alphabet  = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	Groups    = 5
+	GroupSize = 5
+	// Length is the number of characters in a key, excluding separators.
+	Length    = Groups * GroupSize
+	Separator = "-"
+
+	dataChars = GroupSize - 1
+)
+
+// ErrInvalid is matched by an *Error with errors.Is.
+var ErrInvalid = errors.New("keyformat: invalid license key")
+
+// Error describes why a key failed validation. Its Error text is written to
+// be shown to the person typing the key.
+type Error struct {
+	// Group is the 1-based group containing the problem, or 0 if the
+	// problem is with the key as a whole, such as its length.
+	Group int
+	// Position is the 1-based position of an invalid character within the
+	// key, ignoring separators, or 0.
+	Position int
+	Reason   string
+}
+
+func (e *Error) Error() string {
+	if e.Group > 0 {
+		return fmt.Sprintf("license key group %d: %s", e.Group, e.Reason)
+	}
+	return "license key: " + e.Reason
+}
+
+func (e *Error) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// Normalize returns key in canonical form: upper case, without whitespace,
+// with the ambiguous characters O, I and L read as 0, 1 and 1, and regrouped
+// with dashes. Characters outside the alphabet are kept so that Validate can
+// point at them.
+func Normalize(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(key) {
+		switch r {
+		case ' ', '\t', '\r', '\n', '-', '_':
+			continue
+		case 'O':
+			r = '0'
+		case 'I', 'L':
+			r = '1'
+		}
+		b.WriteRune(r)
+	}
+	return group([]rune(b.String()))
+}
+
+// group inserts separators between the groups of raw, a key without
+// separators.
+func group(raw []rune) string {
+	var b strings.Builder
+	for i, r := range raw {
+		if i > 0 && i%GroupSize == 0 {
+			b.WriteString(Separator)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Validate checks the characters, length and check characters of key after
+// normalizing it with Normalize. It returns an *Error describing the first
+// problem found.
+func Validate(key string) error {
+	raw := []rune(strings.ReplaceAll(Normalize(key), Separator, ""))
+	if len(raw) == 0 {
+		return &Error{Reason: "no license key entered"}
+	}
+	for i, r := range raw {
+		if !strings.ContainsRune(Alphabet, r) {
+			return &Error{
+				Group:    i/GroupSize + 1,
+				Position: i + 1,
+				Reason:   fmt.Sprintf("%q is not a valid character", r),
+			}
+		}
+	}
+	if len(raw) != Length {
+		return &Error{Reason: fmt.Sprintf("expected %d characters, got %d", Length, len(raw))}
+	}
+	for g := 0; g < Groups; g++ {
+		chars := string(raw[g*GroupSize : (g+1)*GroupSize])
+		if checkChar(g, chars[:dataChars]) != chars[dataChars] {
+			return &Error{Group: g + 1, Reason: "looks mistyped; check each character"}
+		}
+	}
+	return nil
+}
+
+// Valid reports whether key passes Validate.
+func Valid(key string) bool {
+	return Validate(key) == nil
+}
+
+// Generate returns a new random key in canonical form, carrying 100 bits of
+// entropy.
+func Generate() (string, error) {
+	var random [Groups * dataChars]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return "", fmt.Errorf("keyformat: %w", err)
+	}
+	raw := make([]rune, 0, Length)
+	for g := 0; g < Groups; g++ {
+		data := make([]byte, dataChars)
+		for i := range data {
+			data[i] = Alphabet[random[g*dataChars+i]%byte(len(Alphabet))]
+		}
+		raw = append(raw, []rune(string(data))...)
+		raw = append(raw, rune(checkChar(g, string(data))))
+	}
+	return group(raw), nil
+}
+
+// checkChar computes the Luhn mod 32 check character for the data
+// characters of group index g. The group index is mixed in so that groups
+// entered in the wrong order are detected.
+func checkChar(g int, data string) byte {
+	const n = len(Alphabet)
+	factor, sum := 2, 0
+	input := string(Alphabet[g%n]) + data
+	for i := len(input) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(Alphabet, input[i])
+		factor = 3 - factor
+		sum += addend/n + addend%n
+	}
+	return Alphabet[(n-sum%n)%n]
+}
@@ -0,0 +1,112 @@
+package keyformat
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// validKey is the key from the package documentation.
+const validKey = "7K3Q0-0M9XH-R2D4B-HV8TN-ABCDJ"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{validKey, validKey},
+		{"7k3q0-0m9xh-r2d4b-hv8tn-abcdj", validKey},
+		{"7K3Q00M9XHR2D4BHV8TNABCDJ", validKey},
+		{" 7K3Q0 0M9XH\tR2D4B_HV8TN\nABCDJ ", validKey},
+		{"7k3qo-om9xh-r2d4b-hv8tn-abcdj", validKey},
+		{"il", "11"},
+		{"abcdef", "ABCDE-F"},
+		{"ab!cd", "AB!CD"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		key, err := Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Validate(key); err != nil {
+			t.Fatalf("Validate(%q): %v", key, err)
+		}
+		if Normalize(key) != key {
+			t.Fatalf("generated key %q is not canonical", key)
+		}
+		if err := Validate(strings.ToLower(strings.ReplaceAll(key, Separator, " "))); err != nil {
+			t.Fatalf("Validate of %q as typed: %v", key, err)
+		}
+		if seen[key] {
+			t.Fatalf("Generate repeated %q", key)
+		}
+		seen[key] = true
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		group    int
+		position int
+	}{
+		{"empty", "", 0, 0},
+		{"only separators", " - ", 0, 0},
+		{"too short", "7K3Q0-0M9XH-R2D4B-HV8TN", 0, 0},
+		{"too long", validKey + "0", 0, 0},
+		{"invalid character", "7K3Q0-0M9XH-R2D4B-HV8TN-ABCD!", 5, 25},
+		{"U is not in the alphabet", "7K3Q0-0MUXH-R2D4B-HV8TN-ABCDJ", 2, 8},
+		{"mistyped character", "7K3Q0-0M9XH-R2E4B-HV8TN-ABCDJ", 3, 0},
+		{"mistyped check character", "7K3Q0-0M9XH-R2D4B-HV8TN-ABCDK", 5, 0},
+		{"swapped characters", "7K3Q0-M09XH-R2D4B-HV8TN-ABCDJ", 2, 0},
+		{"swapped groups", "0M9XH-7K3Q0-R2D4B-HV8TN-ABCDJ", 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.key)
+			var kerr *Error
+			if !errors.As(err, &kerr) {
+				t.Fatalf("Validate(%q) = %v, want an *Error", tt.key, err)
+			}
+			if !errors.Is(err, ErrInvalid) {
+				t.Fatalf("Validate(%q) does not match ErrInvalid", tt.key)
+			}
+			if kerr.Group != tt.group || kerr.Position != tt.position {
+				t.Fatalf("Validate(%q): group %d, position %d (%s), want %d and %d", tt.key, kerr.Group, kerr.Position, kerr.Reason, tt.group, tt.position)
+			}
+		})
+	}
+
+	if !Valid(validKey) {
+		t.Fatalf("Valid(%q) = false", validKey)
+	}
+}
+
+// TestValidateRejectsSubstitutions checks that the check characters catch
+// every single-character typo.
+func TestValidateRejectsSubstitutions(t *testing.T) {
+	raw := strings.ReplaceAll(validKey, Separator, "")
+	for i := range raw {
+		for _, r := range Alphabet {
+			if byte(r) == raw[i] {
+				continue
+			}
+			typo := raw[:i] + string(r) + raw[i+1:]
+			err := Validate(typo)
+			var kerr *Error
+			if !errors.As(err, &kerr) || kerr.Group != i/GroupSize+1 {
+				t.Fatalf("Validate(%q) = %v, want an error in group %d", group([]rune(typo)), err, i/GroupSize+1)
+			}
+		}
+	}
+}
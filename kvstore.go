@@ -0,0 +1,353 @@
+package licenseedict
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// sharedStateTimeout bounds each key-value operation made on behalf of a
+// call that has no context, such as Validate.
+const sharedStateTimeout = 2 * time.Second
+
+// sharedRevocationTTL is how long a revocation recorded in shared state is
+// honored unless the server accepts the license again first.
+const sharedRevocationTTL = 24 * time.Hour
+
+// KeyValueStore is the small key-value API the SDK needs to share state
+// between instances of a horizontally scaled service. It maps directly onto
+// Redis, and onto other stores with atomic counters. The SDK does not depend
+// on a client library; an adapter for github.com/redis/go-redis looks like:
+//
+//	type redisKV struct{ rdb *redis.Client }
+//
+//	func (r redisKV) Get(ctx context.Context, key string) ([]byte, bool, error) {
+//	    b, err := r.rdb.Get(ctx, key).Bytes()
+//	    if errors.Is(err, redis.Nil) {
+//	        return nil, false, nil
+//	    }
+//	    return b, err == nil, err
+//	}
+//
+//	func (r redisKV) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+//	    return r.rdb.Set(ctx, key, value, ttl).Err()
+//	}
+//
+//	func (r redisKV) Delete(ctx context.Context, key string) error {
+//	    return r.rdb.Del(ctx, key).Err()
+//	}
+//
+//	func (r redisKV) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+//	    return r.rdb.IncrBy(ctx, key, delta).Result()
+//	}
+type KeyValueStore interface {
+	// Get returns the value stored at key, with ok false if there is none.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores value at key. A zero ttl means the key does not expire.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// IncrBy atomically adds delta to the integer at key, treating a missing
+	// key as zero, and returns the new value, like Redis INCRBY.
+	IncrBy(ctx context.Context, key string, delta int64) (int64, error)
+}
+
+// NewKVCacheStore returns a CacheStore that keeps the license cache at key in
+// kv, for use with WithCacheStore.
+func NewKVCacheStore(kv KeyValueStore, key string) CacheStore {
+	return &kvCacheStore{kv: kv, key: func() string { return key }}
+}
+
+// kvCacheStore keeps the license cache in a KeyValueStore. key returns the
+// key to use; an empty key means there is nothing to load or save.
+type kvCacheStore struct {
+	kv  KeyValueStore
+	key func() string
+}
+
+func (s *kvCacheStore) Load() ([]byte, error) {
+	key := s.key()
+	if key == "" {
+		return nil, os.ErrNotExist
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sharedStateTimeout)
+	defer cancel()
+	data, ok, err := s.kv.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return data, nil
+}
+
+func (s *kvCacheStore) Save(data []byte) error {
+	key := s.key()
+	if key == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sharedStateTimeout)
+	defer cancel()
+	return s.kv.Set(ctx, key, data, 0)
+}
+
+func (s *kvCacheStore) Delete() error {
+	key := s.key()
+	if key == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sharedStateTimeout)
+	defer cancel()
+	return s.kv.Delete(ctx, key)
+}
+
+// WithSharedState shares client state between the instances of a scaled-out
+// service through kv, with every key under prefix (for example
+// "myapp:license:"):
+//
+//   - The license cache, so a token verified by one instance is available to
+//     the others. Each license is cached under its own key, derived from
+//     the license ID of the token in use, and a cached license is only
+//     served for the token it was cached for or a verified token for the
+//     same license. It replaces the file cache and WithCacheStore.
+//   - Revocations. When the server reports the license revoked, the license
+//     ID is recorded and validation fails with LicenseRevoked on every
+//     instance, not just the one that was told. The record expires after
+//     24 hours and is cleared as soon as a heartbeat or renewal succeeds,
+//     so a license the vendor reinstates or reissues is not blocked.
+//   - Metering. RecordUsage adds to shared counters, and each flush reports
+//     and subtracts whatever the counters hold, so usage recorded on one
+//     instance is reported even if that instance stops. An instance only
+//     flushes metrics it has recorded itself.
+//
+// Errors from kv do not fail validation; they are reported to the error
+// hooks.
+func WithSharedState(kv KeyValueStore, prefix string) Option {
+	return func(c *clientConfig) {
+		c.sharedState = &sharedState{kv: kv, prefix: prefix}
+	}
+}
+
+// sharedState is the state shared through WithSharedState. Its methods are
+// no-ops on a nil receiver.
+type sharedState struct {
+	kv     KeyValueStore
+	prefix string
+}
+
+// cacheStore returns the shared license cache. licenseID returns the
+// license ID of the token in use, which selects the key.
+func (s *sharedState) cacheStore(licenseID func() string) CacheStore {
+	return &kvCacheStore{kv: s.kv, key: func() string {
+		id := licenseID()
+		if id == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(id))
+		return s.prefix + "cache:" + hex.EncodeToString(sum[:16])
+	}}
+}
+
+func (s *sharedState) revokedKey(licenseID string) string {
+	return s.prefix + "revoked:" + licenseID
+}
+
+func (s *sharedState) usageKey(metric string) string {
+	return s.prefix + "usage:" + strings.ReplaceAll(metric, ":", "_")
+}
+
+// isRevoked reports whether another instance recorded licenseID as revoked.
+func (s *sharedState) isRevoked(licenseID string) (bool, error) {
+	if s == nil || licenseID == "" {
+		return false, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sharedStateTimeout)
+	defer cancel()
+	_, ok, err := s.kv.Get(ctx, s.revokedKey(licenseID))
+	return ok, err
+}
+
+// markRevoked records licenseID as revoked for all instances.
+func (s *sharedState) markRevoked(licenseID string) error {
+	if s == nil || licenseID == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sharedStateTimeout)
+	defer cancel()
+	return s.kv.Set(ctx, s.revokedKey(licenseID), []byte(time.Now().UTC().Format(time.RFC3339)), sharedRevocationTTL)
+}
+
+// clearRevoked removes a revocation recorded for licenseID.
+func (s *sharedState) clearRevoked(licenseID string) error {
+	if s == nil || licenseID == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sharedStateTimeout)
+	defer cancel()
+	return s.kv.Delete(ctx, s.revokedKey(licenseID))
+}
+
+// addUsage adds quantity to the shared counter for metric.
+func (s *sharedState) addUsage(ctx context.Context, metric string, quantity int64) error {
+	_, err := s.kv.IncrBy(ctx, s.usageKey(metric), quantity)
+	return err
+}
+
+// usage returns the shared counter for metric.
+func (s *sharedState) usage(ctx context.Context, metric string) (int64, error) {
+	return s.kv.IncrBy(ctx, s.usageKey(metric), 0)
+}
+
+// claimUsage takes the current count for metric off the shared counter and
+// returns it. If another instance claimed the same units concurrently, the
+// counter would go negative; the claim is then handed back and 0 returned.
+// Units are conserved either way.
+func (s *sharedState) claimUsage(ctx context.Context, metric string) (int64, error) {
+	n, err := s.usage(ctx, metric)
+	if err != nil || n <= 0 {
+		return 0, err
+	}
+	left, err := s.kv.IncrBy(ctx, s.usageKey(metric), -n)
+	if err != nil {
+		return 0, err
+	}
+	if left < 0 {
+		_, err := s.kv.IncrBy(ctx, s.usageKey(metric), n)
+		return 0, err
+	}
+	return n, nil
+}
+
+// shareRevocation records the current license as revoked in shared state.
+func (c *Client) shareRevocation() {
+	license := c.License()
+	if license == nil {
+		return
+	}
+	if err := c.cfg.sharedState.markRevoked(license.LicenseID); err != nil {
+		c.hookError(fmt.Errorf("licenseedict: record shared revocation: %w", err))
+	}
+}
+
+// clearSharedRevocation removes a shared revocation of the license of token
+// after the server accepted or reissued it. The token is not verified here;
+// it is about to be verified by the caller or was already.
+func (c *Client) clearSharedRevocation(token string) {
+	if c.cfg.sharedState == nil {
+		return
+	}
+	payload, err := decodeTokenPayload(token)
+	if err != nil {
+		return
+	}
+	if err := c.cfg.sharedState.clearRevoked(payload.LicenseID); err != nil {
+		c.hookError(fmt.Errorf("licenseedict: clear shared revocation: %w", err))
+	}
+}
+
+// sharedCacheID returns the license ID of the token in use, read without
+// verification; it only selects the shared cache key, and loaded licenses
+// are verified and matched against the token.
+func (c *Client) sharedCacheID() string {
+	payload, err := decodeTokenPayload(c.currentToken())
+	if err != nil {
+		return ""
+	}
+	return payload.LicenseID
+}
+
+// recordSharedUsage adds quantity to the shared counter for metric.
+func (c *Client) recordSharedUsage(metric string, quantity int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sharedStateTimeout)
+	defer cancel()
+	if err := c.cfg.sharedState.addUsage(ctx, metric, quantity); err != nil {
+		return fmt.Errorf("licenseedict: record shared usage: %w", err)
+	}
+	m := &c.meter
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.shared == nil {
+		m.shared = make(map[string]struct{})
+	}
+	m.shared[metric] = struct{}{}
+	return nil
+}
+
+// sharedMetrics returns the metrics this instance recorded in shared state.
+func (c *Client) sharedMetrics() []string {
+	m := &c.meter
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	metrics := make([]string, 0, len(m.shared))
+	for metric := range m.shared {
+		metrics = append(metrics, metric)
+	}
+	return metrics
+}
+
+// sharedUsage returns the shared counters of the metrics this instance
+// recorded. Counters that cannot be read are left out.
+func (c *Client) sharedUsage() []UsageRecord {
+	s := c.cfg.sharedState
+	if s == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sharedStateTimeout)
+	defer cancel()
+	var out []UsageRecord
+	for _, metric := range c.sharedMetrics() {
+		if n, err := s.usage(ctx, metric); err == nil && n > 0 {
+			out = append(out, UsageRecord{Metric: metric, Quantity: n})
+		}
+	}
+	return out
+}
+
+// claimSharedUsage moves the shared counters of the metrics this instance
+// recorded into its local buffer for reporting.
+func (c *Client) claimSharedUsage(ctx context.Context) error {
+	s := c.cfg.sharedState
+	if s == nil {
+		return nil
+	}
+	batch := make(map[string]*UsageRecord)
+	var claimErr error
+	now := time.Now()
+	for _, metric := range c.sharedMetrics() {
+		n, err := s.claimUsage(ctx, metric)
+		if err != nil {
+			claimErr = fmt.Errorf("licenseedict: claim shared usage: %w", err)
+			continue
+		}
+		if n > 0 {
+			batch[metric] = &UsageRecord{Metric: metric, Quantity: n, Since: now}
+		}
+	}
+	if len(batch) > 0 {
+		c.requeueUsage(batch)
+	}
+	return claimErr
+}
+
+// returnSharedUsage hands usage claimed but not reported back to the shared
+// counters so another instance reports it.
+func (c *Client) returnSharedUsage() {
+	m := &c.meter
+	m.mu.Lock()
+	batch := m.pending
+	m.pending = nil
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), sharedStateTimeout)
+	defer cancel()
+	for metric, rec := range batch {
+		if err := c.cfg.sharedState.addUsage(ctx, metric, rec.Quantity); err != nil {
+			c.hookError(fmt.Errorf("licenseedict: return shared usage: %w", err))
+		}
+	}
+}
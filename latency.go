@@ -0,0 +1,128 @@
+package licenseedict
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+const (
+	defaultProbeInterval = 5 * time.Minute
+	healthPath           = "/health"
+)
+
+// latencyProber periodically measures the round-trip time to each configured
+// server so requests prefer the fastest healthy endpoint.
+type latencyProber struct {
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// startLatencyProbing launches the probe goroutine. It is a no-op unless
+// latency-based selection was enabled via WithLatencySelection.
+func (c *Client) startLatencyProbing() {
+	if !c.cfg.latencySelection || !c.networkAvailable() {
+		return
+	}
+	interval := c.cfg.probeInterval
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+
+	c.prober = &latencyProber{
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+	go c.probeLoop(interval, c.prober.stopCh, c.prober.doneCh)
+}
+
+func (c *Client) stopLatencyProbing() {
+	if c.prober == nil {
+		return
+	}
+	close(c.prober.stopCh)
+	<-c.prober.doneCh
+	c.prober = nil
+}
+
+func (c *Client) probeLoop(interval time.Duration, stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	c.probeServers(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			c.probeServers(ctx)
+		}
+	}
+}
+
+// probeServers measures every candidate server and emits
+// EventEndpointSwitched when the preferred server changes.
+func (c *Client) probeServers(ctx context.Context) {
+	urls := c.serverURLs()
+	if len(urls) < 2 || c.deferForQuietHours("latency probe") {
+		return
+	}
+	before := c.resolveServerURL()
+
+	for _, u := range urls {
+		rtt, err := c.http.probe(ctx, c.endpointURL(u, c.cfg.endpoints.Health, healthPath))
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			c.servers.markFailure(u)
+			continue
+		}
+		c.servers.recordLatency(u, rtt)
+	}
+
+	if after := c.resolveServerURL(); after != before {
+		c.emitEvent(Event{Type: EventEndpointSwitched, Message: "switched to " + after, Data: after})
+	}
+}
+
+// recordLatency stores a successful probe result for url.
+func (p *serverPool) recordLatency(url string, rtt time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.latency == nil {
+		p.latency = make(map[string]time.Duration)
+	}
+	p.latency[url] = rtt
+	delete(p.health, url)
+}
+
+// sortByLatency orders urls by their last measured round-trip time. Servers
+// that have not been probed keep their relative order after probed ones.
+func (p *serverPool) sortByLatency(urls []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.latency) == 0 {
+		return
+	}
+	sort.SliceStable(urls, func(i, j int) bool {
+		li, iok := p.latency[urls[i]]
+		lj, jok := p.latency[urls[j]]
+		if iok != jok {
+			return iok
+		}
+		return iok && li < lj
+	})
+}
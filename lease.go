@@ -0,0 +1,246 @@
+package licenseedict
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrLeaseReleased is returned by SeatLease.Err after the lease was released.
+var ErrLeaseReleased = errors.New("licenseedict: seat lease released")
+
+// SeatLease is a seat held on the server by one instance. Each lease keeps
+// its seat alive with its own heartbeat, so a single Client can hold several
+// leases, for example one per user session on a shared host. A lease ends
+// when it is released, when the server takes the seat away, or when the
+// client is closed; Done is closed and Err reports why.
+type SeatLease struct {
+	c    *Client
+	opts HeartbeatOptions
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	interval time.Duration
+	status   *HeartbeatStatus
+	err      error
+}
+
+// AcquireSeat claims a seat for the instance described by opts and keeps it
+// alive in the background until the lease ends. If opts.InstanceID is empty,
+// the ID from WithInstanceID is used, or a random ID if none is configured.
+// The first heartbeat is sent synchronously: if the server refuses the seat,
+// AcquireSeat returns the error and no lease.
+func (c *Client) AcquireSeat(ctx context.Context, opts HeartbeatOptions) (*SeatLease, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if err := c.requireServer(); err != nil {
+		return nil, err
+	}
+	if c.currentToken() == "" {
+		return nil, ErrNoToken
+	}
+	if opts.InstanceID == "" {
+		opts.InstanceID = c.cfg.instanceID
+	}
+	if opts.InstanceID == "" {
+		opts.InstanceID = newRequestID()
+	}
+
+	interval := c.cfg.heartbeatInterval
+	if interval == 0 {
+		interval = defaultHeartbeatInterval
+	}
+	l := &SeatLease{c: c, opts: opts, interval: interval, done: make(chan struct{})}
+	if _, err := l.Renew(ctx); err != nil {
+		return nil, err
+	}
+
+	loopCtx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+
+	c.mu.Lock()
+	if c.leases == nil {
+		c.leases = make(map[*SeatLease]struct{})
+	}
+	c.leases[l] = struct{}{}
+	c.mu.Unlock()
+
+	go l.loop(loopCtx)
+	return l, nil
+}
+
+// AcquireFeatureSeat claims a seat in the pool for feature, for licenses
+// that limit concurrency of some features separately from the base seat
+// count. It is AcquireSeat with opts.Features set to feature; the lease's
+// instance ID defaults to the client instance ID suffixed with the feature
+// name, so it does not collide with the base seat's heartbeat.
+func (c *Client) AcquireFeatureSeat(ctx context.Context, feature string, opts ...HeartbeatOptions) (*SeatLease, error) {
+	var o HeartbeatOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.InstanceID == "" && c.cfg.instanceID != "" {
+		o.InstanceID = c.cfg.instanceID + ":" + feature
+	}
+	o.Features = []string{feature}
+	return c.AcquireSeat(ctx, o)
+}
+
+// InstanceID returns the instance ID the lease heartbeats for.
+func (l *SeatLease) InstanceID() string {
+	return l.opts.InstanceID
+}
+
+// Status returns the server's most recent response for this lease.
+func (l *SeatLease) Status() *HeartbeatStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.status == nil {
+		return nil
+	}
+	status := *l.status
+	return &status
+}
+
+// Done returns a channel that is closed when the lease ends.
+func (l *SeatLease) Done() <-chan struct{} {
+	return l.done
+}
+
+// Err returns nil while the lease is active. After Done is closed it returns
+// ErrLeaseReleased, ErrClientClosed, or the heartbeat error that cost the
+// lease its seat.
+func (l *SeatLease) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Renew sends a heartbeat for the lease immediately. Transient failures are
+// returned but do not end the lease; a rejection by the server does.
+func (l *SeatLease) Renew(ctx context.Context) (*HeartbeatStatus, error) {
+	if err := l.Err(); err != nil {
+		return nil, err
+	}
+	c := l.c
+	resp, res, err := c.postHeartbeat(ctx, c.currentToken(), l.opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		c.emitEvent(res.event(Event{Type: EventHeartbeatError, Message: fmt.Sprintf("lease %s: %v", l.opts.InstanceID, err)}))
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: "lease heartbeat request failed", Err: err})
+	}
+
+	l.mu.Lock()
+	l.status = &resp
+	if resp.HeartbeatInterval > 0 {
+		l.interval = time.Duration(resp.HeartbeatInterval) * time.Second
+	}
+	l.mu.Unlock()
+
+	var leaseErr *ValidationError
+	switch {
+	case res.StatusCode == statusOK:
+		c.emitEvent(res.event(Event{Type: EventHeartbeatOK, Message: "lease " + l.opts.InstanceID + " heartbeat accepted", Data: resp}))
+		c.setSuspended(res, false, "")
+		c.clearSharedRevocation(c.currentToken())
+		return &resp, nil
+	case res.StatusCode == statusTooManyRequests:
+		c.emitEvent(res.event(Event{Type: EventHeartbeatRejected, Message: "lease " + l.opts.InstanceID + ": seat limit reached", Data: resp}))
+		leaseErr = &ValidationError{Code: SeatLimitReached, Message: "seat limit reached"}
+	case res.StatusCode == statusForbidden && resp.Status == heartbeatStatusRevoked:
+		leaseErr = &ValidationError{Code: LicenseRevoked, Message: "server reports the license as revoked"}
+		c.shareRevocation()
+	case res.StatusCode == statusForbidden && resp.Status == heartbeatStatusSuspended:
+		// The seat is kept; the lease resumes once the suspension clears.
+		c.setSuspended(res, true, resp.Reason)
+		return &resp, res.annotate(suspendedError(resp.Reason))
+	case res.StatusCode == statusForbidden && resp.Status == heartbeatStatusAttestationRejected:
+		c.emitEvent(res.event(Event{Type: EventAttestationRejected, Message: "server rejected the binary attestation", Data: resp}))
+		leaseErr = &ValidationError{Code: AttestationRejected, Message: "server rejected the binary attestation"}
+	case res.StatusCode == statusForbidden && resp.Status == heartbeatStatusRegionRestricted:
+		leaseErr = &ValidationError{Code: RegionRestricted, Message: "server rejected heartbeat: license is restricted to other regions"}
+	case res.StatusCode == statusForbidden, res.StatusCode == statusUnauthorized:
+		leaseErr = &ValidationError{Code: ReactivationRequired, Message: fmt.Sprintf("server rejected lease token with status %d", res.StatusCode)}
+	default:
+		c.emitEvent(res.event(Event{Type: EventHeartbeatError, Message: fmt.Sprintf("lease %s heartbeat returned status %d", l.opts.InstanceID, res.StatusCode), Data: resp}))
+		return &resp, res.annotate(&ValidationError{Code: ServerUnreachable, Message: fmt.Sprintf("lease heartbeat returned status %d", res.StatusCode)})
+	}
+
+	err = res.annotate(leaseErr)
+	l.end(err)
+	return &resp, err
+}
+
+// Release stops the lease's heartbeat and returns the seat to the server.
+// It is a no-op if the lease has already ended.
+func (l *SeatLease) Release(ctx context.Context) error {
+	if !l.end(ErrLeaseReleased) {
+		return nil
+	}
+	token := l.c.currentToken()
+	if token == "" {
+		return ErrNoToken
+	}
+	_, err := l.c.checkout(ctx, token, l.opts)
+	return err
+}
+
+// end marks the lease as finished with err and stops its heartbeat. It
+// reports whether this call ended the lease.
+func (l *SeatLease) end(err error) bool {
+	l.mu.Lock()
+	if l.err != nil {
+		l.mu.Unlock()
+		return false
+	}
+	l.err = err
+	l.mu.Unlock()
+
+	if l.cancel != nil {
+		l.cancel()
+	}
+	close(l.done)
+
+	l.c.mu.Lock()
+	delete(l.c.leases, l)
+	l.c.mu.Unlock()
+	return true
+}
+
+func (l *SeatLease) loop(ctx context.Context) {
+	for {
+		l.mu.Lock()
+		interval := l.interval
+		l.mu.Unlock()
+
+		timer := time.NewTimer(l.c.jitter(interval))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			l.Renew(ctx)
+		}
+	}
+}
+
+// endLeases ends every active lease with err, without releasing the seats.
+func (c *Client) endLeases(err error) {
+	c.mu.Lock()
+	leases := make([]*SeatLease, 0, len(c.leases))
+	for l := range c.leases {
+		leases = append(leases, l)
+	}
+	c.mu.Unlock()
+
+	for _, l := range leases {
+		l.end(err)
+	}
+}
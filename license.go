@@ -1,28 +1,106 @@
 package licenseedict
 
-import "time"
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"time"
+)
 
 // License holds the decoded and validated license information.
 type License struct {
-	Valid       bool      `json:"valid"`
-	LicenseID   string    `json:"license_id"`
-	ProductID   string    `json:"product_id"`
-	LicenseKey  string    `json:"license_key"`
-	Licensee    string    `json:"licensee"`
-	Plan        string    `json:"plan"`
-	Features    []string  `json:"features"`
-	MaxSeats    int       `json:"max_seats"`
-	IssuedAt    time.Time `json:"issued_at"`
-	ExpiresAt   time.Time `json:"expires_at"`
-	ServerURL   string    `json:"server_url"`
-	SignedToken string    `json:"signed_token"`
-}
-
-// HasFeature returns true if the license includes the named feature.
+	Valid            bool      `json:"valid"`
+	LicenseID        string    `json:"license_id"`
+	ProductID        string    `json:"product_id"`
+	LicenseKey       string    `json:"license_key"`
+	Licensee         string    `json:"licensee"`
+	Plan             string    `json:"plan"`
+	Features         []string  `json:"features"`
+	MaxSeats         int       `json:"max_seats"`
+	IssuedAt         time.Time `json:"issued_at"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	ServerURL        string    `json:"server_url"`
+	SignedToken      string    `json:"signed_token"`
+	MaintenanceUntil time.Time `json:"maintenance_until"`
+	AllowedApps      []string  `json:"allowed_apps,omitempty"`
+	AllowedRegions   []string  `json:"allowed_regions,omitempty"`
+	AllowedIPRanges  []string  `json:"allowed_ip_ranges,omitempty"`
+	ServerURLs       []string  `json:"server_urls,omitempty"`
+
+	// Fingerprint is the host binding of a node-locked license: hashed host
+	// components, of which a tolerance must match. See
+	// WithFingerprintTolerance and Client.Rebind.
+	Fingerprint HostFingerprint `json:"fingerprint,omitempty"`
+
+	// Entitlements are typed, per-license limits and settings such as
+	// "max_users". Read them with Entitlement.
+	Entitlements map[string]json.RawMessage `json:"entitlements,omitempty"`
+
+	// LicenseModel is how the license is enforced, such as LicenseModelSite.
+	// It is empty for licenses issued without a model.
+	LicenseModel LicenseModel `json:"license_model,omitempty"`
+
+	// OrgID, TenantID and ContractID identify the organization, the tenant
+	// (end client) and the contract the license was issued under. They are
+	// empty for licenses issued without them. See WithTenant.
+	OrgID      string `json:"org_id,omitempty"`
+	TenantID   string `json:"tenant_id,omitempty"`
+	ContractID string `json:"contract_id,omitempty"`
+
+	// DelegationKey is set on licenses that may issue child licenses: the
+	// base64 Ed25519 public key the licensee signs them with.
+	DelegationKey string `json:"delegation_key,omitempty"`
+
+	// Parent is the delegating license when this license was issued by a
+	// reseller under a delegation chain; see issuer.Issuer.Delegate.
+	Parent *License `json:"parent,omitempty"`
+
+	// Suspended is set while the server reports the license as suspended.
+	// A suspended license remains Valid; see WithSuspensionHandler.
+	Suspended bool `json:"suspended,omitempty"`
+
+	// features indexes Features for HasFeature; it is not serialized.
+	features featureIndex
+}
+
+// LicenseModel identifies how a license is enforced.
+type LicenseModel string
+
+// License models. The model decides which client subsystems are enabled by
+// default; see WithAutoHeartbeat, WithFingerprintChecks and WithMetering.
+const (
+	LicenseModelPerpetual    LicenseModel = "perpetual"
+	LicenseModelSubscription LicenseModel = "subscription"
+	LicenseModelTrial        LicenseModel = "trial"
+	// LicenseModelFloating licenses share a seat pool; heartbeats start
+	// automatically after validation.
+	LicenseModelFloating LicenseModel = "floating"
+	// LicenseModelNodeLocked licenses are bound to one host's fingerprint.
+	LicenseModelNodeLocked LicenseModel = "node-locked"
+	// LicenseModelMetered licenses bill by usage recorded with RecordUsage.
+	LicenseModelMetered LicenseModel = "metered"
+	// LicenseModelSite is a site or enterprise license: instead of enforcing
+	// seats, the client periodically reports distinct hosts for true-up.
+	LicenseModelSite LicenseModel = "site"
+)
+
+// model returns the license model, or "" for a nil license.
+func (l *License) model() LicenseModel {
+	if l == nil {
+		return ""
+	}
+	return l.LicenseModel
+}
+
+// HasFeature returns true if the license includes the named feature. Lookups
+// on licenses produced by the SDK take constant time.
 func (l *License) HasFeature(feature string) bool {
 	if l == nil {
 		return false
 	}
+	if set, ok := l.featureSet(); ok {
+		return set.Has(feature)
+	}
 	for _, f := range l.Features {
 		if f == feature {
 			return true
@@ -41,3 +119,77 @@ func (l *License) IsExpired() bool {
 	}
 	return time.Now().After(l.ExpiresAt)
 }
+
+// IsPerpetual returns true if the license never expires at runtime.
+func (l *License) IsPerpetual() bool {
+	return l != nil && l.ExpiresAt.IsZero()
+}
+
+// CoversRelease returns true if a build produced at buildDate is entitled to
+// run under this license. Licenses without a maintenance window cover every
+// release; otherwise the build must not be newer than MaintenanceUntil.
+func (l *License) CoversRelease(buildDate time.Time) bool {
+	if l == nil {
+		return false
+	}
+	if l.MaintenanceUntil.IsZero() || buildDate.IsZero() {
+		return true
+	}
+	return !buildDate.After(l.MaintenanceUntil)
+}
+
+// AllowsApp returns true if the license may be used by the named application.
+// Licenses without an allowed_apps claim are valid for any application.
+func (l *License) AllowsApp(appName string) bool {
+	if l == nil {
+		return false
+	}
+	if len(l.AllowedApps) == 0 {
+		return true
+	}
+	for _, a := range l.AllowedApps {
+		if a == appName {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsRegion returns true if the license may be used in the given region
+// (an ISO 3166 country or region code, compared case-insensitively).
+// Licenses without an allowed_regions claim are valid in every region.
+func (l *License) AllowsRegion(region string) bool {
+	if l == nil {
+		return false
+	}
+	if len(l.AllowedRegions) == 0 {
+		return true
+	}
+	for _, r := range l.AllowedRegions {
+		if strings.EqualFold(r, region) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsIP returns true if ip falls within one of the license's allowed CIDR
+// ranges. Licenses without an allowed_ip_ranges claim allow every address.
+func (l *License) AllowsIP(ip net.IP) bool {
+	if l == nil {
+		return false
+	}
+	if len(l.AllowedIPRanges) == 0 {
+		return true
+	}
+	for _, cidr := range l.AllowedIPRanges {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
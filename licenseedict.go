@@ -1,6 +1,6 @@
 // Package licenseedict provides a Go SDK for the LicenseEdict licensing platform.
 //
-// It supports three usage patterns:
+// It supports four usage patterns:
 //
 // Pattern 1 -- Simplified functions (new API):
 //
@@ -25,10 +25,36 @@
 //	)
 //	defer client.Close()
 //	license, _ := client.Validate(token)
+//
+// Pattern 4 -- Package-level default client (full features without passing a
+// client around):
+//
+//	licenseedict.Configure(licenseedict.WithPublicKey(publicKeyB64))
+//	defer licenseedict.Close()
+//	licenseedict.Validate(token)
+//	if licenseedict.HasFeature("PRO") { ... }
+//
+// # Builds without network access
+//
+// Building with the licenseedict_nonetwork tag removes the SDK's ability to
+// contact a license server, for deployments whose security review forbids any
+// phone-home capability:
+//
+//	go build -tags licenseedict_nonetwork ./...
+//
+// Such builds do not link net/http or any dialer: the HTTP transport, the
+// DNS cache, and the APIs that take or serve net/http types (WithHTTPClient,
+// WithRequestHeaderInjector, WithHTTPMiddleware, Client.HealthHandler and
+// Client.PublishExpvar) are compiled out. Clients behave as if configured
+// with WithOfflineOnly, and online-only APIs such as Renew, StartHeartbeat
+// and Checkout return an error wrapping ErrNetworkDisabled. Tokens are still
+// validated offline, and the simulated server of WithSimulatedServer keeps
+// working for tests.
 package licenseedict
 
 import (
 	"crypto/ed25519"
+	"fmt"
 	"time"
 )
 
@@ -38,7 +64,14 @@ import (
 // The returned License.Valid indicates whether the license passed all checks.
 // Even when Valid is false, the License struct is populated with decoded data.
 // A non-nil error indicates a fundamental failure (decode error, missing key).
-func CheckLicense(publicKey string, token string) (*License, error) {
+// If the license fails a check requested by an option, such as
+// WithExpectedProductID, the decoded license is returned with Valid set to
+// false alongside a *ValidationError.
+//
+// By default CheckLicense caches the license in a shared directory under
+// os.TempDir. Multi-tenant services should pass WithoutCheckCache or a
+// per-tenant WithCheckCacheDir so licenses are not shared through one file.
+func CheckLicense(publicKey string, token string, opts ...CheckOption) (*License, error) {
 	if publicKey == "" {
 		return &License{}, ErrNoPublicKey
 	}
@@ -51,40 +84,143 @@ func CheckLicense(publicKey string, token string) (*License, error) {
 		return &License{}, err
 	}
 
-	payload, err := verifyToken(pubKey, token)
+	return checkLicense(pubKey, token, newCheckConfig("", "", opts))
+}
+
+// CheckLicenseWithOptions is equivalent to CheckLicense.
+//
+// Deprecated: CheckLicense accepts options directly.
+func CheckLicenseWithOptions(publicKey string, token string, opts ...CheckOption) (*License, error) {
+	return CheckLicense(publicKey, token, opts...)
+}
+
+// CheckOption configures CheckLicense, CheckFeature and CheckLicenseLegacy.
+type CheckOption func(*checkConfig)
+
+type checkConfig struct {
+	appName         string
+	appPublisher    string
+	cacheDir        string
+	disableCache    bool
+	clockSkew       time.Duration
+	strict          bool
+	expectedProduct string
+}
+
+func newCheckConfig(appName, appPublisher string, opts []CheckOption) *checkConfig {
+	cfg := &checkConfig{appName: appName, appPublisher: appPublisher}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithCheckCacheDir stores the cached license in dir instead of the default
+// location.
+func WithCheckCacheDir(dir string) CheckOption {
+	return func(c *checkConfig) {
+		c.cacheDir = dir
+	}
+}
+
+// WithoutCheckCache disables the license cache: the license is neither saved
+// nor used as a fallback when verification fails.
+func WithoutCheckCache() CheckOption {
+	return func(c *checkConfig) {
+		c.disableCache = true
+	}
+}
+
+// WithCheckClockSkew tolerates clock differences of up to d between the
+// issuing server and this host when checking issue and expiry times.
+func WithCheckClockSkew(d time.Duration) CheckOption {
+	return func(c *checkConfig) {
+		c.clockSkew = d
+	}
+}
+
+// WithCheckStrict rejects tokens whose payload is missing required fields or
+// has malformed timestamps, as WithStrictPayload does for clients. Rejected
+// tokens are reported instead of being answered from the cache.
+func WithCheckStrict() CheckOption {
+	return func(c *checkConfig) {
+		c.strict = true
+	}
+}
+
+// WithExpectedProductID marks licenses issued for any other product as
+// invalid and reports a ProductMismatch error, including a license served
+// from the cache.
+func WithExpectedProductID(productID string) CheckOption {
+	return func(c *checkConfig) {
+		c.expectedProduct = productID
+	}
+}
+
+// checkLicense implements the simplified entry points.
+func checkLicense(pubKey ed25519.PublicKey, token string, cfg *checkConfig) (*License, error) {
+	cm := newCacheManager(cfg.appName, cfg.appPublisher, cfg.cacheDir, cfg.disableCache)
+
+	payload, err := verifyToken(pubKey, token, cfg.strict)
 	if err != nil {
+		if cfg.strict && tokenRejected(err) {
+			return &License{}, err
+		}
 		// Try cache fallback
-		cm := newCacheManager("", "", "", false)
 		cached, cacheErr := cm.load()
 		if cacheErr == nil && cached != nil {
-			return cached, nil
+			if cacheErr = verifyCachedLicense(pubKey, cached, cfg.strict); cacheErr == nil {
+				if policyErr := cfg.checkProduct(cached); policyErr != nil {
+					invalid := *cached
+					invalid.Valid = false
+					return &invalid, policyErr
+				}
+				return cached, nil
+			}
+			_, _ = cm.quarantine()
 		}
-		return &License{}, err
+		return &License{}, withCacheFallbackError(err, cacheErr)
 	}
 
 	license := payloadToLicense(payload, token, true)
 
 	// Temporal validity checks
 	now := time.Now()
-	if !payload.IssuedAt.IsZero() && now.Before(payload.IssuedAt) {
+	if !payload.IssuedAt.IsZero() && now.Add(cfg.clockSkew).Before(payload.IssuedAt) {
 		license.Valid = false
 	}
-	if !payload.ExpiresAt.IsZero() && now.After(payload.ExpiresAt) {
+	if !payload.ExpiresAt.IsZero() && now.Add(-cfg.clockSkew).After(payload.ExpiresAt) {
+		license.Valid = false
+	}
+
+	policyErr := cfg.checkProduct(license)
+	if policyErr != nil {
 		license.Valid = false
 	}
 
 	// Cache the license
-	cm := newCacheManager("", "", "", false)
 	_ = cm.save(license)
 
-	return license, nil
+	return license, policyErr
+}
+
+// checkProduct reports a ProductMismatch error if WithExpectedProductID was
+// given and license is for another product.
+func (cfg *checkConfig) checkProduct(license *License) error {
+	if cfg.expectedProduct == "" || license.ProductID == cfg.expectedProduct {
+		return nil
+	}
+	return &ValidationError{
+		Code:    ProductMismatch,
+		Message: fmt.Sprintf("license is for product %q, expected %q", license.ProductID, cfg.expectedProduct),
+	}
 }
 
 // CheckFeature returns true if the license for the given token includes the
 // named feature. This is a convenience function that validates the license and
 // checks the feature in one call.
-func CheckFeature(publicKey string, token string, feature string) (bool, error) {
-	license, err := CheckLicense(publicKey, token)
+func CheckFeature(publicKey string, token string, feature string, opts ...CheckOption) (bool, error) {
+	license, err := CheckLicense(publicKey, token, opts...)
 	if err != nil {
 		return false, err
 	}
@@ -98,7 +234,9 @@ func CheckFeature(publicKey string, token string, feature string) (bool, error)
 // The returned License.Valid indicates whether the license passed all checks.
 // Even when Valid is false, the License struct is populated with decoded data.
 // A non-nil error indicates a fundamental failure (decode error, missing key).
-func CheckLicenseLegacy(signedToken string, publicKey ed25519.PublicKey, appName, appPublisher string) (*License, error) {
+//
+// It accepts the same options as CheckLicense.
+func CheckLicenseLegacy(signedToken string, publicKey ed25519.PublicKey, appName, appPublisher string, opts ...CheckOption) (*License, error) {
 	if publicKey == nil {
 		return &License{}, ErrNoPublicKey
 	}
@@ -106,33 +244,7 @@ func CheckLicenseLegacy(signedToken string, publicKey ed25519.PublicKey, appName
 		return &License{}, ErrNoToken
 	}
 
-	payload, err := verifyToken(publicKey, signedToken)
-	if err != nil {
-		// Try cache fallback
-		cm := newCacheManager(appName, appPublisher, "", false)
-		cached, cacheErr := cm.load()
-		if cacheErr == nil && cached != nil {
-			return cached, nil
-		}
-		return &License{}, err
-	}
-
-	license := payloadToLicense(payload, signedToken, true)
-
-	// Temporal validity checks
-	now := time.Now()
-	if !payload.IssuedAt.IsZero() && now.Before(payload.IssuedAt) {
-		license.Valid = false
-	}
-	if !payload.ExpiresAt.IsZero() && now.After(payload.ExpiresAt) {
-		license.Valid = false
-	}
-
-	// Cache the license
-	cm := newCacheManager(appName, appPublisher, "", false)
-	_ = cm.save(license)
-
-	return license, nil
+	return checkLicense(publicKey, signedToken, newCheckConfig(appName, appPublisher, opts))
 }
 
 // CheckFeatureLegacy returns true if the license includes the named feature.
@@ -0,0 +1,23 @@
+package licenseedict
+
+import "github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK/keyformat"
+
+// KeyFormatError describes why a license key failed the client-side format
+// check. See package keyformat for the key layout.
+type KeyFormatError = keyformat.Error
+
+// ErrInvalidLicenseKey is matched by a *KeyFormatError with errors.Is.
+var ErrInvalidLicenseKey = keyformat.ErrInvalid
+
+// NormalizeLicenseKey returns key in canonical form. It is shorthand for
+// keyformat.Normalize.
+func NormalizeLicenseKey(key string) string {
+	return keyformat.Normalize(key)
+}
+
+// ValidateLicenseKey checks the format and check characters of a license
+// key as entered by a user, returning a *KeyFormatError that names the group
+// to fix. It is shorthand for keyformat.Validate.
+func ValidateLicenseKey(key string) error {
+	return keyformat.Validate(key)
+}
@@ -0,0 +1,148 @@
+package licenseedict
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Merge combines licenses as if they were stacked: the result has the union
+// of their features, the highest MaxSeats, and the latest ExpiresAt and
+// MaintenanceUntil, where a zero time (no expiry) outlasts any date.
+// Entitlements are combined with earlier licenses taking precedence.
+//
+// Nil and invalid licenses are skipped; Merge returns nil if none is valid.
+// Identity fields are taken from the first valid license, except that
+// ProductID is cleared if the licenses are for different products. The result
+// is not backed by a signed token, so SignedToken is empty.
+func Merge(licenses ...*License) *License {
+	var out *License
+	var features FeatureSet
+	for _, l := range licenses {
+		if l == nil || !l.Valid {
+			continue
+		}
+		if out == nil {
+			out = cloneLicense(l)
+			features = l.FeatureSet()
+			continue
+		}
+		if out.ProductID != l.ProductID {
+			out.ProductID = ""
+		}
+		features = features.Union(l.FeatureSet())
+		if l.MaxSeats > out.MaxSeats {
+			out.MaxSeats = l.MaxSeats
+		}
+		out.ExpiresAt = laterDeadline(out.ExpiresAt, l.ExpiresAt)
+		out.MaintenanceUntil = laterDeadline(out.MaintenanceUntil, l.MaintenanceUntil)
+		for name, v := range l.Entitlements {
+			if _, ok := out.Entitlements[name]; !ok {
+				if out.Entitlements == nil {
+					out.Entitlements = make(map[string]json.RawMessage)
+				}
+				out.Entitlements[name] = v
+			}
+		}
+	}
+	if out == nil {
+		return nil
+	}
+	out.Features = features.Slice()
+	out.indexFeatures()
+	return out
+}
+
+// Intersect computes the license allowed by all of licenses: the features
+// they share, the lowest MaxSeats, and the earliest ExpiresAt and
+// MaintenanceUntil. It is used to apply constraints, such as a product
+// plan's ceiling expressed as a License, to a customer's license.
+//
+// The result is valid only if every license is valid; Intersect returns nil
+// if licenses is empty or contains nil. Identity fields are taken from the
+// first license and SignedToken is empty.
+func Intersect(licenses ...*License) *License {
+	if len(licenses) == 0 {
+		return nil
+	}
+	for _, l := range licenses {
+		if l == nil {
+			return nil
+		}
+	}
+
+	out := cloneLicense(licenses[0])
+	features := licenses[0].FeatureSet()
+	for _, l := range licenses[1:] {
+		out.Valid = out.Valid && l.Valid
+		features = features.Intersect(l.FeatureSet())
+		if l.MaxSeats < out.MaxSeats {
+			out.MaxSeats = l.MaxSeats
+		}
+		out.ExpiresAt = earlierDeadline(out.ExpiresAt, l.ExpiresAt)
+		out.MaintenanceUntil = earlierDeadline(out.MaintenanceUntil, l.MaintenanceUntil)
+	}
+	out.Features = features.Slice()
+	out.indexFeatures()
+	return out
+}
+
+// EffectiveLicense merges the valid licenses for productID. Backends use it
+// to aggregate the licenses an organization holds for one product.
+func EffectiveLicense(productID string, licenses ...*License) *License {
+	var matching []*License
+	for _, l := range licenses {
+		if l != nil && l.ProductID == productID {
+			matching = append(matching, l)
+		}
+	}
+	return Merge(matching...)
+}
+
+// cloneLicense returns a copy of l that shares no slices or maps with it and
+// carries no signed token.
+func cloneLicense(l *License) *License {
+	out := *l
+	out.SignedToken = ""
+	out.Features = append([]string(nil), l.Features...)
+	out.AllowedApps = append([]string(nil), l.AllowedApps...)
+	out.AllowedRegions = append([]string(nil), l.AllowedRegions...)
+	out.AllowedIPRanges = append([]string(nil), l.AllowedIPRanges...)
+	out.ServerURLs = append([]string(nil), l.ServerURLs...)
+	if l.Entitlements != nil {
+		out.Entitlements = make(map[string]json.RawMessage, len(l.Entitlements))
+		for k, v := range l.Entitlements {
+			out.Entitlements[k] = v
+		}
+	}
+	if l.Fingerprint != nil {
+		out.Fingerprint = make(HostFingerprint, len(l.Fingerprint))
+		for k, v := range l.Fingerprint {
+			out.Fingerprint[k] = v
+		}
+	}
+	out.features = featureIndex{}
+	return &out
+}
+
+// laterDeadline returns the later of two deadlines, treating zero as never.
+func laterDeadline(a, b time.Time) time.Time {
+	if a.IsZero() || b.IsZero() {
+		return time.Time{}
+	}
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// earlierDeadline returns the earlier of two deadlines, treating zero as
+// never.
+func earlierDeadline(a, b time.Time) time.Time {
+	if a.IsZero() {
+		return b
+	}
+	if b.IsZero() || a.Before(b) {
+		return a
+	}
+	return b
+}
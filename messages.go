@@ -0,0 +1,295 @@
+package licenseedict
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Message IDs for user-presentable messages that do not correspond to a
+// failure code. Catalogs are keyed by these and by the failure codes such as
+// LicenseRevoked.
+const (
+	MessageNoLicense          = "NO_LICENSE"
+	MessageMissingFeatures    = "MISSING_FEATURES"
+	MessageLicenseActive      = "LICENSE_ACTIVE"
+	MessageLicenseActiveUntil = "LICENSE_ACTIVE_UNTIL"
+	MessageUnknownError       = "UNKNOWN_ERROR"
+)
+
+// defaultLanguage is the catalog used when no catalog matches the requested
+// language or a message is missing from it.
+const defaultLanguage = "en"
+
+// MessageCatalog maps message IDs (failure codes or the Message* constants)
+// to message templates for one language. Templates use text/template syntax
+// and may refer to {{.Plan}}, {{.Licensee}}, {{.ProductID}}, {{.ExpiresAt}}
+// (formatted as 2006-01-02), {{.Code}} and {{.Missing}} (a comma-separated
+// list of features).
+type MessageCatalog map[string]string
+
+// ParseMessageCatalog parses a catalog file in the flat JSON format
+// {"LICENSE_REVOKED": "..."} or the go-i18n format
+// {"LICENSE_REVOKED": {"description": "...", "other": "..."}}, so catalogs
+// can be maintained with existing translation tooling.
+func ParseMessageCatalog(data []byte) (MessageCatalog, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("licenseedict: parse message catalog: %w", err)
+	}
+	catalog := make(MessageCatalog, len(raw))
+	for id, value := range raw {
+		var text string
+		if err := json.Unmarshal(value, &text); err == nil {
+			catalog[id] = text
+			continue
+		}
+		var msg struct {
+			Other string `json:"other"`
+		}
+		if err := json.Unmarshal(value, &msg); err != nil || msg.Other == "" {
+			return nil, fmt.Errorf("licenseedict: parse message catalog: %s: want a string or an object with \"other\"", id)
+		}
+		catalog[id] = msg.Other
+	}
+	return catalog, nil
+}
+
+var messages = struct {
+	sync.RWMutex
+	catalogs map[string]MessageCatalog
+}{catalogs: builtinCatalogs()}
+
+// RegisterMessages adds or replaces messages for lang, a BCP 47 tag such as
+// "de" or "pt-BR". Messages missing from catalog keep their previous text.
+// Templates are checked when registered.
+func RegisterMessages(lang string, catalog MessageCatalog) error {
+	for id, text := range catalog {
+		if _, err := template.New(id).Parse(text); err != nil {
+			return fmt.Errorf("licenseedict: message %s: %w", id, err)
+		}
+	}
+	lang = strings.ToLower(lang)
+	messages.Lock()
+	defer messages.Unlock()
+	existing := messages.catalogs[lang]
+	merged := make(MessageCatalog, len(existing)+len(catalog))
+	for id, text := range existing {
+		merged[id] = text
+	}
+	for id, text := range catalog {
+		merged[id] = text
+	}
+	messages.catalogs[lang] = merged
+	return nil
+}
+
+// messageData is the data user message templates are executed with.
+type messageData struct {
+	Code      string
+	Plan      string
+	Licensee  string
+	ProductID string
+	ExpiresAt string
+	Missing   string
+}
+
+// lookupMessage returns the template for id in lang, falling back from a
+// regional tag to its base language ("de-CH" to "de") and then to English.
+func lookupMessage(lang, id string) string {
+	messages.RLock()
+	defer messages.RUnlock()
+	lang = strings.ToLower(strings.ReplaceAll(lang, "_", "-"))
+	for lang != "" {
+		if text, ok := messages.catalogs[lang][id]; ok {
+			return text
+		}
+		i := strings.LastIndexByte(lang, '-')
+		if i < 0 {
+			break
+		}
+		lang = lang[:i]
+	}
+	if text, ok := messages.catalogs[defaultLanguage][id]; ok {
+		return text
+	}
+	return messages.catalogs[defaultLanguage][MessageUnknownError]
+}
+
+func renderMessage(lang, id string, data messageData) string {
+	text := lookupMessage(lang, id)
+	tmpl, err := template.New(id).Parse(text)
+	if err != nil {
+		return text
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return text
+	}
+	return b.String()
+}
+
+// UserMessage returns a message describing err suitable for showing to end
+// users in lang, such as "This license has been revoked. Please contact
+// support." instead of the technical error text. Unknown errors get a
+// generic message. It returns "" for a nil error.
+func UserMessage(err error, lang string) string {
+	if err == nil {
+		return ""
+	}
+	var vErr *ValidationError
+	var missing *MissingFeaturesError
+	switch {
+	case errors.As(err, &missing):
+		return renderMessage(lang, MessageMissingFeatures, messageData{Missing: strings.Join(missing.Missing, ", ")})
+	case errors.As(err, &vErr):
+		return vErr.UserMessage(lang)
+	case errors.Is(err, ErrNoToken):
+		return renderMessage(lang, MessageNoLicense, messageData{})
+	}
+	return renderMessage(lang, MessageUnknownError, messageData{})
+}
+
+// UserMessage returns the user-presentable message for the error's code in
+// lang. See UserMessage.
+func (e *ValidationError) UserMessage(lang string) string {
+	return renderMessage(lang, e.Code, messageData{Code: e.Code})
+}
+
+// UserMessage returns a short user-presentable description of the license
+// status in lang, such as "Your Pro license is active until 2027-01-31."
+func (l *License) UserMessage(lang string) string {
+	data := messageData{
+		Plan:      l.Plan,
+		Licensee:  l.Licensee,
+		ProductID: l.ProductID,
+	}
+	if !l.ExpiresAt.IsZero() {
+		data.ExpiresAt = l.ExpiresAt.Format("2006-01-02")
+	}
+	switch {
+	case l.Suspended:
+		return renderMessage(lang, LicenseSuspended, data)
+	case l.IsExpired():
+		return renderMessage(lang, LicenseNotValidAfter, data)
+	case l.ExpiresAt.IsZero():
+		return renderMessage(lang, MessageLicenseActive, data)
+	}
+	return renderMessage(lang, MessageLicenseActiveUntil, data)
+}
+
+func builtinCatalogs() map[string]MessageCatalog {
+	return map[string]MessageCatalog{
+		"en": {
+			LicenseDecodeError:        "The license could not be read. Please re-enter your license.",
+			PubKeyDecodeError:         "The application is not configured correctly for licensing. Please contact support.",
+			InvalidLicenseSignature:   "The license is not valid for this application. Please re-enter your license.",
+			LicenseNotValidBefore:     "The license is not valid yet. Please check your computer's date and time.",
+			LicenseNotValidAfter:      "Your license has expired. Please renew it to continue.",
+			LicenseRevoked:            "This license has been revoked. Please contact support.",
+			ServerUnreachable:         "The license server could not be reached. Please check your internet connection.",
+			SeatLimitReached:          "All seats for this license are in use. Please try again later or contact your administrator.",
+			RenewalFailed:             "The license could not be renewed. Please try again later.",
+			MaintenanceExpired:        "Your license does not cover this version. Please renew your maintenance or use an earlier version.",
+			ProductMismatch:           "This license is for a different product.",
+			AppMismatch:               "This license does not cover this application.",
+			RegionRestricted:          "This license cannot be used in your region.",
+			ReactivationRequired:      "This license needs to be activated again.",
+			LicenseSuspended:          "Your license is suspended. Please contact support.",
+			AttestationRejected:       "This copy of the application could not be verified. Please reinstall it.",
+			VirtualizedEnvironment:    "This license cannot be used in a virtual machine or container.",
+			FingerprintMismatch:       "This license is activated on a different computer.",
+			TenantMismatch:            "This license belongs to a different organization.",
+			RequestRejected:           "The license server refused the request. Please contact support if the problem persists.",
+			MessageNoLicense:          "No license has been entered.",
+			MessageMissingFeatures:    "Your license does not include: {{.Missing}}.",
+			MessageLicenseActive:      "Your {{.Plan}} license is active.",
+			MessageLicenseActiveUntil: "Your {{.Plan}} license is active until {{.ExpiresAt}}.",
+			MessageUnknownError:       "A licensing error occurred. Please contact support if the problem persists.",
+		},
+		"de": {
+			LicenseDecodeError:        "Die Lizenz konnte nicht gelesen werden. Bitte geben Sie Ihre Lizenz erneut ein.",
+			PubKeyDecodeError:         "Die Anwendung ist für die Lizenzierung nicht richtig eingerichtet. Bitte wenden Sie sich an den Support.",
+			InvalidLicenseSignature:   "Die Lizenz ist für diese Anwendung nicht gültig. Bitte geben Sie Ihre Lizenz erneut ein.",
+			LicenseNotValidBefore:     "Die Lizenz ist noch nicht gültig. Bitte prüfen Sie Datum und Uhrzeit Ihres Computers.",
+			LicenseNotValidAfter:      "Ihre Lizenz ist abgelaufen. Bitte verlängern Sie sie, um fortzufahren.",
+			LicenseRevoked:            "Diese Lizenz wurde widerrufen. Bitte wenden Sie sich an den Support.",
+			ServerUnreachable:         "Der Lizenzserver ist nicht erreichbar. Bitte prüfen Sie Ihre Internetverbindung.",
+			SeatLimitReached:          "Alle Plätze dieser Lizenz sind belegt. Bitte versuchen Sie es später erneut oder wenden Sie sich an Ihren Administrator.",
+			RenewalFailed:             "Die Lizenz konnte nicht verlängert werden. Bitte versuchen Sie es später erneut.",
+			MaintenanceExpired:        "Ihre Lizenz gilt nicht für diese Version. Bitte verlängern Sie Ihre Wartung oder verwenden Sie eine frühere Version.",
+			ProductMismatch:           "Diese Lizenz gilt für ein anderes Produkt.",
+			AppMismatch:               "Diese Lizenz gilt nicht für diese Anwendung.",
+			RegionRestricted:          "Diese Lizenz kann in Ihrer Region nicht verwendet werden.",
+			ReactivationRequired:      "Diese Lizenz muss erneut aktiviert werden.",
+			LicenseSuspended:          "Ihre Lizenz ist gesperrt. Bitte wenden Sie sich an den Support.",
+			AttestationRejected:       "Diese Kopie der Anwendung konnte nicht überprüft werden. Bitte installieren Sie sie neu.",
+			VirtualizedEnvironment:    "Diese Lizenz kann nicht in einer virtuellen Maschine oder einem Container verwendet werden.",
+			FingerprintMismatch:       "Diese Lizenz ist auf einem anderen Computer aktiviert.",
+			TenantMismatch:            "Diese Lizenz gehört zu einer anderen Organisation.",
+			RequestRejected:           "Der Lizenzserver hat die Anfrage abgelehnt. Bitte wenden Sie sich an den Support, wenn das Problem weiterhin besteht.",
+			MessageNoLicense:          "Es wurde keine Lizenz eingegeben.",
+			MessageMissingFeatures:    "Ihre Lizenz umfasst nicht: {{.Missing}}.",
+			MessageLicenseActive:      "Ihre {{.Plan}}-Lizenz ist aktiv.",
+			MessageLicenseActiveUntil: "Ihre {{.Plan}}-Lizenz ist bis {{.ExpiresAt}} aktiv.",
+			MessageUnknownError:       "Ein Lizenzfehler ist aufgetreten. Bitte wenden Sie sich an den Support, wenn das Problem weiterhin besteht.",
+		},
+		"fr": {
+			LicenseDecodeError:        "La licence n'a pas pu être lue. Veuillez saisir à nouveau votre licence.",
+			PubKeyDecodeError:         "L'application n'est pas correctement configurée pour les licences. Veuillez contacter le support.",
+			InvalidLicenseSignature:   "La licence n'est pas valide pour cette application. Veuillez saisir à nouveau votre licence.",
+			LicenseNotValidBefore:     "La licence n'est pas encore valide. Veuillez vérifier la date et l'heure de votre ordinateur.",
+			LicenseNotValidAfter:      "Votre licence a expiré. Veuillez la renouveler pour continuer.",
+			LicenseRevoked:            "Cette licence a été révoquée. Veuillez contacter le support.",
+			ServerUnreachable:         "Le serveur de licences est injoignable. Veuillez vérifier votre connexion Internet.",
+			SeatLimitReached:          "Tous les postes de cette licence sont utilisés. Veuillez réessayer plus tard ou contacter votre administrateur.",
+			RenewalFailed:             "La licence n'a pas pu être renouvelée. Veuillez réessayer plus tard.",
+			MaintenanceExpired:        "Votre licence ne couvre pas cette version. Veuillez renouveler votre maintenance ou utiliser une version antérieure.",
+			ProductMismatch:           "Cette licence concerne un autre produit.",
+			AppMismatch:               "Cette licence ne couvre pas cette application.",
+			RegionRestricted:          "Cette licence ne peut pas être utilisée dans votre région.",
+			ReactivationRequired:      "Cette licence doit être activée à nouveau.",
+			LicenseSuspended:          "Votre licence est suspendue. Veuillez contacter le support.",
+			AttestationRejected:       "Cette copie de l'application n'a pas pu être vérifiée. Veuillez la réinstaller.",
+			VirtualizedEnvironment:    "Cette licence ne peut pas être utilisée dans une machine virtuelle ou un conteneur.",
+			FingerprintMismatch:       "Cette licence est activée sur un autre ordinateur.",
+			TenantMismatch:            "Cette licence appartient à une autre organisation.",
+			RequestRejected:           "Le serveur de licences a refusé la demande. Veuillez contacter le support si le problème persiste.",
+			MessageNoLicense:          "Aucune licence n'a été saisie.",
+			MessageMissingFeatures:    "Votre licence n'inclut pas : {{.Missing}}.",
+			MessageLicenseActive:      "Votre licence {{.Plan}} est active.",
+			MessageLicenseActiveUntil: "Votre licence {{.Plan}} est active jusqu'au {{.ExpiresAt}}.",
+			MessageUnknownError:       "Une erreur de licence s'est produite. Veuillez contacter le support si le problème persiste.",
+		},
+		"es": {
+			LicenseDecodeError:        "No se pudo leer la licencia. Vuelva a introducir su licencia.",
+			PubKeyDecodeError:         "La aplicación no está configurada correctamente para las licencias. Póngase en contacto con soporte.",
+			InvalidLicenseSignature:   "La licencia no es válida para esta aplicación. Vuelva a introducir su licencia.",
+			LicenseNotValidBefore:     "La licencia aún no es válida. Compruebe la fecha y la hora de su equipo.",
+			LicenseNotValidAfter:      "Su licencia ha caducado. Renuévela para continuar.",
+			LicenseRevoked:            "Esta licencia ha sido revocada. Póngase en contacto con soporte.",
+			ServerUnreachable:         "No se pudo conectar con el servidor de licencias. Compruebe su conexión a Internet.",
+			SeatLimitReached:          "Todos los puestos de esta licencia están en uso. Inténtelo más tarde o póngase en contacto con su administrador.",
+			RenewalFailed:             "No se pudo renovar la licencia. Inténtelo más tarde.",
+			MaintenanceExpired:        "Su licencia no cubre esta versión. Renueve su mantenimiento o utilice una versión anterior.",
+			ProductMismatch:           "Esta licencia es para otro producto.",
+			AppMismatch:               "Esta licencia no cubre esta aplicación.",
+			RegionRestricted:          "Esta licencia no se puede usar en su región.",
+			ReactivationRequired:      "Esta licencia debe activarse de nuevo.",
+			LicenseSuspended:          "Su licencia está suspendida. Póngase en contacto con soporte.",
+			AttestationRejected:       "No se pudo verificar esta copia de la aplicación. Vuelva a instalarla.",
+			VirtualizedEnvironment:    "Esta licencia no se puede usar en una máquina virtual o un contenedor.",
+			FingerprintMismatch:       "Esta licencia está activada en otro equipo.",
+			TenantMismatch:            "Esta licencia pertenece a otra organización.",
+			RequestRejected:           "El servidor de licencias rechazó la solicitud. Póngase en contacto con soporte si el problema persiste.",
+			MessageNoLicense:          "No se ha introducido ninguna licencia.",
+			MessageMissingFeatures:    "Su licencia no incluye: {{.Missing}}.",
+			MessageLicenseActive:      "Su licencia {{.Plan}} está activa.",
+			MessageLicenseActiveUntil: "Su licencia {{.Plan}} está activa hasta el {{.ExpiresAt}}.",
+			MessageUnknownError:       "Se produjo un error de licencia. Póngase en contacto con soporte si el problema persiste.",
+		},
+	}
+}
@@ -0,0 +1,245 @@
+package licenseedict
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMeterFlushInterval = time.Minute
+	// maxMeterBuffer bounds the number of distinct metrics held while the
+	// server is unreachable.
+	maxMeterBuffer = 1024
+)
+
+// ErrMeteringDisabled is returned by RecordUsage when the license model does
+// not use metering and it was not enabled with WithMetering.
+var ErrMeteringDisabled = errors.New("licenseedict: metering is not enabled for this license")
+
+// UsageRecord is an aggregated usage quantity for one metric.
+type UsageRecord struct {
+	Metric   string    `json:"metric"`
+	Quantity int64     `json:"quantity"`
+	Since    time.Time `json:"since"`
+}
+
+// meterBuffer aggregates recorded usage between flushes.
+type meterBuffer struct {
+	mu      sync.Mutex
+	pending map[string]*UsageRecord
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+
+	// shared holds the metrics this instance recorded in shared counters
+	// with WithSharedState.
+	shared map[string]struct{}
+}
+
+// RecordUsage adds quantity to metric in the usage buffer. Buffered usage is
+// sent to the server periodically and on Close; call FlushUsage to send it
+// immediately.
+func (c *Client) RecordUsage(metric string, quantity int64) error {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+	if !c.meteringEnabled(c.License().model()) {
+		return ErrMeteringDisabled
+	}
+	if c.cfg.sharedState != nil {
+		return c.recordSharedUsage(metric, quantity)
+	}
+	m := &c.meter
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pending == nil {
+		m.pending = make(map[string]*UsageRecord)
+	}
+	rec, ok := m.pending[metric]
+	if !ok {
+		if len(m.pending) >= maxMeterBuffer {
+			return fmt.Errorf("licenseedict: usage buffer full (%d metrics)", maxMeterBuffer)
+		}
+		rec = &UsageRecord{Metric: metric, Since: time.Now()}
+		m.pending[metric] = rec
+	}
+	rec.Quantity += quantity
+	return nil
+}
+
+// PendingUsage returns the usage recorded but not yet sent to the server.
+// With WithSharedState it also includes the shared counters of the metrics
+// this instance recorded.
+func (c *Client) PendingUsage() []UsageRecord {
+	m := &c.meter
+	m.mu.Lock()
+	out := make([]UsageRecord, 0, len(m.pending))
+	for _, rec := range m.pending {
+		out = append(out, *rec)
+	}
+	m.mu.Unlock()
+	return append(out, c.sharedUsage()...)
+}
+
+// FlushUsage sends buffered usage to the server. On failure the usage is
+// returned to the buffer so it is retried on the next flush.
+func (c *Client) FlushUsage(ctx context.Context) error {
+	if err := c.requireServer(); err != nil {
+		return err
+	}
+	token := c.currentToken()
+	if token == "" {
+		return ErrNoToken
+	}
+	if err := c.claimSharedUsage(ctx); err != nil {
+		return err
+	}
+
+	m := &c.meter
+	m.mu.Lock()
+	batch := m.pending
+	m.pending = nil
+	m.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	records := make([]UsageRecord, 0, len(batch))
+	for _, rec := range batch {
+		records = append(records, *rec)
+	}
+	body := map[string]interface{}{
+		"signed_token": token,
+		"instance_id":  c.cfg.instanceID,
+		"usage":        records,
+		// Usage quantities are needed for billing and are sent at every
+		// consent level; the level is reported so the server can tell.
+		"telemetry_consent": c.TelemetryConsent(),
+	}
+
+	res, err := c.callServer(ctx, methodPost, c.cfg.endpoints.Usage, usagePath, body, nil)
+	if err == nil && res.StatusCode != statusOK {
+		err = res.annotate(&ValidationError{Code: ServerUnreachable, Message: fmt.Sprintf("usage report returned status %d", res.StatusCode)})
+	} else if err != nil {
+		err = res.annotate(&ValidationError{Code: ServerUnreachable, Message: "usage report request failed", Err: err})
+	}
+	if err != nil {
+		c.requeueUsage(batch)
+		return err
+	}
+	// Usage restored from the cache was part of this batch.
+	_ = c.cache.saveUsage(nil)
+	return nil
+}
+
+// requeueUsage merges an unsent batch back into the buffer.
+func (c *Client) requeueUsage(batch map[string]*UsageRecord) {
+	m := &c.meter
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pending == nil {
+		m.pending = batch
+		return
+	}
+	for metric, rec := range batch {
+		if cur, ok := m.pending[metric]; ok {
+			cur.Quantity += rec.Quantity
+			if rec.Since.Before(cur.Since) {
+				cur.Since = rec.Since
+			}
+			continue
+		}
+		m.pending[metric] = rec
+	}
+}
+
+// startMetering launches the periodic usage flush.
+func (c *Client) startMetering() {
+	interval := c.cfg.meterFlushInterval
+	if interval <= 0 {
+		interval = defaultMeterFlushInterval
+	}
+	c.meter.stopCh = make(chan struct{})
+	c.meter.doneCh = make(chan struct{})
+	go c.meterLoop(interval, c.meter.stopCh, c.meter.doneCh)
+}
+
+// stopMetering stops the flush loop and makes a final attempt to send
+// buffered usage, unless it is quiet hours. Usage that is still unsent is
+// stored in the cache for the next run.
+func (c *Client) stopMetering() {
+	c.model.meteringOnce.Do(func() {})
+	if c.meter.stopCh != nil {
+		close(c.meter.stopCh)
+		<-c.meter.doneCh
+
+		if !c.deferForQuietHours("usage report") {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := c.FlushUsage(ctx); err != nil {
+				c.hookError(err)
+			}
+			cancel()
+		}
+	}
+	if c.cfg.sharedState != nil {
+		c.returnSharedUsage()
+		return
+	}
+	if err := c.cache.saveUsage(c.PendingUsage()); err != nil {
+		c.hookError(fmt.Errorf("licenseedict: store pending usage: %w", err))
+	}
+}
+
+// restoreUsage moves usage stored by an earlier run into the buffer. The
+// stored copy is kept until it has been reported.
+func (c *Client) restoreUsage() {
+	if c.cfg.sharedState != nil {
+		return
+	}
+	stored := c.cache.storedUsage()
+	if len(stored) == 0 {
+		return
+	}
+	batch := make(map[string]*UsageRecord, len(stored))
+	for i := range stored {
+		rec := stored[i]
+		if cur, ok := batch[rec.Metric]; ok {
+			cur.Quantity += rec.Quantity
+			continue
+		}
+		batch[rec.Metric] = &rec
+	}
+	c.requeueUsage(batch)
+}
+
+func (c *Client) meterLoop(interval time.Duration, stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if c.deferForQuietHours("usage report") {
+				continue
+			}
+			if err := c.FlushUsage(ctx); err != nil && ctx.Err() == nil {
+				c.hookError(err)
+			}
+		}
+	}
+}
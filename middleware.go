@@ -0,0 +1,39 @@
+//go:build !licenseedict_nonetwork
+
+package licenseedict
+
+import "net/http"
+
+// RoundTripperFunc adapts a function to the http.RoundTripper interface.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the next round tripper in the chain. It may inspect or
+// modify the request, short-circuit with its own response, or observe the
+// response returned by next.
+type Middleware func(next RoundTripperFunc) RoundTripperFunc
+
+// applyMiddleware returns a copy of client whose transport runs the given
+// middleware, the first being outermost. The caller's client is not modified.
+func applyMiddleware(client *http.Client, middleware []Middleware) *http.Client {
+	if len(middleware) == 0 {
+		return client
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	next := RoundTripperFunc(base.RoundTrip)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		next = middleware[i](next)
+	}
+
+	wrapped := *client
+	wrapped.Transport = next
+	return &wrapped
+}
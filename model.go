@@ -0,0 +1,61 @@
+package licenseedict
+
+import (
+	"context"
+	"sync"
+)
+
+// modelState tracks the subsystems started on behalf of the license model so
+// each is started at most once per client.
+type modelState struct {
+	heartbeatOnce sync.Once
+	meteringOnce  sync.Once
+}
+
+// heartbeatEnabled reports whether heartbeats start automatically for model.
+func (c *Client) heartbeatEnabled(model LicenseModel) bool {
+	if c.cfg.autoHeartbeat != nil {
+		return *c.cfg.autoHeartbeat
+	}
+	return model == LicenseModelFloating
+}
+
+// fingerprintChecksEnabled reports whether the fingerprint bound in a license
+// of the given model is enforced. Licenses issued without a model keep the
+// historical behavior of enforcing any bound fingerprint.
+func (c *Client) fingerprintChecksEnabled(model LicenseModel) bool {
+	if c.cfg.fingerprintChecks != nil {
+		return *c.cfg.fingerprintChecks
+	}
+	return model == "" || model == LicenseModelNodeLocked
+}
+
+// meteringEnabled reports whether usage recorded with RecordUsage is buffered
+// and flushed to the server for model.
+func (c *Client) meteringEnabled(model LicenseModel) bool {
+	if c.cfg.metering != nil {
+		return *c.cfg.metering
+	}
+	return model == LicenseModelMetered
+}
+
+// applyLicenseModel starts the subsystems the license's model calls for.
+// Failures are reported through the OnError hook; validation itself is not
+// affected. A heartbeat started here gives way to a later StartHeartbeat
+// with the caller's options.
+func (c *Client) applyLicenseModel(license *License) {
+	if license == nil || !license.Valid || c.cfg.offlineOnly || c.resolveServerURL() == "" {
+		return
+	}
+	if c.heartbeatEnabled(license.LicenseModel) {
+		c.model.heartbeatOnce.Do(func() {
+			if _, err := c.startHeartbeat(context.Background(), nil, true); err != nil && err != ErrAlreadyRunning {
+				c.hookError(err)
+			}
+		})
+	}
+	if c.meteringEnabled(license.LicenseModel) {
+		c.model.meteringOnce.Do(c.startMetering)
+	}
+	c.maybeStartSiteReporting(license)
+}
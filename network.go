@@ -0,0 +1,90 @@
+package licenseedict
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultNetworkPollInterval = 10 * time.Second
+
+// NetworkWatcher reports changes in network connectivity. The heartbeat loop
+// of a client created with WithNetworkWatcher uses it to retry a failed
+// heartbeat immediately when connectivity returns instead of waiting for the
+// next interval.
+//
+// Watch returns a channel that receives a value whenever the network
+// configuration may have changed. The channel is closed when ctx is done.
+type NetworkWatcher interface {
+	Watch(ctx context.Context) <-chan struct{}
+}
+
+// NewPollingNetworkWatcher returns a portable NetworkWatcher that polls the
+// local network interfaces every interval and signals when their state or
+// addresses change. A zero interval uses the default of 10s.
+func NewPollingNetworkWatcher(interval time.Duration) NetworkWatcher {
+	if interval <= 0 {
+		interval = defaultNetworkPollInterval
+	}
+	return &pollingNetworkWatcher{interval: interval}
+}
+
+type pollingNetworkWatcher struct {
+	interval time.Duration
+}
+
+func (w *pollingNetworkWatcher) Watch(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		last := interfaceSnapshot()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				current := interfaceSnapshot()
+				if current == last {
+					continue
+				}
+				last = current
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+// interfaceSnapshot returns a canonical description of the up, non-loopback
+// interfaces and their addresses.
+func interfaceSnapshot() string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+
+	var parts []string
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			parts = append(parts, iface.Name+"="+addr.String())
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,203 @@
+package licenseedict
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OfflinePolicy controls how the client behaves when it cannot verify a
+// token or reach the server. Without WithOfflinePolicy the client falls back
+// to any verified cached license, including one that has since expired, and
+// never invalidates a license for being offline.
+//
+// The zero OfflinePolicy keeps cache fallback but refuses expired cached
+// licenses and imposes no offline limit.
+type OfflinePolicy struct {
+	// DisableCacheFallback makes Validate return the verification error
+	// instead of falling back to the cached license.
+	DisableCacheFallback bool
+
+	// AllowCachedExpired lets the cache fallback and ValidateFromCache serve
+	// a cached license after its expiry. When false, such a license is
+	// returned with Valid set to false and a LicenseNotValidAfter error.
+	AllowCachedExpired bool
+
+	// MaxOfflineDuration, if positive, invalidates the license once the
+	// server has not been reached for this long. It is measured from the
+	// last response received from any server, or from the first attempt to
+	// reach one; a client that never contacts a server is not limited. The
+	// time is stored in the license cache, so restarting the application
+	// does not reset it.
+	MaxOfflineDuration time.Duration
+}
+
+// WithOfflinePolicy sets the rules applied when the token cannot be verified
+// or the server cannot be reached. See OfflinePolicy.
+func WithOfflinePolicy(p OfflinePolicy) Option {
+	return func(c *clientConfig) {
+		c.offlinePolicy = &p
+	}
+}
+
+// errCacheFallbackDisabled is the cache fallback error reported when the
+// offline policy disables the fallback.
+var errCacheFallbackDisabled = errors.New("licenseedict: cache fallback disabled by offline policy")
+
+// cacheFallback returns the cached license of token to serve when its
+// verification fails, applying the offline policy.
+func (c *Client) cacheFallback(token string) (*License, error) {
+	p := c.cfg.offlinePolicy
+	if p != nil && p.DisableCacheFallback {
+		return nil, errCacheFallbackDisabled
+	}
+	return c.loadVerifiedCache(token)
+}
+
+// tokenRejected reports whether a verification error means the token was
+// rejected for its signature or, in strict mode, its payload schema. Tokens
+// that merely fail to decode, as a truncated one does, may still be answered
+// from the cache.
+func tokenRejected(err error) bool {
+	var schemaErr *PayloadSchemaError
+	if errors.As(err, &schemaErr) {
+		return true
+	}
+	var vErr *ValidationError
+	return errors.As(err, &vErr) && vErr.Code == InvalidLicenseSignature
+}
+
+// checkCachedExpiry applies the offline policy's expiry rule to a license
+// served from the cache. The returned license is a copy when it is changed.
+func (c *Client) checkCachedExpiry(cached *License) (*License, error) {
+	p := c.cfg.offlinePolicy
+	if p == nil || p.AllowCachedExpired || !cached.IsExpired() {
+		return cached, nil
+	}
+	expired := *cached
+	expired.Valid = false
+	return &expired, &ValidationError{
+		Code:    LicenseNotValidAfter,
+		Message: fmt.Sprintf("cached license expired at %s", cached.ExpiresAt.Format(time.RFC3339)),
+	}
+}
+
+// checkCached applies the offline policy's expiry and offline rules and the
+// client's binding and policy checks to a license served from the cache,
+// which was verified but may have been cached under a different
+// configuration. The returned license is a copy with Valid set to false when
+// a check fails.
+func (c *Client) checkCached(cached *License) (*License, error) {
+	cached, err := c.checkCachedExpiry(cached)
+	if err != nil {
+		return cached, err
+	}
+	err = c.checkPolicy(cached)
+	if err == nil {
+		err = c.checkOffline()
+	}
+	if err != nil {
+		invalid := *cached
+		invalid.Valid = false
+		return &invalid, err
+	}
+	return cached, nil
+}
+
+// contactSaveInterval limits how often the last server contact is written
+// to the cache. The stored time may lag by up to this much, which only
+// makes the offline limit stricter after a restart.
+const contactSaveInterval = time.Minute
+
+// markServerContact records that a server responded.
+func (c *Client) markServerContact() {
+	now := time.Now()
+	c.lastContact.Store(now.UnixNano())
+	c.persistContact(now)
+}
+
+// markContactAttempt starts the offline period at the first attempt to reach
+// a server, if no server has responded yet, in this or an earlier run.
+func (c *Client) markContactAttempt() {
+	now := time.Now()
+	if c.lastContact.CompareAndSwap(0, now.UnixNano()) {
+		c.persistContact(now)
+	}
+}
+
+// persistContact stores t in the cache so that restarting the process does
+// not restart the offline period. Writes are limited to one per
+// contactSaveInterval.
+func (c *Client) persistContact(t time.Time) {
+	saved := c.contactSaved.Load()
+	if saved != 0 && t.Sub(time.Unix(0, saved)) < contactSaveInterval {
+		return
+	}
+	if !c.contactSaved.CompareAndSwap(saved, t.UnixNano()) {
+		return
+	}
+	if err := c.cache.saveLastContact(t); err != nil {
+		c.hookError(fmt.Errorf("licenseedict: save last server contact: %w", err))
+	}
+}
+
+// restoreLastContact resumes the offline period stored by an earlier run.
+// A stored time in the future is taken as now.
+func (c *Client) restoreLastContact() {
+	stored := c.cache.storedLastContact()
+	if stored.IsZero() {
+		return
+	}
+	if now := time.Now(); stored.After(now) {
+		stored = now
+	}
+	c.lastContact.Store(stored.UnixNano())
+	c.contactSaved.Store(stored.UnixNano())
+}
+
+// offlineFor returns how long the server has not been reached, or zero if
+// no attempt to reach it was made yet.
+func (c *Client) offlineFor() time.Duration {
+	last := c.lastContact.Load()
+	if last == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, last))
+}
+
+// checkOffline reports a ServerUnreachable error if the offline policy's
+// MaxOfflineDuration has been exceeded.
+func (c *Client) checkOffline() error {
+	p := c.cfg.offlinePolicy
+	if p == nil || p.MaxOfflineDuration <= 0 || c.cfg.offlineOnly {
+		return nil
+	}
+	if d := c.offlineFor(); d > p.MaxOfflineDuration {
+		return &ValidationError{
+			Code:    ServerUnreachable,
+			Message: fmt.Sprintf("server not reached for %s, exceeding the offline limit of %s", d.Round(time.Second), p.MaxOfflineDuration),
+		}
+	}
+	return nil
+}
+
+// enforceOfflineLimit invalidates the current license once the offline limit
+// is exceeded. It is called after failed heartbeats.
+func (c *Client) enforceOfflineLimit() {
+	err := c.checkOffline()
+	if err == nil {
+		return
+	}
+	c.mu.Lock()
+	current := c.license
+	if current == nil || !current.Valid {
+		c.mu.Unlock()
+		return
+	}
+	invalid := *current
+	invalid.Valid = false
+	c.license = &invalid
+	c.mu.Unlock()
+	c.updateGates(&invalid)
+	c.hookValidate(&invalid, err)
+}
@@ -0,0 +1,62 @@
+//go:build !licenseedict_nonetwork
+
+package licenseedict_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
+)
+
+func TestOfflineLimitSurvivesRestart(t *testing.T) {
+	r := newRaceIssuer(t)
+	token := r.sign(t, "lic-offline", "")
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	dir := t.TempDir()
+	const limit = 50 * time.Millisecond
+	newClient := func() *licenseedict.Client {
+		c, err := licenseedict.NewClient(
+			licenseedict.WithPublicKey(r.publicKey),
+			licenseedict.WithToken(token),
+			licenseedict.WithCacheDir(dir),
+			licenseedict.WithServerURL(down.URL),
+			licenseedict.WithOfflinePolicy(licenseedict.OfflinePolicy{MaxOfflineDuration: limit}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		drain(c)
+		return c
+	}
+
+	c := newClient()
+	if _, err := c.Validate(); err != nil {
+		t.Fatalf("Validate before contacting the server: %v", err)
+	}
+	if _, err := c.Renew(); err == nil {
+		t.Fatal("Renew succeeded against an unavailable server")
+	}
+	time.Sleep(2 * limit)
+	if _, err := c.Validate(); !errors.Is(err, licenseedict.ErrServerUnreachable) {
+		t.Fatalf("Validate over the offline limit: err = %v, want ErrServerUnreachable", err)
+	}
+	c.Close()
+
+	c = newClient()
+	defer c.Close()
+	license, err := c.Validate()
+	if !errors.Is(err, licenseedict.ErrServerUnreachable) {
+		t.Fatalf("Validate after restart: err = %v, want ErrServerUnreachable", err)
+	}
+	if license != nil && license.Valid {
+		t.Fatal("license is valid after restart past the offline limit")
+	}
+}
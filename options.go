@@ -3,7 +3,8 @@ package licenseedict
 import (
 	"crypto/ed25519"
 	"log/slog"
-	"net/http"
+	"net"
+	"strings"
 	"time"
 )
 
@@ -11,24 +12,87 @@ import (
 type Option func(*clientConfig)
 
 type clientConfig struct {
+	networkConfig
+
 	publicKey         ed25519.PublicKey
 	publicKeyStr      string // base64-encoded, for convenience API
 	token             string // stored token for Validate()
+	bundlePath        string
+	licenseFile       string
 	serverURL         string
+	serverURLs        []string
+	latencySelection  bool
+	probeInterval     time.Duration
 	appName           string
 	appPublisher      string
-	httpClient        *http.Client
+	appVersion        string
 	httpTimeout       time.Duration
+	compression       bool
+	transport         TransportOptions
+	serverAuth        string
+	simulation        *SimulationScenario
 	cacheDir          string
 	disableCache      bool
+	cacheTTL          time.Duration
+	serveStale        bool
 	offlineOnly       bool
 	userAgent         string
 	instanceID        string
 	heartbeatInterval time.Duration
 	renewBefore       time.Duration
+	renewWindowMin    time.Duration
+	renewWindowMax    time.Duration
+	learnRenewWindow  bool
+	renewSchedule     string
+	legacyRenewal     bool
+	renewScheduleLoc  *time.Location
 	disableAutoRenew  bool
 	onRenew           func(*License)
-	logger            *slog.Logger
+	onReactivate      ReactivationHandler
+	onSuspension      func(suspended bool, reason string)
+	tamperChecks      *TamperCheckOptions
+	binaryAttestation bool
+	denyVirtualized   bool
+
+	fingerprintTolerance int
+	siteReportInterval   time.Duration
+	offlinePolicy        *OfflinePolicy
+	tenantID             string
+	cacheLocation        CacheLocation
+	cacheStore           CacheStore
+	registryScope        RegistryScope
+	resolver             *LicenseResolver
+	autoHeartbeat        *bool
+	fingerprintChecks    *bool
+	metering             *bool
+	meterFlushInterval   time.Duration
+	shutdownTimeout      time.Duration
+	audit                *AuditOptions
+	hooks                Hooks
+	eventDelivery        EventDeliveryMode
+	eventBufferSize      int
+	onEventOverflow      func(Event, uint64)
+	logger               *slog.Logger
+	buildDate            time.Time
+	strictPayload        bool
+	expectedProduct      string
+	region               string
+	clientIP             string
+	heartbeatMetadata    func() map[string]string
+	telemetryConsent     TelemetryConsent
+	minimalHeartbeat     bool
+	quietHours           []string
+	quietHoursLoc        *time.Location
+	networkBudget        *NetworkBudget
+	inMemoryState        bool
+	sharedState          *sharedState
+	heartbeatJitter      float64
+
+	networkWatcher NetworkWatcher
+
+	apiPrefix  string
+	endpoints  Endpoints
+	apiVersion int
 }
 
 // WithPublicKey sets the Ed25519 public key for offline verification.
@@ -57,6 +121,28 @@ func WithToken(token string) Option {
 	}
 }
 
+// WithLicenseFile reads the license token from a file at path, either in the
+// armored format written by WriteArmoredLicense or as a bare token. The token
+// is used by Validate unless WithToken is also given, and takes precedence
+// over a WithLicenseBundle token. NewClient returns an error if the file
+// cannot be read or decoded.
+func WithLicenseFile(path string) Option {
+	return func(c *clientConfig) {
+		c.licenseFile = path
+	}
+}
+
+// WithLicenseBundle loads a signed offline license bundle from path. The
+// bundle must be signed with the key set via WithPublicKey; its token is used
+// by Validate unless WithToken is also given, its revocation list is checked
+// on every validation, and its key set is trusted for token signatures.
+// NewClient returns an error if the bundle cannot be loaded.
+func WithLicenseBundle(path string) Option {
+	return func(c *clientConfig) {
+		c.bundlePath = path
+	}
+}
+
 // WithServerURL overrides the server URL extracted from the token.
 func WithServerURL(url string) Option {
 	return func(c *clientConfig) {
@@ -64,7 +150,31 @@ func WithServerURL(url string) Option {
 	}
 }
 
+// WithServerURLs sets an ordered list of server URLs for HA or geo-redundant
+// deployments. Requests go to the first healthy server and fail over to the
+// next on network errors or 5xx responses. It takes precedence over
+// WithServerURL and the server URLs in the token.
+func WithServerURLs(urls ...string) Option {
+	return func(c *clientConfig) {
+		c.serverURLs = urls
+	}
+}
+
+// WithLatencySelection probes all configured servers every interval (default
+// 5m) and prefers the lowest-latency healthy one. An EventEndpointSwitched
+// event is emitted whenever the preferred server changes. It only has an
+// effect when more than one server URL is available.
+func WithLatencySelection(interval time.Duration) Option {
+	return func(c *clientConfig) {
+		c.latencySelection = true
+		c.probeInterval = interval
+	}
+}
+
 // WithAppInfo sets the application name and publisher for cache directory naming.
+// The name is also checked against the token's allowed_apps claim, if present,
+// and, unless WithUserAgent is set, included in the User-Agent sent to the
+// server together with the version from WithAppVersion.
 func WithAppInfo(name, publisher string) Option {
 	return func(c *clientConfig) {
 		c.appName = name
@@ -72,10 +182,12 @@ func WithAppInfo(name, publisher string) Option {
 	}
 }
 
-// WithHTTPClient sets a custom HTTP client for server communication.
-func WithHTTPClient(client *http.Client) Option {
+// WithAppVersion sets the application version reported to the server in the
+// X-LicenseEdict-App-Version header, the default User-Agent, and heartbeat
+// metadata.
+func WithAppVersion(version string) Option {
 	return func(c *clientConfig) {
-		c.httpClient = client
+		c.appVersion = version
 	}
 }
 
@@ -86,6 +198,55 @@ func WithHTTPTimeout(d time.Duration) Option {
 	}
 }
 
+// WithTransportOptions tunes connection pooling, keep-alives, and dial/TLS
+// timeouts of the default HTTP transport. It has no effect when a custom
+// client is supplied via WithHTTPClient.
+func WithTransportOptions(opts TransportOptions) Option {
+	return func(c *clientConfig) {
+		if opts.Resolver == nil {
+			opts.Resolver = c.transport.Resolver
+		}
+		c.transport = opts
+	}
+}
+
+// WithResolver sets the DNS resolver used by the default HTTP transport.
+// Lookups are cached regardless of the resolver; see TransportOptions.
+func WithResolver(r *net.Resolver) Option {
+	return func(c *clientConfig) {
+		c.transport.Resolver = r
+	}
+}
+
+// WithServerAuth sends an Authorization header with every request, for
+// servers or reverse proxies that require credentials beyond the signed
+// token. A bare credential is sent as a bearer token; a value that already
+// includes a scheme (such as "Basic dXNlcjpwYXNz") is sent verbatim.
+func WithServerAuth(credential string) Option {
+	return func(c *clientConfig) {
+		c.serverAuth = credential
+	}
+}
+
+// WithSimulatedServer replaces all server communication with an in-process
+// simulation driven by scenario, so applications can exercise seat limits,
+// renewals, revocation, and latency without a network or a real server.
+// It is intended for development and integration testing only.
+func WithSimulatedServer(scenario SimulationScenario) Option {
+	return func(c *clientConfig) {
+		c.simulation = &scenario
+	}
+}
+
+// WithCompression enables gzip compression of request bodies of 1 KiB or more
+// (such as batched heartbeats and usage reports) and requests gzip-encoded
+// responses from the server.
+func WithCompression() Option {
+	return func(c *clientConfig) {
+		c.compression = true
+	}
+}
+
 // WithCacheDir overrides the cache directory path.
 func WithCacheDir(dir string) Option {
 	return func(c *clientConfig) {
@@ -93,6 +254,24 @@ func WithCacheDir(dir string) Option {
 	}
 }
 
+// WithCacheTTL limits how long a cached license may be used as a fallback or
+// served by stale-while-revalidate. Older cache entries are ignored.
+// The default is no limit.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(c *clientConfig) {
+		c.cacheTTL = ttl
+	}
+}
+
+// WithStaleWhileRevalidate makes Validate return a verified cached license
+// for the same token immediately and revalidate in the background, emitting
+// EventLicenseChanged if the fresh result differs.
+func WithStaleWhileRevalidate() Option {
+	return func(c *clientConfig) {
+		c.serveStale = true
+	}
+}
+
 // WithoutCache disables license caching entirely.
 func WithoutCache() Option {
 	return func(c *clientConfig) {
@@ -100,6 +279,20 @@ func WithoutCache() Option {
 	}
 }
 
+// WithInMemoryState keeps all client state in memory and never writes to the
+// file system, for read-only file systems, serverless functions and scratch
+// containers. The license cache, telemetry consent and unsent usage live in
+// an in-memory store that is lost when the process exits, overriding
+// WithCacheDir, WithCacheStore and WithRegistryStore. An instance ID is
+// generated for the life of the client unless WithInstanceID is set.
+// NewClient returns an error if WithAuditLog is also set, since the audit log
+// is a file.
+func WithInMemoryState() Option {
+	return func(c *clientConfig) {
+		c.inMemoryState = true
+	}
+}
+
 // WithOfflineOnly disables all server communication.
 func WithOfflineOnly() Option {
 	return func(c *clientConfig) {
@@ -107,13 +300,46 @@ func WithOfflineOnly() Option {
 	}
 }
 
-// WithUserAgent sets a custom User-Agent string for HTTP requests.
+// WithUserAgent sets a custom User-Agent string for HTTP requests. It
+// replaces the default derived from WithAppInfo and WithAppVersion.
 func WithUserAgent(ua string) Option {
 	return func(c *clientConfig) {
 		c.userAgent = ua
 	}
 }
 
+// effectiveUserAgent returns the User-Agent for HTTP requests and the default
+// heartbeat user agent: the one set with WithUserAgent, or one built from the
+// app info, such as "MyApp/2.3.1 MyCompany LicenseEdictSDK-Go/1.0".
+func (c *clientConfig) effectiveUserAgent() string {
+	if c.userAgent != "" {
+		return c.userAgent
+	}
+	if c.appName == "" {
+		return defaultUserAgent
+	}
+	ua := uaToken(c.appName)
+	if c.appVersion != "" {
+		ua += "/" + uaToken(c.appVersion)
+	}
+	if c.appPublisher != "" {
+		ua += " " + uaToken(c.appPublisher)
+	}
+	return ua + " " + defaultUserAgent
+}
+
+// uaToken makes s safe to use as a User-Agent product token by replacing
+// whitespace and separators.
+func uaToken(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r <= ' ', r == '/', r == '(', r == ')', r == 0x7f:
+			return '-'
+		}
+		return r
+	}, s)
+}
+
 // WithInstanceID sets a custom instance ID for seat tracking.
 // If not set, an ID is auto-generated.
 func WithInstanceID(id string) Option {
@@ -129,6 +355,16 @@ func WithHeartbeatInterval(d time.Duration) Option {
 	}
 }
 
+// WithHeartbeatJitter randomizes each heartbeat interval by ±fraction (for
+// example 0.1 for ±10%) so fleets started from the same image spread their
+// heartbeats instead of hitting the server in the same second.
+// The fraction is clamped to [0, 1].
+func WithHeartbeatJitter(fraction float64) Option {
+	return func(c *clientConfig) {
+		c.heartbeatJitter = fraction
+	}
+}
+
 // WithRenewBefore sets the auto-renewal threshold (default: 7 days before expiry).
 func WithRenewBefore(d time.Duration) Option {
 	return func(c *clientConfig) {
@@ -136,6 +372,41 @@ func WithRenewBefore(d time.Duration) Option {
 	}
 }
 
+// WithRenewalWindow bounds when auto-renewal fires, measured as time left
+// before expiry. The renewal threshold from WithRenewBefore is raised to at
+// least min, so renewal is not left too late, and lowered to at most max, so
+// the client does not ask before the server allows it. A zero bound is not
+// applied. See WithLearnedRenewalWindow to take max from the server.
+func WithRenewalWindow(min, max time.Duration) Option {
+	return func(c *clientConfig) {
+		c.renewWindowMin = min
+		c.renewWindowMax = max
+	}
+}
+
+// WithLearnedRenewalWindow sets whether the renewal window the server
+// reports in renewal and renewal preview responses caps the auto-renewal
+// threshold, as the max of WithRenewalWindow does. It is disabled by
+// default.
+func WithLearnedRenewalWindow(enabled bool) Option {
+	return func(c *clientConfig) {
+		c.learnRenewWindow = enabled
+	}
+}
+
+// WithRenewalSchedule restricts auto-renewals to a daily time-of-day window
+// such as "02:00-04:00" in loc (time.Local if nil). Once a license is due for
+// renewal, the renewal runs at a random point within the next window so a
+// fleet does not renew at once; if the license would expire before the
+// window opens, it is renewed immediately. A window may wrap past midnight.
+// NewClient returns an error if window is malformed.
+func WithRenewalSchedule(window string, loc *time.Location) Option {
+	return func(c *clientConfig) {
+		c.renewSchedule = window
+		c.renewScheduleLoc = loc
+	}
+}
+
 // WithoutAutoRenew disables automatic license renewal.
 func WithoutAutoRenew() Option {
 	return func(c *clientConfig) {
@@ -143,6 +414,16 @@ func WithoutAutoRenew() Option {
 	}
 }
 
+// WithLegacyRenewal restores the original Renew behavior when the renewed
+// token fails validation: Renew returns a License holding only the new token
+// and a nil error, and RenewResult returns no error. The unverified token is
+// stored for later use. Prefer handling *RenewalValidationError instead.
+func WithLegacyRenewal() Option {
+	return func(c *clientConfig) {
+		c.legacyRenewal = true
+	}
+}
+
 // WithOnRenew registers a callback invoked after successful auto-renewal.
 func WithOnRenew(fn func(*License)) Option {
 	return func(c *clientConfig) {
@@ -150,9 +431,265 @@ func WithOnRenew(fn func(*License)) Option {
 	}
 }
 
+// WithReactivationHandler registers fn to supply a replacement token when the
+// server rejects the current one with 401 or 403 during a heartbeat or
+// renewal. The new token is validated and swapped in without a restart,
+// including for a running heartbeat. fn runs on the goroutine that observed
+// the rejection and should not block for long.
+func WithReactivationHandler(fn ReactivationHandler) Option {
+	return func(c *clientConfig) {
+		c.onReactivate = fn
+	}
+}
+
+// WithSuspensionHandler registers fn to be called when the server reports
+// the license as suspended (suspended is true) and again when the suspension
+// is lifted. Applications typically switch to a read-only mode while
+// suspended. reason is the server's explanation, if any.
+func WithSuspensionHandler(fn func(suspended bool, reason string)) Option {
+	return func(c *clientConfig) {
+		c.onSuspension = fn
+	}
+}
+
+// WithTamperChecks enables runtime integrity checks: the public key checksum,
+// debugger detection, and library injection detection. The checks run once
+// in NewClient and whenever RunTamperChecks is called; findings are reported
+// as EventTamperDetected events and never stop the client.
+func WithTamperChecks(opts TamperCheckOptions) Option {
+	return func(c *clientConfig) {
+		c.tamperChecks = &opts
+	}
+}
+
+// WithBinaryAttestation includes the SHA-256 of the running executable and
+// the app version from WithAppVersion in heartbeat metadata, so the server
+// can flag tampered or unsupported builds. A rejection is reported as an
+// EventAttestationRejected event and a ValidationError with Code
+// AttestationRejected, which matches ErrAttestationRejected.
+func WithBinaryAttestation() Option {
+	return func(c *clientConfig) {
+		c.binaryAttestation = true
+	}
+}
+
+// WithDenyVirtualizedActivation refuses node-locked activation, such as
+// RenewWithKey, when DetectEnvironment reports a VM or container. Cloned VM
+// images would otherwise each activate as a new host. The refusal is a
+// ValidationError with Code VirtualizedEnvironment, which matches
+// ErrVirtualizedEnvironment. Validation of an existing token is unaffected.
+func WithDenyVirtualizedActivation() Option {
+	return func(c *clientConfig) {
+		c.denyVirtualized = true
+	}
+}
+
+// WithFingerprintTolerance sets how many of a node-locked license's bound
+// fingerprint components must match the host, so replacing a NIC or disk
+// does not invalidate the license. By default all components must match.
+// Values larger than the number of bound components require all of them.
+func WithFingerprintTolerance(n int) Option {
+	return func(c *clientConfig) {
+		c.fingerprintTolerance = n
+	}
+}
+
+// WithSiteReportInterval sets how often a client with a site license reports
+// its host to the server (default 24 hours). A negative interval disables
+// automatic reports; ReportHosts can still be called directly.
+func WithSiteReportInterval(d time.Duration) Option {
+	return func(c *clientConfig) {
+		c.siteReportInterval = d
+	}
+}
+
+// WithAutoHeartbeat overrides whether heartbeats start automatically after a
+// successful validation. By default they do only for floating licenses.
+func WithAutoHeartbeat(enabled bool) Option {
+	return func(c *clientConfig) {
+		c.autoHeartbeat = &enabled
+	}
+}
+
+// WithFingerprintChecks overrides whether a fingerprint bound in the license
+// is enforced. By default it is for node-locked licenses and for licenses
+// issued without a model.
+func WithFingerprintChecks(enabled bool) Option {
+	return func(c *clientConfig) {
+		c.fingerprintChecks = &enabled
+	}
+}
+
+// WithMetering overrides whether RecordUsage buffers usage for the server.
+// By default metering is enabled only for metered licenses.
+func WithMetering(enabled bool) Option {
+	return func(c *clientConfig) {
+		c.metering = &enabled
+	}
+}
+
+// WithMeterFlushInterval sets how often buffered usage is sent to the server
+// (default 1 minute).
+func WithMeterFlushInterval(d time.Duration) Option {
+	return func(c *clientConfig) {
+		c.meterFlushInterval = d
+	}
+}
+
+// WithHooks registers lifecycle callbacks. See Hooks for when each is called.
+//
+// Hooks called by the heartbeat loop may call StopHeartbeat, Close or
+// Shutdown. These then stop the loop without waiting for it to exit, since
+// it cannot exit until the hook returns.
+func WithHooks(h Hooks) Option {
+	return func(c *clientConfig) {
+		c.hooks = h
+	}
+}
+
+// WithEventDeliveryMode sets how events are delivered when the Events
+// channel is full. The default, EventDeliveryDrop, never blocks.
+func WithEventDeliveryMode(mode EventDeliveryMode) Option {
+	return func(c *clientConfig) {
+		c.eventDelivery = mode
+	}
+}
+
+// WithEventBufferSize sets the capacity of the Events channel (default 16).
+func WithEventBufferSize(n int) Option {
+	return func(c *clientConfig) {
+		if n >= 0 {
+			c.eventBufferSize = n
+		}
+	}
+}
+
+// WithEventOverflow registers a callback invoked whenever an event is dropped
+// because the Events channel is full. It receives the dropped event and the
+// total number of events of that type dropped so far.
+func WithEventOverflow(fn func(dropped Event, total uint64)) Option {
+	return func(c *clientConfig) {
+		c.onEventOverflow = fn
+	}
+}
+
 // WithLogger sets a custom structured logger.
 func WithLogger(l *slog.Logger) Option {
 	return func(c *clientConfig) {
 		c.logger = l
 	}
 }
+
+// WithBuildDate sets the release date of the embedding application. When set,
+// Validate rejects licenses whose maintenance window ended before this date,
+// allowing perpetual licenses to gate updates rather than runtime.
+func WithBuildDate(t time.Time) Option {
+	return func(c *clientConfig) {
+		c.buildDate = t
+	}
+}
+
+// WithStrictPayload makes token decoding reject payloads that are missing
+// required fields (license_id, product_id, issued_at) or contain malformed
+// timestamps. By default such fields silently decode to zero values. A token
+// rejected this way, or for its signature, is reported by Validate instead
+// of being answered from the cache.
+func WithStrictPayload() Option {
+	return func(c *clientConfig) {
+		c.strictPayload = true
+	}
+}
+
+// WithExpectedProduct binds the client to a product ID. Validate fails with
+// code PRODUCT_MISMATCH when the token was issued for a different product.
+func WithExpectedProduct(productID string) Option {
+	return func(c *clientConfig) {
+		c.expectedProduct = productID
+	}
+}
+
+// WithTenant sets the active tenant in multi-tenant deployments. Validate
+// fails with code TENANT_MISMATCH, matching ErrTenantMismatch, for a license
+// issued to another tenant; licenses without a tenant are accepted. See Client.SelectLicense.
+func WithTenant(tenantID string) Option {
+	return func(c *clientConfig) {
+		c.tenantID = tenantID
+	}
+}
+
+// WithRegion declares the ISO 3166 region the application is running in.
+// It is checked against the token's allowed_regions claim and reported to
+// the server with each heartbeat.
+func WithRegion(region string) Option {
+	return func(c *clientConfig) {
+		c.region = region
+	}
+}
+
+// WithClientIP sets the address checked against the token's allowed_ip_ranges
+// claim. If not set, the address the server reports seeing in heartbeat
+// responses is used; until one is reported, only the server enforces the
+// claim.
+func WithClientIP(ip string) Option {
+	return func(c *clientConfig) {
+		c.clientIP = ip
+	}
+}
+
+// WithHeartbeatMetadata registers a function whose result is attached to every
+// heartbeat as custom metadata (for example load or the active module). It is
+// called once per heartbeat from the heartbeat goroutine. Keys that collide
+// with built-in fields (hostname, ip, user_agent, user_hash, region, app_name,
+// app_version, the environment and attestation fields) are ignored, whether
+// or not the built-in field is sent.
+func WithHeartbeatMetadata(fn func() map[string]string) Option {
+	return func(c *clientConfig) {
+		c.heartbeatMetadata = fn
+	}
+}
+
+// WithMinimalHeartbeat sends heartbeats with only the signed token and the
+// instance ID, plus the features and reservation ID when HeartbeatOptions
+// sets them. No metadata is sent at all, whatever the telemetry consent, and
+// checkouts omit the user hash. Binary attestation data is left out too, so
+// servers that require WithBinaryAttestation will reject the heartbeats.
+func WithMinimalHeartbeat() Option {
+	return func(c *clientConfig) {
+		c.minimalHeartbeat = true
+	}
+}
+
+// WithNetworkWatcher makes the heartbeat loop watch w and retry a failed
+// heartbeat as soon as the network changes, instead of waiting out the
+// heartbeat interval. NewPollingNetworkWatcher is a portable choice. By
+// default, and under WithOfflineOnly, the network is not watched.
+func WithNetworkWatcher(w NetworkWatcher) Option {
+	return func(c *clientConfig) {
+		c.networkWatcher = w
+	}
+}
+
+// WithAPIPrefix replaces the default /api/v{N} path prefix, for servers that
+// sit behind a reverse proxy or path rewrite.
+func WithAPIPrefix(prefix string) Option {
+	return func(c *clientConfig) {
+		c.apiPrefix = prefix
+	}
+}
+
+// WithEndpoints overrides individual endpoint paths. Non-empty fields are
+// appended to the server URL verbatim, bypassing the API prefix.
+func WithEndpoints(e Endpoints) Option {
+	return func(c *clientConfig) {
+		c.endpoints = e
+	}
+}
+
+// WithAPIVersion sets the preferred API version (default 1). The version is
+// advertised to the server on every request and used once the server reports
+// that it supports it.
+func WithAPIVersion(v int) Option {
+	return func(c *clientConfig) {
+		c.apiVersion = v
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !licenseedict_nonetwork
+
+package licenseedict
+
+import "net/http"
+
+// networkConfig holds the options that take net/http types. They are only
+// available in builds with network support.
+type networkConfig struct {
+	httpClient     *http.Client
+	headerInjector func(*http.Request)
+	middleware     []Middleware
+}
+
+// WithHTTPClient sets a custom HTTP client for server communication.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *clientConfig) {
+		c.httpClient = client
+	}
+}
+
+// WithRequestHeaderInjector registers a function called on every outgoing
+// request after the SDK's own headers are set, for example to add an API key
+// header required by an authenticating proxy.
+func WithRequestHeaderInjector(fn func(*http.Request)) Option {
+	return func(c *clientConfig) {
+		c.headerInjector = fn
+	}
+}
+
+// WithHTTPMiddleware adds middleware around the transport used for all SDK
+// requests, for custom auth, logging, fault injection, or compliance
+// interception. Middleware added first runs outermost. It also applies to a
+// client supplied via WithHTTPClient, which is copied rather than modified.
+func WithHTTPMiddleware(mw ...Middleware) Option {
+	return func(c *clientConfig) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
@@ -0,0 +1,108 @@
+package licenseedict
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// checkPolicy applies the client's binding and policy rules to a license whose
+// signature has already been verified. It returns the first violation found.
+func (c *Client) checkPolicy(license *License) error {
+	if c.bundle.IsRevoked(license.LicenseID) {
+		return &ValidationError{
+			Code:    LicenseRevoked,
+			Message: "license is listed in the bundle's revocation list",
+		}
+	}
+	if revoked, err := c.cfg.sharedState.isRevoked(license.LicenseID); err != nil {
+		c.hookError(fmt.Errorf("licenseedict: check shared revocations: %w", err))
+	} else if revoked {
+		return &ValidationError{
+			Code:    LicenseRevoked,
+			Message: "license was reported revoked to another instance",
+		}
+	}
+
+	if c.cfg.expectedProduct != "" && license.ProductID != c.cfg.expectedProduct {
+		return &ValidationError{
+			Code:    ProductMismatch,
+			Message: fmt.Sprintf("license is for product %q, expected %q", license.ProductID, c.cfg.expectedProduct),
+		}
+	}
+
+	if c.cfg.tenantID != "" && license.TenantID != "" && license.TenantID != c.cfg.tenantID {
+		return &ValidationError{
+			Code:    TenantMismatch,
+			Message: fmt.Sprintf("license is for tenant %q, active tenant is %q", license.TenantID, c.cfg.tenantID),
+		}
+	}
+
+	if !license.AllowsApp(c.cfg.appName) {
+		return &ValidationError{
+			Code:    AppMismatch,
+			Message: fmt.Sprintf("license does not allow application %q", c.cfg.appName),
+		}
+	}
+
+	if err := c.checkRegion(license); err != nil {
+		return err
+	}
+
+	if err := c.checkFingerprint(license); err != nil {
+		return err
+	}
+
+	// Perpetual licenses gate updates: the running build must fall within
+	// the maintenance window.
+	if !license.CoversRelease(c.cfg.buildDate) {
+		return &ValidationError{
+			Code:    MaintenanceExpired,
+			Message: fmt.Sprintf("build date %s is after maintenance end %s", c.cfg.buildDate.Format(time.DateOnly), license.MaintenanceUntil.Format(time.DateOnly)),
+		}
+	}
+
+	return nil
+}
+
+// checkRegion enforces the license's region and IP-range restrictions on the
+// client side. Regions are only checked when the host region is configured via
+// WithRegion; the server confirms the restriction on every heartbeat.
+func (c *Client) checkRegion(license *License) error {
+	if c.cfg.region != "" && !license.AllowsRegion(c.cfg.region) {
+		return &ValidationError{
+			Code:    RegionRestricted,
+			Message: fmt.Sprintf("license is not valid in region %q", c.cfg.region),
+		}
+	}
+
+	if len(license.AllowedIPRanges) == 0 {
+		return nil
+	}
+	raw := c.clientIP()
+	if raw == "" {
+		// Until the server reports the address it sees, the restriction is
+		// left to the server.
+		return nil
+	}
+	if ip := net.ParseIP(raw); ip != nil && license.AllowsIP(ip) {
+		return nil
+	}
+	return &ValidationError{
+		Code:    RegionRestricted,
+		Message: fmt.Sprintf("client address %q is not within the license's allowed IP ranges", raw),
+	}
+}
+
+// clientIP returns the configured client IP, or the address the server last
+// reported seeing in a heartbeat response. Local interface addresses are
+// not used: behind NAT they are not the address the server sees, and they
+// are easily changed.
+func (c *Client) clientIP() string {
+	if c.cfg.clientIP != "" {
+		return c.cfg.clientIP
+	}
+	c.hb.statusMu.Lock()
+	defer c.hb.statusMu.Unlock()
+	return c.hb.clientIP
+}
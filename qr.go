@@ -0,0 +1,118 @@
+package licenseedict
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// qrScheme is the URI scheme used for license QR payloads.
+const qrScheme = "licenseedict"
+
+// ActivationPayload is the activation input carried by a license QR code:
+// a license key or signed token, plus where to activate it.
+type ActivationPayload struct {
+	LicenseKey string
+	ServerURL  string
+	ProductID  string
+	// SignedToken is a full signed token for offline activation. It is
+	// carried in URL-safe base64 to keep the QR code small.
+	SignedToken string
+}
+
+// EncodeActivationQR returns the text to encode in a QR code for p, in the
+// form licenseedict://activate?key=...&server=...&product=...&token=...
+// Render it with any QR library; the SDK does not produce images. At least
+// one of LicenseKey and SignedToken must be set.
+func EncodeActivationQR(p ActivationPayload) (string, error) {
+	if p.LicenseKey == "" && p.SignedToken == "" {
+		return "", fmt.Errorf("licenseedict: activation payload needs a license key or token")
+	}
+
+	q := url.Values{}
+	if p.LicenseKey != "" {
+		q.Set("key", p.LicenseKey)
+	}
+	if p.ServerURL != "" {
+		q.Set("server", p.ServerURL)
+	}
+	if p.ProductID != "" {
+		q.Set("product", p.ProductID)
+	}
+	if p.SignedToken != "" {
+		raw, err := base64.StdEncoding.DecodeString(p.SignedToken)
+		if err != nil {
+			return "", &ValidationError{Code: LicenseDecodeError, Message: "signed token is not valid base64", Err: err}
+		}
+		q.Set("token", base64.RawURLEncoding.EncodeToString(raw))
+	}
+	return (&url.URL{Scheme: qrScheme, Host: "activate", RawQuery: q.Encode()}).String(), nil
+}
+
+// ParseActivationQR parses text scanned from a license QR code. It accepts
+// payloads produced by EncodeActivationQR, http(s) activation URLs carrying a
+// "key" or "token" query parameter (the URL itself is used as ServerURL when
+// no "server" parameter is present), and bare license keys. It returns
+// ErrInvalidQRCode if text is none of these.
+func ParseActivationQR(text string) (*ActivationPayload, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrInvalidQRCode
+	}
+
+	u, err := url.Parse(text)
+	if err != nil || u.Scheme == "" {
+		if strings.ContainsAny(text, " \t\r\n/?&=") {
+			return nil, ErrInvalidQRCode
+		}
+		return &ActivationPayload{LicenseKey: text}, nil
+	}
+
+	switch {
+	case strings.EqualFold(u.Scheme, qrScheme):
+		if !strings.EqualFold(u.Host, "activate") {
+			return nil, ErrInvalidQRCode
+		}
+	case strings.EqualFold(u.Scheme, "https"), strings.EqualFold(u.Scheme, "http"):
+	default:
+		return nil, ErrInvalidQRCode
+	}
+
+	q := u.Query()
+	p := &ActivationPayload{
+		LicenseKey: q.Get("key"),
+		ServerURL:  q.Get("server"),
+		ProductID:  q.Get("product"),
+	}
+	if token := q.Get("token"); token != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(token, "="))
+		if err != nil {
+			return nil, &ValidationError{Code: LicenseDecodeError, Message: "QR token is not valid base64", Err: err}
+		}
+		p.SignedToken = base64.StdEncoding.EncodeToString(raw)
+	}
+	if p.LicenseKey == "" && p.SignedToken == "" {
+		return nil, ErrInvalidQRCode
+	}
+	if p.ServerURL == "" && !strings.EqualFold(u.Scheme, qrScheme) {
+		p.ServerURL = (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+	}
+	return p, nil
+}
+
+// Options returns client options for the payload's server URL and token.
+// LicenseKey has no corresponding option and is left to the caller.
+func (p *ActivationPayload) Options() []Option {
+	var opts []Option
+	if p.ServerURL != "" {
+		opts = append(opts, WithServerURL(p.ServerURL))
+	}
+	if p.SignedToken != "" {
+		opts = append(opts, WithToken(p.SignedToken))
+	}
+	if p.ProductID != "" {
+		opts = append(opts, WithExpectedProduct(p.ProductID))
+	}
+	return opts
+}
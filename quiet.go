@@ -0,0 +1,57 @@
+package licenseedict
+
+import "time"
+
+// WithQuietHours suppresses non-critical background network calls during
+// daily windows such as "22:00-06:00" in loc (time.Local if nil), for
+// environments with change freezes or maintenance windows. A window may wrap
+// past midnight.
+//
+// During quiet hours:
+//   - periodic usage reports are deferred; usage still buffered when the
+//     client closes is stored in the cache and reported by a later run,
+//   - site license host reports and server latency probes are skipped,
+//   - auto-renewal is postponed until the quiet hours end, unless the
+//     license would expire first. A renewal that is still due is triggered
+//     again by the next validation after a restart.
+//
+// Heartbeats, checkouts and calls the application makes explicitly, such
+// as Renew or FlushUsage, are not affected. Each deferral emits an
+// EventNetworkDeferred event. NewClient returns an error if a window is
+// malformed.
+func WithQuietHours(windows []string, loc *time.Location) Option {
+	return func(c *clientConfig) {
+		c.quietHours = windows
+		c.quietHoursLoc = loc
+	}
+}
+
+// quietHours is the set of windows configured with WithQuietHours.
+type quietHours []*dailyWindow
+
+// active reports whether now falls inside quiet hours and, if so, when they
+// end. Adjoining or overlapping windows are treated as one; windows that
+// cover the whole day are reported as ending a day later.
+func (q quietHours) active(now time.Time) (bool, time.Time) {
+	until := now
+	for inside := true; inside && until.Sub(now) < 24*time.Hour; {
+		inside = false
+		for _, w := range q {
+			if in, end := w.contains(until); in && end.After(until) {
+				until = end
+				inside = true
+			}
+		}
+	}
+	return until.After(now), until
+}
+
+// deferForQuietHours reports whether a background call described by what
+// must wait for the quiet hours to end, emitting EventNetworkDeferred if so.
+func (c *Client) deferForQuietHours(what string) bool {
+	quiet, until := c.quietHours.active(time.Now())
+	if quiet {
+		c.emitEvent(Event{Type: EventNetworkDeferred, Message: what + " deferred until " + until.Format("15:04"), Data: until})
+	}
+	return quiet
+}
@@ -0,0 +1,257 @@
+package licenseedict_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK/issuer"
+)
+
+// These tests interleave the client's public API from many goroutines. They
+// pass without the race detector too, but are meant to be run with
+//
+//	go test -race ./...
+
+// raceIssuer signs test licenses and backs the simulated server's renewals.
+type raceIssuer struct {
+	key       ed25519.PrivateKey
+	publicKey string
+	issuer    *issuer.Issuer
+}
+
+func newRaceIssuer(t *testing.T) *raceIssuer {
+	t.Helper()
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &raceIssuer{key: key, publicKey: base64.StdEncoding.EncodeToString(pub), issuer: issuer.NewFromKey(key)}
+}
+
+func (r *raceIssuer) sign(t *testing.T, licenseID string, model licenseedict.LicenseModel) string {
+	t.Helper()
+	token, err := r.issuer.Sign(issuer.Claims{
+		LicenseID:    licenseID,
+		ProductID:    "race-product",
+		LicenseKey:   "KEY-" + licenseID,
+		Plan:         "pro",
+		Features:     []string{"PRO"},
+		MaxSeats:     5,
+		IssuedAt:     time.Now().Add(-time.Minute),
+		ExpiresAt:    time.Now().Add(24 * time.Hour),
+		LicenseModel: model,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func (r *raceIssuer) client(t *testing.T, token string, opts ...licenseedict.Option) *licenseedict.Client {
+	t.Helper()
+	opts = append([]licenseedict.Option{
+		licenseedict.WithPublicKey(r.publicKey),
+		licenseedict.WithToken(token),
+		licenseedict.WithInMemoryState(),
+		licenseedict.WithSimulatedServer(licenseedict.SimulationScenario{SigningKey: r.key}),
+		licenseedict.WithHeartbeatInterval(time.Millisecond),
+	}, opts...)
+	c, err := licenseedict.NewClient(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// drain consumes c.Events until it is closed.
+func drain(c *licenseedict.Client) {
+	go func() {
+		for range c.Events {
+		}
+	}()
+}
+
+// hammer runs fn from n goroutines, each calling it iterations times, and
+// waits for them to finish.
+func hammer(n, iterations int, fn func(g, i int)) {
+	var wg sync.WaitGroup
+	for g := 0; g < n; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				fn(g, i)
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestRaceValidateAndSetToken(t *testing.T) {
+	r := newRaceIssuer(t)
+	tokens := []string{r.sign(t, "lic-a", ""), r.sign(t, "lic-b", "")}
+	c := r.client(t, tokens[0])
+	defer c.Close()
+	drain(c)
+
+	hammer(8, 50, func(g, i int) {
+		switch g % 4 {
+		case 0:
+			if _, err := c.Validate(); err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+		case 1:
+			if _, err := c.SetToken(tokens[i%2]); err != nil {
+				t.Errorf("SetToken: %v", err)
+			}
+		case 2:
+			if license := c.License(); license != nil && !license.Valid {
+				t.Errorf("License() returned an invalid license")
+			}
+			c.FeatureGate("PRO").Enabled()
+		case 3:
+			c.HealthCheck(context.Background())
+			c.DebugSnapshot()
+		}
+	})
+
+	license := c.License()
+	if license == nil || (license.LicenseID != "lic-a" && license.LicenseID != "lic-b") {
+		t.Fatalf("License() = %+v after concurrent SetToken", license)
+	}
+}
+
+func TestRaceHeartbeatStartStop(t *testing.T) {
+	r := newRaceIssuer(t)
+	c := r.client(t, r.sign(t, "lic-hb", ""))
+	defer c.Close()
+	drain(c)
+
+	hammer(6, 30, func(g, i int) {
+		switch g % 3 {
+		case 0:
+			_, err := c.StartHeartbeat(licenseedict.HeartbeatOptions{InstanceID: "race"})
+			if err != nil && !errors.Is(err, licenseedict.ErrAlreadyRunning) {
+				t.Errorf("StartHeartbeat: %v", err)
+			}
+		case 1:
+			c.StopHeartbeat()
+		case 2:
+			if _, err := c.Validate(); err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+			c.LastHeartbeat()
+		}
+	})
+}
+
+func TestRaceFloatingAutoHeartbeat(t *testing.T) {
+	r := newRaceIssuer(t)
+	c := r.client(t, r.sign(t, "lic-float", licenseedict.LicenseModelFloating))
+	defer c.Close()
+	drain(c)
+
+	// Validate starts heartbeats for a floating license; an explicit start
+	// racing with it must take the loop over rather than fail.
+	hammer(4, 20, func(g, i int) {
+		if g == 0 && i == 0 {
+			if _, err := c.StartHeartbeat(licenseedict.HeartbeatOptions{InstanceID: "explicit"}); err != nil && !errors.Is(err, licenseedict.ErrAlreadyRunning) {
+				t.Errorf("StartHeartbeat: %v", err)
+			}
+			return
+		}
+		if _, err := c.Validate(); err != nil {
+			t.Errorf("Validate: %v", err)
+		}
+	})
+}
+
+func TestRaceRenewAndValidate(t *testing.T) {
+	r := newRaceIssuer(t)
+	c := r.client(t, r.sign(t, "lic-renew", ""))
+	defer c.Close()
+	drain(c)
+	if _, err := c.StartHeartbeat(); err != nil {
+		t.Fatal(err)
+	}
+
+	hammer(6, 20, func(g, i int) {
+		switch g % 3 {
+		case 0:
+			if _, err := c.Renew(); err != nil {
+				t.Errorf("Renew: %v", err)
+			}
+		case 1:
+			if _, err := c.Validate(); err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+		case 2:
+			if _, err := c.HeartbeatNow(context.Background()); err != nil {
+				t.Errorf("HeartbeatNow: %v", err)
+			}
+		}
+	})
+}
+
+func TestRaceClose(t *testing.T) {
+	r := newRaceIssuer(t)
+	tokens := []string{r.sign(t, "lic-x", ""), r.sign(t, "lic-y", "")}
+
+	for _, mode := range []licenseedict.EventDeliveryMode{licenseedict.EventDeliveryDrop, licenseedict.EventDeliveryBlock} {
+		c := r.client(t, tokens[0], licenseedict.WithEventDeliveryMode(mode), licenseedict.WithEventBufferSize(1))
+		if _, err := c.StartHeartbeat(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Nobody drains Events: Close must not wait on a blocked send.
+		var wg sync.WaitGroup
+		stop := make(chan struct{})
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; ; i++ {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					switch g {
+					case 0:
+						c.Validate()
+					case 1:
+						c.SetToken(tokens[i%2])
+					case 2:
+						c.StartHeartbeat()
+					case 3:
+						c.StopHeartbeat()
+					}
+				}
+			}(g)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		closed := make(chan error, 1)
+		go func() { closed <- c.Close() }()
+		select {
+		case <-closed:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Close did not return with delivery mode %v", mode)
+		}
+		close(stop)
+		wg.Wait()
+
+		if _, err := c.Validate(); !errors.Is(err, licenseedict.ErrClientClosed) {
+			t.Errorf("Validate after Close: err = %v, want ErrClientClosed", err)
+		}
+		for range c.Events {
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package licenseedict
+
+import "context"
+
+// ReactivationHandler supplies a replacement token after the server rejects
+// the current one. cause has Code ReactivationRequired or LicenseRevoked.
+// Returning an empty token or an error leaves the client unchanged.
+type ReactivationHandler func(ctx context.Context, cause *ValidationError) (string, error)
+
+// reactivate emits EventReactivationRequired and, if a ReactivationHandler
+// is configured, validates the token it returns and swaps it in. It reports
+// whether a new valid license is now in use.
+func (c *Client) reactivate(ctx context.Context, res apiResponse, cause *ValidationError) (*License, bool) {
+	c.emitEvent(res.event(Event{Type: EventReactivationRequired, Message: cause.Message, Data: cause}))
+
+	fn := c.cfg.onReactivate
+	if fn == nil {
+		return nil, false
+	}
+	var token string
+	var err error
+	c.safeCall("ReactivationHandler", func() { token, err = fn(ctx, cause) })
+	if err != nil {
+		c.hookError(err)
+		return nil, false
+	}
+	if token == "" {
+		return nil, false
+	}
+
+	license, err := c.Validate(token)
+	if err != nil || !license.Valid {
+		if err == nil {
+			err = &ValidationError{Code: ReactivationRequired, Message: "replacement token is not valid"}
+		}
+		c.hookError(err)
+		return nil, false
+	}
+	return license, true
+}
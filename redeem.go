@@ -0,0 +1,137 @@
+package licenseedict
+
+import (
+	"context"
+	"fmt"
+)
+
+// Redemption statuses for codes the server refuses. The server reports the
+// cause in Redemption.Status; these are the defaults when it does not.
+const (
+	redeemStatusInvalid         = "invalid"
+	redeemStatusAlreadyRedeemed = "already_redeemed"
+)
+
+// Redemption is the server's response to a redeemed code.
+type Redemption struct {
+	// Status is "redeemed" on success. For rejected codes it gives the
+	// cause, such as "invalid", "expired", "already_redeemed" or
+	// "not_applicable", and Reason explains it.
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	// SignedToken is the license token with the code applied.
+	SignedToken string `json:"signed_token,omitempty"`
+	// Description describes what the code granted, for display.
+	Description string `json:"description,omitempty"`
+}
+
+// CodeRejectedError is returned by RedeemCode when the server refuses the
+// code. It matches ErrCodeRejected with errors.Is, and also ErrCodeNotFound
+// for an unknown code or ErrCodeAlreadyRedeemed for a code that was already
+// used.
+type CodeRejectedError struct {
+	Status string
+	Reason string
+	// RequestID and ServerRequestID identify the request, as for
+	// ValidationError.
+	RequestID       string
+	ServerRequestID string
+}
+
+func (e *CodeRejectedError) Error() string {
+	msg := "licenseedict: code rejected"
+	if e.Status != "" {
+		msg += ": " + e.Status
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	if e.RequestID != "" {
+		msg += " (request_id=" + e.RequestID + ")"
+	}
+	return msg
+}
+
+func (e *CodeRejectedError) Is(target error) bool {
+	switch target {
+	case ErrCodeRejected:
+		return true
+	case ErrCodeNotFound:
+		return e.Status == redeemStatusInvalid
+	case ErrCodeAlreadyRedeemed:
+		return e.Status == redeemStatusAlreadyRedeemed
+	}
+	return false
+}
+
+// RedeemCode applies a promotional or add-on code to the current license via
+// the server. The updated token is verified and installed as with SetToken,
+// so features the code adds are available immediately, and an
+// EventCodeRedeemed event is emitted with the Redemption. A code the server
+// refuses yields a *CodeRejectedError and leaves the license unchanged; a
+// license the server reports as revoked yields a ValidationError with code
+// LicenseRevoked instead.
+//
+// If the server redeemed the code but the returned token cannot be
+// installed, RedeemCode returns a *RedemptionValidationError carrying the
+// token, since the code cannot be redeemed again.
+func (c *Client) RedeemCode(ctx context.Context, code string) (*License, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if code == "" {
+		return nil, &CodeRejectedError{Status: "invalid", Reason: "no code provided"}
+	}
+	if err := c.requireServer(); err != nil {
+		return nil, err
+	}
+	token := c.currentToken()
+	if token == "" {
+		return nil, ErrNoToken
+	}
+
+	body := map[string]string{
+		"signed_token": token,
+		"code":         code,
+		"instance_id":  c.cfg.instanceID,
+	}
+
+	var result Redemption
+	res, err := c.callServer(ctx, methodPost, c.cfg.endpoints.Redeem, redeemPath, body, &result)
+	if err != nil {
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: "code redemption request failed", Err: err})
+	}
+	switch {
+	case res.StatusCode == statusOK && result.SignedToken == "":
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: "code redemption returned no token"})
+	case res.StatusCode == statusOK:
+	case res.StatusCode == statusForbidden && result.Status == heartbeatStatusRevoked:
+		c.emitEvent(res.event(Event{Type: EventLicenseRevoked, Message: "license has been revoked", Data: result}))
+		c.shareRevocation()
+		return nil, res.annotate(&ValidationError{Code: LicenseRevoked, Message: "server rejected code redemption: license revoked"})
+	case res.StatusCode == statusNotFound:
+		return nil, codeRejected(res, result, redeemStatusInvalid)
+	case res.StatusCode == statusConflict:
+		return nil, codeRejected(res, result, redeemStatusAlreadyRedeemed)
+	case res.StatusCode >= 400 && res.StatusCode < 500:
+		return nil, codeRejected(res, result, "")
+	default:
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: fmt.Sprintf("code redemption returned status %d", res.StatusCode)})
+	}
+
+	license, err := c.SetToken(result.SignedToken)
+	if err != nil {
+		return license, &RedemptionValidationError{Result: result, Err: err}
+	}
+	c.emitEvent(res.event(Event{Type: EventCodeRedeemed, Message: "code redeemed", Data: result}))
+	return license, nil
+}
+
+// codeRejected builds the *CodeRejectedError for a refused redemption,
+// using status when the server did not report one.
+func codeRejected(res apiResponse, result Redemption, status string) *CodeRejectedError {
+	if result.Status == "" {
+		result.Status = status
+	}
+	return &CodeRejectedError{Status: result.Status, Reason: result.Reason, RequestID: res.RequestID, ServerRequestID: res.ServerRequestID}
+}
@@ -0,0 +1,36 @@
+package licenseedict
+
+import "errors"
+
+// RegistryScope selects the Windows registry hive used by the registry
+// cache store.
+type RegistryScope int
+
+const (
+	// RegistryNone disables the registry store.
+	RegistryNone RegistryScope = iota
+	// RegistryCurrentUser stores the license under HKEY_CURRENT_USER, for
+	// the current user only.
+	RegistryCurrentUser
+	// RegistryLocalMachine stores the license under HKEY_LOCAL_MACHINE, for
+	// all users. Writing requires administrator rights, so it suits
+	// licenses provisioned machine-wide by an installer.
+	RegistryLocalMachine
+)
+
+// registryValueName is the registry value holding the encoded cache.
+const registryValueName = "LicenseCache"
+
+// ErrRegistryUnsupported is returned by NewRegistryStore, and by NewClient
+// with WithRegistryStore, on platforms other than Windows.
+var ErrRegistryUnsupported = errors.New("licenseedict: registry store is only available on Windows")
+
+// WithRegistryStore stores the license cache in the Windows registry under
+// Software\<Publisher>\<App> in the given hive, using the names from
+// WithAppInfo. NewClient fails with ErrRegistryUnsupported on other
+// platforms.
+func WithRegistryStore(scope RegistryScope) Option {
+	return func(c *clientConfig) {
+		c.registryScope = scope
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package licenseedict
+
+// NewRegistryStore returns ErrRegistryUnsupported: the registry store is only
+// available on Windows.
+func NewRegistryStore(scope RegistryScope, publisher, appName string) (CacheStore, error) {
+	return nil, ErrRegistryUnsupported
+}
@@ -0,0 +1,90 @@
+package licenseedict
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// registryStore is a CacheStore backed by a registry value.
+type registryStore struct {
+	root registry.Key
+	path string
+}
+
+// NewRegistryStore returns a CacheStore that keeps the license cache in the
+// registry under Software\<publisher>\<appName> in the given hive.
+func NewRegistryStore(scope RegistryScope, publisher, appName string) (CacheStore, error) {
+	var root registry.Key
+	switch scope {
+	case RegistryCurrentUser:
+		root = registry.CURRENT_USER
+	case RegistryLocalMachine:
+		root = registry.LOCAL_MACHINE
+	default:
+		return nil, fmt.Errorf("licenseedict: invalid registry scope %d", scope)
+	}
+	if appName == "" {
+		appName = "licenseedict"
+	}
+	keyPath := `Software\` + appName
+	if publisher != "" {
+		keyPath = `Software\` + publisher + `\` + appName
+	}
+	return &registryStore{root: root, path: keyPath}, nil
+}
+
+func (s *registryStore) Load() ([]byte, error) {
+	k, err := registry.OpenKey(s.root, s.path, registry.QUERY_VALUE)
+	if err != nil {
+		return nil, registryNotExist(err)
+	}
+	defer k.Close()
+	data, _, err := k.GetBinaryValue(registryValueName)
+	if err != nil {
+		return nil, registryNotExist(err)
+	}
+	return data, nil
+}
+
+func (s *registryStore) Save(data []byte) error {
+	k, _, err := registry.CreateKey(s.root, s.path, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	return k.SetBinaryValue(registryValueName, data)
+}
+
+func (s *registryStore) Delete() error {
+	k, err := registry.OpenKey(s.root, s.path, registry.SET_VALUE)
+	if err != nil {
+		if errors.Is(err, registry.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer k.Close()
+	if err := k.DeleteValue(registryValueName); err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *registryStore) String() string {
+	hive := "HKCU"
+	if s.root == registry.LOCAL_MACHINE {
+		hive = "HKLM"
+	}
+	return hive + `\` + s.path + `\` + registryValueName
+}
+
+// registryNotExist maps a missing key or value to os.ErrNotExist.
+func registryNotExist(err error) error {
+	if errors.Is(err, registry.ErrNotExist) {
+		return fmt.Errorf("%w: %v", os.ErrNotExist, err)
+	}
+	return err
+}
@@ -1,33 +1,55 @@
 package licenseedict
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"time"
 )
 
 // Renew exchanges the current signed token for a renewed one via the server.
 // On success, the client's internal license and token are updated and the
 // new License is returned. The RenewalResult details are emitted as an
 // EventLicenseRenewed event on the Events channel.
+//
+// If the renewed token cannot be verified or is not valid, Renew returns a
+// *RenewalValidationError and keeps the previous license. See
+// WithLegacyRenewal for the earlier behavior.
 func (c *Client) Renew() (*License, error) {
-	if c.closed {
-		return nil, ErrClientClosed
-	}
+	license, _, err := c.renew(context.Background())
+	return license, err
+}
 
-	serverURL := c.resolveServerURL()
-	if serverURL == "" {
-		return nil, ErrNoServerURL
-	}
+// RenewResult exchanges the current signed token for a renewed one via the
+// server and returns the raw RenewalResult from the server response.
+// This is the legacy return type; prefer Renew() which returns *License.
+// If the renewed token fails validation, the result is returned together with
+// a *RenewalValidationError. If the server refuses the token and the
+// WithReactivationHandler handler supplies a replacement, the result
+// describes the replacement token and has the status "reactivated".
+func (c *Client) RenewResult() (*RenewalResult, error) {
+	_, result, err := c.renew(context.Background())
+	return result, err
+}
 
-	c.mu.RLock()
-	token := c.signedToken
-	c.mu.RUnlock()
+// renewalStatusReactivated is the RenewalResult status reported by
+// RenewResult when a refused renewal was recovered by reactivation.
+const renewalStatusReactivated = "reactivated"
 
-	if token == "" {
-		token = c.cfg.token
+// renew implements Renew and RenewResult. The result is nil if the server
+// did not answer with a renewal.
+func (c *Client) renew(ctx context.Context) (*License, *RenewalResult, error) {
+	if c.closed.Load() {
+		return nil, nil, ErrClientClosed
+	}
+
+	if err := c.requireServer(); err != nil {
+		return nil, nil, err
 	}
+
+	token := c.currentToken()
 	if token == "" {
-		return nil, ErrNoToken
+		return nil, nil, ErrNoToken
 	}
 
 	body := map[string]string{
@@ -35,26 +57,47 @@ func (c *Client) Renew() (*License, error) {
 	}
 
 	var result RenewalResult
-	url := fmt.Sprintf("%s/api/v1/licenses/renew", serverURL)
-	statusCode, err := c.http.postJSON(url, body, &result)
+	res, err := c.callServer(ctx, methodPost, c.cfg.endpoints.Renew, renewPath, body, &result)
+	c.learnRenewalWindow(result.RenewalWindow)
+	c.stats.renewals.Add(1)
 	if err != nil {
-		return nil, &ValidationError{Code: RenewalFailed, Message: "renewal request failed", Err: err}
+		c.stats.renewalsFailed.Add(1)
+		return nil, nil, res.annotate(&ValidationError{Code: RenewalFailed, Message: "renewal request failed", Err: err})
+	}
+
+	if res.StatusCode == statusForbidden && result.Status == heartbeatStatusSuspended {
+		c.stats.renewalsFailed.Add(1)
+		c.setSuspended(res, true, result.Reason)
+		return nil, nil, res.annotate(suspendedError(result.Reason))
+	}
+	if res.StatusCode == statusUnauthorized || res.StatusCode == statusForbidden {
+		c.stats.renewalsFailed.Add(1)
+		renewErr := res.annotate(&ValidationError{Code: ReactivationRequired, Message: fmt.Sprintf("server rejected renewal token with status %d", res.StatusCode)})
+		if license, ok := c.reactivate(ctx, res, renewErr); ok {
+			return license, reactivatedResult(license, token), nil
+		}
+		return nil, nil, renewErr
 	}
 
-	if statusCode != http.StatusOK {
-		return nil, &ValidationError{Code: RenewalFailed, Message: fmt.Sprintf("renewal returned status %d", statusCode)}
+	if res.StatusCode != statusOK {
+		c.stats.renewalsFailed.Add(1)
+		return nil, nil, res.annotate(&ValidationError{Code: RenewalFailed, Message: fmt.Sprintf("renewal returned status %d", res.StatusCode)})
 	}
 
 	// Re-validate with the new token
-	if result.SignedToken != "" && c.cfg.publicKey != nil {
-		newLicense, validateErr := c.Validate(result.SignedToken)
-		if validateErr == nil && newLicense.Valid {
-			c.emitEvent(Event{Type: EventLicenseRenewed, Message: "license renewed", Data: result})
-			return newLicense, nil
-		}
+	newLicense, validateErr := c.adoptRenewal(result)
+	if validateErr == nil {
+		c.emitEvent(res.event(Event{Type: EventLicenseRenewed, Message: "license renewed", Data: result}))
+		c.hookRenew(newLicense)
+		return newLicense, &result, nil
+	}
+
+	if !c.cfg.legacyRenewal {
+		c.stats.renewalsFailed.Add(1)
+		return nil, &result, validateErr
 	}
 
-	// If we can't re-validate, return a license with token info from result
+	// Legacy behavior: return a license with token info from result
 	license := &License{
 		SignedToken: result.SignedToken,
 	}
@@ -65,29 +108,179 @@ func (c *Client) Renew() (*License, error) {
 		c.mu.Unlock()
 	}
 
+	return license, &result, nil
+}
+
+// reactivatedResult describes license, installed by reactivation in place
+// of the refused token previous, as a RenewalResult.
+func reactivatedResult(license *License, previous string) *RenewalResult {
+	result := &RenewalResult{
+		Status:      renewalStatusReactivated,
+		SignedToken: license.SignedToken,
+	}
+	if !license.IssuedAt.IsZero() {
+		result.IssuedAt = license.IssuedAt.Format(time.RFC3339)
+	}
+	if !license.ExpiresAt.IsZero() {
+		result.ExpiresAt = license.ExpiresAt.Format(time.RFC3339)
+	}
+	if payload, err := decodeTokenPayload(previous); err == nil && !payload.ExpiresAt.IsZero() {
+		result.PreviousExpiresAt = payload.ExpiresAt.Format(time.RFC3339)
+	}
+	return result
+}
+
+// adoptRenewal verifies and installs the token from a successful renewal
+// response. Failures are reported as a *RenewalValidationError.
+func (c *Client) adoptRenewal(result RenewalResult) (*License, error) {
+	if result.SignedToken == "" {
+		return nil, &RenewalValidationError{Result: result, Err: &ValidationError{Code: RenewalFailed, Message: "renewal response contained no token"}}
+	}
+	c.clearSharedRevocation(result.SignedToken)
+	license, err := c.adoptToken(result.SignedToken)
+	if err != nil {
+		return nil, &RenewalValidationError{Result: result, Err: err}
+	}
 	return license, nil
 }
 
-// RenewResult exchanges the current signed token for a renewed one via the
-// server and returns the raw RenewalResult from the server response.
-// This is the legacy return type; prefer Renew() which returns *License.
-func (c *Client) RenewResult() (*RenewalResult, error) {
-	if c.closed {
+// RenewWithKey obtains a fresh token using the license key instead of the
+// current token. Use it when the stored token has long expired and the server
+// refuses token-based renewal, for example after reinstalling. The request
+// carries a host fingerprint so the server can apply its activation rules.
+//
+// The returned token is verified and installed as with SetToken, and an
+// EventLicenseRenewed event is emitted.
+func (c *Client) RenewWithKey(licenseKey string) (*License, error) {
+	if c.closed.Load() {
 		return nil, ErrClientClosed
 	}
+	result, res, err := c.requestTokenByKey(context.Background(), licenseKey, "")
+	if err != nil {
+		return nil, err
+	}
 
-	serverURL := c.resolveServerURL()
-	if serverURL == "" {
-		return nil, ErrNoServerURL
+	c.clearSharedRevocation(result.SignedToken)
+	license, err := c.SetToken(result.SignedToken)
+	if err != nil {
+		c.stats.renewalsFailed.Add(1)
+		return license, err
 	}
+	c.emitEvent(res.event(Event{Type: EventLicenseRenewed, Message: "license renewed by key", Data: result}))
+	c.hookRenew(license)
+	return license, nil
+}
 
-	c.mu.RLock()
-	token := c.signedToken
-	c.mu.RUnlock()
+// requestTokenByKey asks the server for a token for licenseKey. activationID,
+// if set, is sent so the server can recognize a retried activation instead
+// of counting it twice. The token is returned unverified.
+func (c *Client) requestTokenByKey(ctx context.Context, licenseKey, activationID string) (RenewalResult, apiResponse, error) {
+	var result RenewalResult
+	if licenseKey == "" {
+		return result, apiResponse{}, &ValidationError{Code: RenewalFailed, Message: "no license key provided"}
+	}
+	if c.cfg.denyVirtualized {
+		if env := DetectEnvironment(); env.VM || env.Container {
+			return result, apiResponse{}, &ValidationError{Code: VirtualizedEnvironment, Message: "node-locked activation is not allowed in a virtual machine or container"}
+		}
+	}
+	if err := c.requireServer(); err != nil {
+		return result, apiResponse{}, err
+	}
 
-	if token == "" {
-		token = c.cfg.token
+	body := map[string]interface{}{
+		"license_key":            licenseKey,
+		"fingerprint":            c.fingerprint(),
+		"fingerprint_components": c.hostFingerprint(),
+		"instance_id":            c.cfg.instanceID,
+		"product_id":             c.cfg.expectedProduct,
+	}
+	if activationID != "" {
+		body["activation_id"] = activationID
 	}
+
+	res, err := c.callServer(ctx, methodPost, c.cfg.endpoints.RenewWithKey, renewWithKeyPath, body, &result)
+	c.learnRenewalWindow(result.RenewalWindow)
+	c.stats.renewals.Add(1)
+	if err != nil {
+		c.stats.renewalsFailed.Add(1)
+		return result, res, res.annotate(&ValidationError{Code: RenewalFailed, Message: "renewal by key failed", Err: err})
+	}
+	if res.StatusCode != statusOK {
+		c.stats.renewalsFailed.Add(1)
+		return result, res, res.annotate(&ValidationError{Code: RenewalFailed, Message: fmt.Sprintf("renewal by key returned status %d", res.StatusCode)})
+	}
+	if result.SignedToken == "" {
+		c.stats.renewalsFailed.Add(1)
+		return result, res, res.annotate(&ValidationError{Code: RenewalFailed, Message: "renewal by key returned no token"})
+	}
+	return result, res, nil
+}
+
+// renewThreshold returns how long before expiry auto-renewal fires: the
+// WithRenewBefore threshold clamped to the configured renewal window and,
+// with WithLearnedRenewalWindow, the one learned from the server.
+func (c *Client) renewThreshold() time.Duration {
+	threshold := c.cfg.renewBefore
+	if threshold == 0 {
+		threshold = defaultRenewBefore
+	}
+	if min := c.cfg.renewWindowMin; min > 0 && threshold < min {
+		threshold = min
+	}
+	max := c.cfg.renewWindowMax
+	if learned := time.Duration(c.renewWindow.Load()); learned > 0 && (max == 0 || learned < max) {
+		max = learned
+	}
+	if max > 0 && threshold > max {
+		threshold = max
+	}
+	return threshold
+}
+
+// learnRenewalWindow records a renewal window, in seconds, reported by the
+// server, if WithLearnedRenewalWindow is enabled.
+func (c *Client) learnRenewalWindow(seconds int) {
+	if c.cfg.learnRenewWindow && seconds > 0 {
+		c.renewWindow.Store(int64(time.Duration(seconds) * time.Second))
+	}
+}
+
+// RenewalPreview is the server's answer to a renewal pre-flight check. No
+// renewal is performed.
+type RenewalPreview struct {
+	// Eligible reports whether a renewal would currently succeed. If not,
+	// Reason gives the server's explanation.
+	Eligible          bool      `json:"eligible"`
+	Reason            string    `json:"reason,omitempty"`
+	Plan              string    `json:"plan,omitempty"`
+	ExpiresAt         time.Time `json:"expires_at,omitempty"`
+	PreviousExpiresAt time.Time `json:"previous_expires_at,omitempty"`
+	// Price is the cost of the renewal, if the server reports one.
+	Price *Price `json:"price,omitempty"`
+	// RenewalWindow is how long before expiry, in seconds, the server
+	// accepts renewals.
+	RenewalWindow int `json:"renewal_window,omitempty"`
+}
+
+// Price is a monetary amount. Amount is a decimal string, such as "49.00",
+// to avoid floating-point rounding.
+type Price struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// RenewalPreview asks the server whether renewing the current token would
+// succeed and what the renewed license would look like, without committing
+// the renewal. Use it to show users when and how their license will renew.
+func (c *Client) RenewalPreview(ctx context.Context) (*RenewalPreview, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if err := c.requireServer(); err != nil {
+		return nil, err
+	}
+	token := c.currentToken()
 	if token == "" {
 		return nil, ErrNoToken
 	}
@@ -96,24 +289,92 @@ func (c *Client) RenewResult() (*RenewalResult, error) {
 		"signed_token": token,
 	}
 
-	var result RenewalResult
-	url := fmt.Sprintf("%s/api/v1/licenses/renew", serverURL)
-	statusCode, err := c.http.postJSON(url, body, &result)
+	var preview RenewalPreview
+	res, err := c.callServer(ctx, methodPost, c.cfg.endpoints.RenewalPreview, renewalPreviewPath, body, &preview)
 	if err != nil {
-		return nil, &ValidationError{Code: RenewalFailed, Message: "renewal request failed", Err: err}
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: "renewal preview request failed", Err: err})
 	}
+	if res.StatusCode != statusOK {
+		return nil, res.annotate(&ValidationError{Code: RenewalFailed, Message: fmt.Sprintf("renewal preview returned status %d", res.StatusCode)})
+	}
+	c.learnRenewalWindow(preview.RenewalWindow)
+	return &preview, nil
+}
+
+// RenewalQuote is the server's price for renewing or upgrading the current
+// license, as calculated by its billing integration. No purchase is made.
+type RenewalQuote struct {
+	// Plan is the plan the quote is for.
+	Plan string `json:"plan"`
+	// Price is the amount due for the renewal or upgrade, after proration.
+	Price Price `json:"price"`
+	// Interval and IntervalCount give the term bought, such as 1 "year" or
+	// 3 "month". Interval is one of "day", "week", "month" and "year".
+	Interval      string `json:"interval"`
+	IntervalCount int    `json:"interval_count"`
+	// ExpiresAt is the expiry the license would have after the purchase.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	// Proration is set when the price accounts for the unused part of the
+	// current term, as for upgrades.
+	Proration *Proration `json:"proration,omitempty"`
+	// CheckoutURL, if set, is where the user completes the purchase.
+	CheckoutURL string `json:"checkout_url,omitempty"`
+	// ValidUntil is when the quoted price expires.
+	ValidUntil time.Time `json:"valid_until,omitempty"`
+}
+
+// Proration details the adjustment applied to a RenewalQuote.
+type Proration struct {
+	// Credit is the value of the unused part of the current term, deducted
+	// from Charge to give the quoted price.
+	Credit Price `json:"credit"`
+	// Charge is the price of the new term before the credit.
+	Charge Price `json:"charge"`
+	// From is the date the proration is calculated from.
+	From time.Time `json:"from"`
+}
 
-	if statusCode != http.StatusOK {
-		return nil, &ValidationError{Code: RenewalFailed, Message: fmt.Sprintf("renewal returned status %d", statusCode)}
+// RenewalQuote asks the server what renewing the current license would
+// cost, for in-app renewal prompts such as "Renew for $499/yr".
+func (c *Client) RenewalQuote(ctx context.Context) (*RenewalQuote, error) {
+	return c.quote(ctx, "")
+}
+
+// UpgradeQuote is like RenewalQuote but quotes a switch of the current
+// license to plan, with the unused part of the current term credited.
+func (c *Client) UpgradeQuote(ctx context.Context, plan string) (*RenewalQuote, error) {
+	if plan == "" {
+		return nil, errors.New("licenseedict: upgrade quote requires a plan")
 	}
+	return c.quote(ctx, plan)
+}
 
-	// Re-validate with the new token to update internal state
-	if result.SignedToken != "" && c.cfg.publicKey != nil {
-		newLicense, validateErr := c.Validate(result.SignedToken)
-		if validateErr == nil && newLicense.Valid {
-			c.emitEvent(Event{Type: EventLicenseRenewed, Message: "license renewed", Data: result})
-		}
+func (c *Client) quote(ctx context.Context, plan string) (*RenewalQuote, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if err := c.requireServer(); err != nil {
+		return nil, err
+	}
+	token := c.currentToken()
+	if token == "" {
+		return nil, ErrNoToken
 	}
 
-	return &result, nil
+	body := map[string]string{
+		"signed_token": token,
+	}
+	if plan != "" {
+		body["plan"] = plan
+	}
+
+	var quote RenewalQuote
+	res, err := c.callServer(ctx, methodPost, c.cfg.endpoints.RenewalQuote, renewalQuotePath, body, &quote)
+	if err != nil {
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: "renewal quote request failed", Err: err})
+	}
+	if res.StatusCode != statusOK {
+		return nil, res.annotate(&ValidationError{Code: RenewalFailed, Message: fmt.Sprintf("renewal quote returned status %d", res.StatusCode)})
+	}
+	return &quote, nil
 }
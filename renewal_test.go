@@ -0,0 +1,52 @@
+package licenseedict_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
+)
+
+func TestRenewReturnsReactivatedLicense(t *testing.T) {
+	r := newRaceIssuer(t)
+	replacement := r.sign(t, "lic-replacement", "")
+	newClient := func() *licenseedict.Client {
+		c, err := licenseedict.NewClient(
+			licenseedict.WithPublicKey(r.publicKey),
+			licenseedict.WithToken(r.sign(t, "lic-renew", "")),
+			licenseedict.WithInMemoryState(),
+			licenseedict.WithSimulatedServer(licenseedict.SimulationScenario{RenewalStatus: 401}),
+			licenseedict.WithReactivationHandler(func(context.Context, *licenseedict.ValidationError) (string, error) {
+				return replacement, nil
+			}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		drain(c)
+		return c
+	}
+
+	c := newClient()
+	defer c.Close()
+	license, err := c.Renew()
+	if err != nil {
+		t.Fatalf("Renew: %v", err)
+	}
+	if license.LicenseID != "lic-replacement" {
+		t.Fatalf("Renew: license %q, want the replacement", license.LicenseID)
+	}
+
+	c = newClient()
+	defer c.Close()
+	result, err := c.RenewResult()
+	if err != nil {
+		t.Fatalf("RenewResult: %v", err)
+	}
+	if result.Status != "reactivated" || result.SignedToken != replacement {
+		t.Fatalf("RenewResult: status %q, want reactivated with the replacement token", result.Status)
+	}
+	if result.PreviousExpiresAt == "" || result.ExpiresAt == "" {
+		t.Fatalf("RenewResult: expiry not reported: %+v", result)
+	}
+}
@@ -0,0 +1,169 @@
+package licenseedict
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrReservationEnded is returned by Reservation.Activate after the
+// reservation was activated, cancelled or expired.
+var ErrReservationEnded = errors.New("licenseedict: reservation already ended")
+
+// Reservation is a floating seat reserved ahead of time, for schedulers that
+// need a seat guaranteed when a job starts. Activate converts it into a
+// SeatLease at job start. If the window ends first, EventReservationExpired
+// is emitted.
+type Reservation struct {
+	ID         string    `json:"reservation_id"`
+	InstanceID string    `json:"instance_id"`
+	StartAt    time.Time `json:"start_at"`
+	EndAt      time.Time `json:"end_at"`
+	Features   []string  `json:"features,omitempty"`
+
+	c      *Client
+	mu     sync.Mutex
+	ended  bool
+	expiry *time.Timer
+}
+
+// ReserveSeat reserves a seat for duration starting at start. opts identify
+// the instance that will use the seat; its InstanceID defaults to
+// WithInstanceID or a random ID. The server rejects the reservation with
+// SEAT_LIMIT_REACHED if no seat is free for the window.
+func (c *Client) ReserveSeat(ctx context.Context, start time.Time, duration time.Duration, opts ...HeartbeatOptions) (*Reservation, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if err := c.requireServer(); err != nil {
+		return nil, err
+	}
+	token := c.currentToken()
+	if token == "" {
+		return nil, ErrNoToken
+	}
+
+	var o HeartbeatOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.InstanceID == "" {
+		o.InstanceID = c.cfg.instanceID
+	}
+	if o.InstanceID == "" {
+		o.InstanceID = newRequestID()
+	}
+
+	body := map[string]interface{}{
+		"signed_token":     token,
+		"instance_id":      o.InstanceID,
+		"start_at":         start.UTC(),
+		"duration_seconds": int(duration / time.Second),
+	}
+	if len(o.Features) > 0 {
+		body["features"] = o.Features
+	}
+
+	var resp struct {
+		Reservation
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}
+	res, err := c.callServer(ctx, methodPost, c.cfg.endpoints.Reserve, reservePath, body, &resp)
+	if err != nil {
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: "reservation request failed", Err: err})
+	}
+	if res.StatusCode == statusConflict || (res.StatusCode == statusOK && resp.Status != "confirmed") {
+		msg := resp.Message
+		if msg == "" {
+			msg = "no seat available for the requested window"
+		}
+		return nil, res.annotate(&ValidationError{Code: SeatLimitReached, Message: msg})
+	}
+	if res.StatusCode != statusOK {
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: fmt.Sprintf("reservation returned status %d", res.StatusCode)})
+	}
+
+	r := &Reservation{
+		ID:         resp.ID,
+		InstanceID: o.InstanceID,
+		StartAt:    resp.StartAt,
+		EndAt:      resp.EndAt,
+		Features:   o.Features,
+		c:          c,
+	}
+	if r.StartAt.IsZero() {
+		r.StartAt = start
+	}
+	if r.EndAt.IsZero() {
+		r.EndAt = start.Add(duration)
+	}
+	r.expiry = time.AfterFunc(time.Until(r.EndAt), r.expire)
+	c.emitEvent(res.event(Event{Type: EventReservationConfirmed, Message: "seat reserved until " + r.EndAt.Format(time.RFC3339), Data: r}))
+	return r, nil
+}
+
+// Activate claims the reserved seat and keeps it alive as a SeatLease. It
+// may be called before StartAt; the server decides whether early activation
+// is allowed. If the seat cannot be acquired, the reservation stays open and
+// Activate may be retried.
+func (r *Reservation) Activate(ctx context.Context) (*SeatLease, error) {
+	r.mu.Lock()
+	ended := r.ended
+	r.mu.Unlock()
+	if ended {
+		return nil, ErrReservationEnded
+	}
+
+	lease, err := r.c.AcquireSeat(ctx, HeartbeatOptions{
+		InstanceID:    r.InstanceID,
+		Features:      r.Features,
+		ReservationID: r.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.end()
+	return lease, nil
+}
+
+// Cancel releases the reservation on the server.
+func (r *Reservation) Cancel(ctx context.Context) error {
+	if !r.end() {
+		return ErrReservationEnded
+	}
+	body := map[string]interface{}{
+		"signed_token":   r.c.currentToken(),
+		"reservation_id": r.ID,
+	}
+	res, err := r.c.callServer(ctx, methodPost, r.c.cfg.endpoints.CancelReserve, cancelReservePath, body, nil)
+	if err != nil {
+		return res.annotate(&ValidationError{Code: ServerUnreachable, Message: "reservation cancel request failed", Err: err})
+	}
+	if res.StatusCode != statusOK && res.StatusCode != statusNotFound {
+		return res.annotate(&ValidationError{Code: ServerUnreachable, Message: fmt.Sprintf("reservation cancel returned status %d", res.StatusCode)})
+	}
+	return nil
+}
+
+// end marks the reservation as used and reports whether it was still open.
+func (r *Reservation) end() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ended {
+		return false
+	}
+	r.ended = true
+	if r.expiry != nil {
+		r.expiry.Stop()
+	}
+	return true
+}
+
+func (r *Reservation) expire() {
+	if r.end() && !r.c.closed.Load() {
+		r.c.emitEvent(Event{Type: EventReservationExpired, Message: "reservation " + r.ID + " expired unused", Data: r})
+	}
+}
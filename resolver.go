@@ -0,0 +1,127 @@
+package licenseedict
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+)
+
+// LicenseSource identifies where a LicenseResolver found the token.
+type LicenseSource string
+
+// License sources, in order of precedence.
+const (
+	SourceExplicit LicenseSource = "explicit"
+	SourceEnv      LicenseSource = "env"
+	SourceUser     LicenseSource = "user"
+	SourceMachine  LicenseSource = "machine"
+	SourceEmbedded LicenseSource = "embedded"
+)
+
+// licenseFileName is the license file looked for in the per-user and
+// machine-wide directories.
+const licenseFileName = "license.lic"
+
+// LicenseResolver finds the license token among layered sources, so that a
+// license entered by the user and one deployed machine-wide by IT coexist
+// predictably. Sources are consulted in this order and the first one that
+// yields a token wins:
+//
+//  1. Explicit, a token set by the application
+//  2. the environment variable EnvVar
+//  3. the per-user license file UserPath
+//  4. the machine-wide license file MachinePath
+//  5. Embedded, a default token compiled into the application
+//
+// Empty fields are skipped. License files may be armored or hold a bare
+// token.
+type LicenseResolver struct {
+	Explicit    string
+	EnvVar      string
+	UserPath    string
+	MachinePath string
+	Embedded    string
+}
+
+// Resolution is the result of LicenseResolver.Resolve.
+type Resolution struct {
+	Token  string
+	Source LicenseSource
+	// Location is the environment variable or file the token came from, if
+	// any.
+	Location string
+}
+
+// NewLicenseResolver returns a resolver using the conventional locations for
+// the application: the LICENSEEDICT_TOKEN environment variable and a
+// license.lic file in the per-user data directory and in the machine-wide
+// directory (see CacheLocationSystem), under <publisher>/<appName>.
+func NewLicenseResolver(appName, publisher string) *LicenseResolver {
+	if appName == "" {
+		appName = "licenseedict"
+	}
+	return &LicenseResolver{
+		EnvVar:      EnvToken,
+		UserPath:    filepath.Join(xdg.DataHome, publisher, appName, licenseFileName),
+		MachinePath: filepath.Join(systemDataDir(), publisher, appName, licenseFileName),
+	}
+}
+
+// Resolve returns the token from the highest-precedence source that has
+// one. Missing files are skipped; a file that exists but cannot be read or
+// parsed is an error, since silently falling through to a lower-precedence
+// license would be surprising. It returns ErrNoToken if no source has a
+// token.
+func (r *LicenseResolver) Resolve() (*Resolution, error) {
+	if token := strings.TrimSpace(r.Explicit); token != "" {
+		return &Resolution{Token: token, Source: SourceExplicit}, nil
+	}
+	if r.EnvVar != "" {
+		if token := strings.TrimSpace(os.Getenv(r.EnvVar)); token != "" {
+			return &Resolution{Token: token, Source: SourceEnv, Location: r.EnvVar}, nil
+		}
+	}
+	for _, f := range []struct {
+		path   string
+		source LicenseSource
+	}{
+		{r.UserPath, SourceUser},
+		{r.MachinePath, SourceMachine},
+	} {
+		if f.path == "" {
+			continue
+		}
+		token, err := LoadLicenseFile(f.path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &Resolution{Token: token, Source: f.source, Location: f.path}, nil
+	}
+	if token := strings.TrimSpace(r.Embedded); token != "" {
+		return &Resolution{Token: token, Source: SourceEmbedded}, nil
+	}
+	return nil, ErrNoToken
+}
+
+// WithLicenseResolver resolves the token with r when the client is created,
+// unless a token was set with WithToken or WithLicenseFile. NewClient fails
+// if resolution fails for a reason other than no token being found. The
+// winning source is reported by Client.LicenseSource.
+func WithLicenseResolver(r *LicenseResolver) Option {
+	return func(c *clientConfig) {
+		c.resolver = r
+	}
+}
+
+// LicenseSource reports where the token came from when the client was
+// created with WithLicenseResolver. It returns nil otherwise, or if no
+// source had a token.
+func (c *Client) LicenseSource() *Resolution {
+	return c.resolution
+}
@@ -0,0 +1,81 @@
+package licenseedict
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// dailyWindow is a daily time-of-day window, used for the renewal schedule
+// and for quiet hours.
+type dailyWindow struct {
+	start  time.Duration // offset from local midnight
+	length time.Duration
+	loc    *time.Location
+}
+
+// parseDailyWindow parses a "HH:MM-HH:MM" window. A window whose end is
+// before its start wraps past midnight. what names the setting in errors.
+func parseDailyWindow(what, spec string, loc *time.Location) (*dailyWindow, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, fmt.Errorf("licenseedict: %s %q: want HH:MM-HH:MM", what, spec)
+	}
+	start, err := parseTimeOfDay(from)
+	if err != nil {
+		return nil, fmt.Errorf("licenseedict: %s %q: %w", what, spec, err)
+	}
+	end, err := parseTimeOfDay(to)
+	if err != nil {
+		return nil, fmt.Errorf("licenseedict: %s %q: %w", what, spec, err)
+	}
+
+	length := end - start
+	if length <= 0 {
+		length += 24 * time.Hour
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return &dailyWindow{start: start, length: length, loc: loc}, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// window returns the current window if now falls inside one, otherwise the
+// next window.
+func (s *dailyWindow) window(now time.Time) (time.Time, time.Time) {
+	local := now.In(s.loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.loc)
+	for day := -1; ; day++ {
+		start := midnight.AddDate(0, 0, day).Add(s.start)
+		end := start.Add(s.length)
+		if end.After(now) {
+			return start, end
+		}
+	}
+}
+
+// delay returns how long to wait from now until a random point in the
+// current or next window, spreading a fleet's renewals across the window.
+func (s *dailyWindow) delay(now time.Time) time.Duration {
+	start, end := s.window(now)
+	if start.Before(now) {
+		start = now
+	}
+	return start.Sub(now) + time.Duration(rand.Int63n(int64(end.Sub(start))+1))
+}
+
+// contains reports whether now falls inside the window, and if so when the
+// window ends.
+func (s *dailyWindow) contains(now time.Time) (bool, time.Time) {
+	start, end := s.window(now)
+	return !start.After(now), end
+}
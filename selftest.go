@@ -0,0 +1,224 @@
+package licenseedict
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// SelfTestStatus is the outcome of a single self-test check.
+type SelfTestStatus string
+
+const (
+	SelfTestPass SelfTestStatus = "pass"
+	SelfTestWarn SelfTestStatus = "warn"
+	SelfTestFail SelfTestStatus = "fail"
+	SelfTestSkip SelfTestStatus = "skip"
+)
+
+// Self-test check names, in the order SelfTest runs them.
+const (
+	CheckPublicKey = "public_key"
+	CheckToken     = "token"
+	CheckCache     = "cache"
+	CheckClock     = "clock"
+	CheckServer    = "server"
+)
+
+// SelfTestCheck is the result of one self-test check.
+type SelfTestCheck struct {
+	Name     string         `json:"name"`
+	Status   SelfTestStatus `json:"status"`
+	Message  string         `json:"message"`
+	Duration time.Duration  `json:"duration_ns"`
+}
+
+// SelfTestReport is the structured result of SelfTest.
+type SelfTestReport struct {
+	StartedAt time.Time       `json:"started_at"`
+	Checks    []SelfTestCheck `json:"checks"`
+}
+
+// OK reports whether no check failed. Warnings and skipped checks are allowed.
+func (r SelfTestReport) OK() bool {
+	for _, check := range r.Checks {
+		if check.Status == SelfTestFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Check returns the named check and whether it was run.
+func (r SelfTestReport) Check(name string) (SelfTestCheck, bool) {
+	for _, check := range r.Checks {
+		if check.Name == name {
+			return check, true
+		}
+	}
+	return SelfTestCheck{}, false
+}
+
+// maxClockSkew is how far the local clock may differ from the license issue
+// time before the clock check warns.
+const maxClockSkew = 5 * time.Minute
+
+// SelfTest checks the client's configuration and environment: public key
+// decoding, token verification, cache read/write, clock sanity, and server
+// reachability. It does not modify the license cache or client state, and
+// returns a report rather than an error so every check is always run.
+func (c *Client) SelfTest(ctx context.Context) SelfTestReport {
+	report := SelfTestReport{StartedAt: time.Now()}
+	run := func(name string, fn func() (SelfTestStatus, string)) {
+		start := time.Now()
+		status, msg := fn()
+		report.Checks = append(report.Checks, SelfTestCheck{Name: name, Status: status, Message: msg, Duration: time.Since(start)})
+	}
+
+	var payload *tokenPayload
+	run(CheckPublicKey, c.selfTestPublicKey)
+	run(CheckToken, func() (SelfTestStatus, string) {
+		var status SelfTestStatus
+		var msg string
+		payload, status, msg = c.selfTestToken()
+		return status, msg
+	})
+	run(CheckCache, c.selfTestCache)
+	run(CheckClock, func() (SelfTestStatus, string) { return c.selfTestClock(payload) })
+	run(CheckServer, func() (SelfTestStatus, string) { return c.selfTestServer(ctx) })
+	return report
+}
+
+func (c *Client) selfTestPublicKey() (SelfTestStatus, string) {
+	if c.devLicense != nil {
+		return SelfTestSkip, "development client"
+	}
+	if c.cfg.publicKey == nil {
+		if c.cfg.publicKeyStr != "" {
+			if _, err := DecodePublicKey(c.cfg.publicKeyStr); err != nil {
+				return SelfTestFail, "public key could not be decoded: " + err.Error()
+			}
+		}
+		return SelfTestFail, "no public key configured"
+	}
+	msg := "Ed25519 public key loaded"
+	if n := len(c.trustedKeys); n > 0 {
+		msg += fmt.Sprintf(" (%d additional trusted keys from bundle)", n)
+	}
+	return SelfTestPass, msg
+}
+
+func (c *Client) selfTestToken() (*tokenPayload, SelfTestStatus, string) {
+	token := c.currentToken()
+	if token == "" {
+		return nil, SelfTestSkip, "no token configured or validated yet"
+	}
+	if c.cfg.publicKey == nil {
+		return nil, SelfTestSkip, "no public key to verify against"
+	}
+	payload, err := c.verify(token)
+	if err != nil {
+		return nil, SelfTestFail, "token verification failed: " + err.Error()
+	}
+	if !payload.ExpiresAt.IsZero() && time.Now().After(payload.ExpiresAt) {
+		return payload, SelfTestFail, "token signature valid but license expired at " + payload.ExpiresAt.Format(time.RFC3339)
+	}
+	return payload, SelfTestPass, "token signature valid for license " + payload.LicenseID
+}
+
+func (c *Client) selfTestCache() (SelfTestStatus, string) {
+	if c.cfg.disableCache {
+		return SelfTestSkip, "cache disabled"
+	}
+	fs := c.cache.file()
+	if fs == nil {
+		return c.selfTestCacheStore()
+	}
+
+	if err := os.MkdirAll(fs.dir, 0700); err != nil {
+		return SelfTestFail, "cannot create cache directory: " + err.Error()
+	}
+	probe, err := os.CreateTemp(fs.dir, ".selftest-*")
+	if err != nil {
+		return SelfTestFail, "cache directory is not writable: " + err.Error()
+	}
+	name := probe.Name()
+	defer os.Remove(name)
+	want := []byte("licenseedict self-test")
+	_, err = probe.Write(want)
+	if closeErr := probe.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return SelfTestFail, "cache write failed: " + err.Error()
+	}
+	if got, err := os.ReadFile(name); err != nil || string(got) != string(want) {
+		return SelfTestFail, "cache read-back failed"
+	}
+
+	_, version, err := c.cache.read()
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return SelfTestPass, "cache directory writable; no cached license yet"
+	case errors.Is(err, errCacheVersionUnsupported):
+		return SelfTestWarn, "cached license was written by a newer SDK version"
+	case err != nil:
+		return SelfTestWarn, "cached license is unreadable: " + err.Error()
+	case version != cacheFormatVersion:
+		return SelfTestWarn, fmt.Sprintf("cached license uses format version %d; call MigrateCache", version)
+	}
+	return SelfTestPass, "cache readable and writable at " + fs.path()
+}
+
+// selfTestCacheStore checks a cache store configured with WithCacheStore. It
+// only reads, since writing would replace the cached license.
+func (c *Client) selfTestCacheStore() (SelfTestStatus, string) {
+	_, version, err := c.cache.read()
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return SelfTestPass, "cache store readable; no cached license yet"
+	case errors.Is(err, errCacheVersionUnsupported):
+		return SelfTestWarn, "cached license was written by a newer SDK version"
+	case err != nil:
+		return SelfTestWarn, "cached license is unreadable: " + err.Error()
+	case version != cacheFormatVersion:
+		return SelfTestWarn, fmt.Sprintf("cached license uses format version %d; call MigrateCache", version)
+	}
+	return SelfTestPass, "cache store readable"
+}
+
+func (c *Client) selfTestClock(payload *tokenPayload) (SelfTestStatus, string) {
+	now := time.Now()
+	if !c.cfg.buildDate.IsZero() && now.Before(c.cfg.buildDate) {
+		return SelfTestFail, "system clock is earlier than the application build date " + c.cfg.buildDate.Format(time.RFC3339)
+	}
+	if payload != nil && !payload.IssuedAt.IsZero() && now.Add(maxClockSkew).Before(payload.IssuedAt) {
+		return SelfTestFail, "system clock is behind the license issue time " + payload.IssuedAt.Format(time.RFC3339)
+	}
+	return SelfTestPass, "system clock is " + now.UTC().Format(time.RFC3339)
+}
+
+func (c *Client) selfTestServer(ctx context.Context) (SelfTestStatus, string) {
+	if c.cfg.offlineOnly {
+		return SelfTestSkip, "offline-only mode"
+	}
+	urls := c.serverURLs()
+	if len(urls) == 0 {
+		return SelfTestSkip, "no server URL configured"
+	}
+
+	var errs []error
+	for _, u := range urls {
+		rtt, err := c.http.probe(ctx, u)
+		if err == nil {
+			return SelfTestPass, fmt.Sprintf("%s reachable in %s", redactURL(u), rtt.Round(time.Millisecond))
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", redactURL(u), err))
+	}
+	if c.cache.path() != "" {
+		return SelfTestWarn, "no server reachable; running from cache: " + errors.Join(errs...).Error()
+	}
+	return SelfTestFail, "no server reachable: " + errors.Join(errs...).Error()
+}
@@ -0,0 +1,169 @@
+package licenseedict
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// serverFailureThreshold is the number of consecutive failures after
+	// which a server is considered unhealthy.
+	serverFailureThreshold = 2
+	// serverCooldown is how long an unhealthy server is skipped before it
+	// is tried again.
+	serverCooldown = 60 * time.Second
+)
+
+// serverPool tracks the health of each configured server URL so requests can
+// fail over to the next server and skip ones that recently failed.
+type serverPool struct {
+	mu      sync.Mutex
+	health  map[string]*serverHealth
+	latency map[string]time.Duration
+}
+
+type serverHealth struct {
+	failures  int
+	downUntil time.Time
+}
+
+func (p *serverPool) healthy(url string, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h := p.health[url]
+	return h == nil || now.After(h.downUntil)
+}
+
+func (p *serverPool) markFailure(url string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.health == nil {
+		p.health = make(map[string]*serverHealth)
+	}
+	h := p.health[url]
+	if h == nil {
+		h = &serverHealth{}
+		p.health[url] = h
+	}
+	h.failures++
+	if h.failures >= serverFailureThreshold {
+		h.downUntil = time.Now().Add(serverCooldown)
+	}
+}
+
+func (p *serverPool) markSuccess(url string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.health, url)
+}
+
+// order returns urls with healthy servers first, preserving the configured
+// order within each group unless latency probes have ranked them. Unhealthy
+// servers are kept as a last resort.
+func (p *serverPool) order(urls []string) []string {
+	now := time.Now()
+	ordered := make([]string, 0, len(urls))
+	var down []string
+	for _, u := range urls {
+		if p.healthy(u, now) {
+			ordered = append(ordered, u)
+		} else {
+			down = append(down, u)
+		}
+	}
+	p.sortByLatency(ordered)
+	return append(ordered, down...)
+}
+
+// serverURLs returns the candidate server URLs in priority order: those set
+// via WithServerURLs or WithServerURL, otherwise the ones from the license.
+func (c *Client) serverURLs() []string {
+	var urls []string
+	if len(c.cfg.serverURLs) > 0 {
+		urls = append(urls, c.cfg.serverURLs...)
+	} else if c.cfg.serverURL != "" {
+		urls = append(urls, c.cfg.serverURL)
+	}
+	if len(urls) > 0 {
+		return urls
+	}
+
+	c.mu.RLock()
+	tokenServerURL, license := c.tokenServerURL, c.license
+	c.mu.RUnlock()
+	if tokenServerURL != "" {
+		return append(urls, tokenServerURL)
+	}
+	if license == nil {
+		// Before the first validation, take the URLs from the configured
+		// token so heartbeats can start without a prior Validate.
+		payload, err := decodeTokenPayload(c.currentToken())
+		if err != nil {
+			return nil
+		}
+		license = &License{ServerURL: payload.ServerURL, ServerURLs: payload.ServerURLs}
+	}
+	if license.ServerURL != "" {
+		urls = append(urls, license.ServerURL)
+	}
+	for _, u := range license.ServerURLs {
+		if u != license.ServerURL {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// networkAvailable reports whether the client may talk to a server: always
+// in regular builds, and only to the in-process simulation in builds with the
+// licenseedict_nonetwork tag.
+func (c *Client) networkAvailable() bool {
+	return networkEnabled || c.cfg.simulation != nil
+}
+
+// requireServer returns the error online-only APIs fail with when they
+// cannot reach a server: ErrNetworkDisabled in builds without network
+// support, or ErrNoServerURL if no server URL is known.
+func (c *Client) requireServer() error {
+	if !c.networkAvailable() {
+		return ErrNetworkDisabled
+	}
+	if c.resolveServerURL() == "" {
+		return ErrNoServerURL
+	}
+	return nil
+}
+
+// callServer sends a JSON request to the first healthy server, failing over
+// to the remaining servers on network errors and 5xx responses. It returns
+// the response details and error of the last attempt.
+func (c *Client) callServer(ctx context.Context, method, override, path string, body, result interface{}) (apiResponse, error) {
+	if !c.networkAvailable() {
+		return apiResponse{}, ErrNetworkDisabled
+	}
+	urls := c.servers.order(c.serverURLs())
+	if len(urls) == 0 {
+		return apiResponse{}, ErrNoServerURL
+	}
+	c.markContactAttempt()
+
+	var (
+		res apiResponse
+		err error
+	)
+	for _, u := range urls {
+		res, err = c.http.doJSON(ctx, method, c.endpointURL(u, override, path), body, result)
+		if ctx.Err() != nil {
+			return res, err
+		}
+		if err != nil || res.StatusCode >= 500 {
+			c.servers.markFailure(u)
+			continue
+		}
+		c.servers.markSuccess(u)
+		c.markServerContact()
+		return res, nil
+	}
+	return res, err
+}
@@ -0,0 +1,101 @@
+package licenseedict
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// defaultShutdownTimeout bounds the final checkout in Shutdown when no
+// timeout is configured.
+const defaultShutdownTimeout = 10 * time.Second
+
+// WithShutdownTimeout bounds how long Shutdown and RunUntilSignal wait for
+// the final checkout before closing the client anyway (default 10s). The
+// seat then expires on the server by TTL.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(c *clientConfig) {
+		c.shutdownTimeout = d
+	}
+}
+
+// Shutdown releases the client's seats and closes it: it stops the
+// heartbeat, checks out the seat if one was acquired, releases open seat
+// leases, and calls Close. The checkout is bounded by ctx and the timeout
+// set with WithShutdownTimeout; the client is closed even if it fails, and
+// the checkout error is returned. A seat the server no longer knows counts
+// as released. Shutdown is safe to call more than once.
+func (c *Client) Shutdown(ctx context.Context) (*CheckoutResult, error) {
+	if c.closed.Load() {
+		return nil, nil
+	}
+	defer c.Close()
+
+	timeout := c.cfg.shutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	c.hb.mu.Lock()
+	running := c.hb.running
+	c.hb.mu.Unlock()
+	last, _ := c.LastHeartbeat()
+
+	var result *CheckoutResult
+	var errs []error
+	if running || last != nil {
+		var err error
+		result, err = c.CheckoutContext(ctx)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	c.mu.Lock()
+	leases := make([]*SeatLease, 0, len(c.leases))
+	for l := range c.leases {
+		leases = append(leases, l)
+	}
+	c.mu.Unlock()
+	for _, l := range leases {
+		if err := l.Release(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// RunUntilSignal blocks until the process receives one of signals (SIGINT
+// and SIGTERM if none are given) or ctx is done, then calls Shutdown. It
+// replaces the usual wait-for-signal, checkout and close sequence at the end
+// of main:
+//
+//	if _, err := client.Start(ctx, licenseedict.StartOptions{Heartbeat: true}); err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := client.RunUntilSignal(ctx); err != nil {
+//	    log.Printf("checkout failed: %v", err)
+//	}
+//
+// The final checkout runs even when ctx was cancelled, bounded by the
+// WithShutdownTimeout timeout.
+func (c *Client) RunUntilSignal(ctx context.Context, signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, signals...)
+	defer signal.Stop(sig)
+
+	select {
+	case <-sig:
+	case <-ctx.Done():
+	}
+	_, err := c.Shutdown(context.WithoutCancel(ctx))
+	return err
+}
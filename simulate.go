@@ -0,0 +1,277 @@
+package licenseedict
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// simulatedServerURL is used when WithSimulatedServer is set and no server
+// URL is configured or present in the token.
+const simulatedServerURL = "http://simulated.licenseedict.invalid"
+
+// SimulationScenario configures the in-process server used by
+// WithSimulatedServer. The zero value accepts every request with unlimited
+// seats.
+type SimulationScenario struct {
+	// MaxSeats limits concurrent instances; 0 means unlimited.
+	MaxSeats int
+	// FeatureSeats limits concurrent instances per feature seat pool.
+	FeatureSeats map[string]int
+	// Latency delays every simulated response.
+	Latency time.Duration
+	// HeartbeatInterval is the interval, in seconds, returned to heartbeats.
+	HeartbeatInterval int
+	// Revoked makes heartbeats and renewals report the license as revoked.
+	Revoked bool
+	// RegionRestricted makes heartbeats report a region restriction.
+	RegionRestricted bool
+	// Suspended makes heartbeats and renewals report the license as suspended.
+	Suspended bool
+	// ClientIP is reported to heartbeats as the address the server sees.
+	ClientIP string
+	// RenewalStatus is the HTTP status returned by renewals (default 200).
+	RenewalStatus int
+	// RenewalPeriod extends the expiry of renewed tokens (default 30 days).
+	RenewalPeriod time.Duration
+	// SigningKey signs renewed tokens. Without it, renewals return the
+	// current token unchanged.
+	SigningKey ed25519.PrivateKey
+	// Product is returned to ProductInfo. Without it, the product has a
+	// single plan built from the token's plan, features and seats.
+	Product *ProductInfo
+}
+
+// simulatedServer answers SDK API requests in-process according to a
+// SimulationScenario, without touching the network.
+type simulatedServer struct {
+	scenario  SimulationScenario
+	endpoints Endpoints
+
+	mu        sync.Mutex
+	instances map[string]time.Time
+	pools     map[string]map[string]time.Time
+}
+
+func newSimulatedServer(scenario SimulationScenario, endpoints Endpoints) *simulatedServer {
+	return &simulatedServer{
+		scenario:  scenario,
+		endpoints: endpoints,
+		instances: make(map[string]time.Time),
+		pools:     make(map[string]map[string]time.Time),
+	}
+}
+
+// serve answers a request for path with the JSON body data, returning the
+// response status and the value to encode as the response body.
+func (s *simulatedServer) serve(ctx context.Context, path string, data []byte) (int, interface{}, error) {
+	if s.scenario.Latency > 0 {
+		select {
+		case <-time.After(s.scenario.Latency):
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		}
+	}
+
+	var body map[string]interface{}
+	_ = json.Unmarshal(data, &body)
+	token, _ := body["signed_token"].(string)
+	instanceID, _ := body["instance_id"].(string)
+	var features []string
+	if list, ok := body["features"].([]interface{}); ok {
+		for _, f := range list {
+			if name, ok := f.(string); ok {
+				features = append(features, name)
+			}
+		}
+	}
+
+	switch {
+	case s.matches(path, s.endpoints.Heartbeat, heartbeatPath):
+		status, resp := s.heartbeat(instanceID, features)
+		return status, resp, nil
+	case s.matches(path, s.endpoints.Checkout, checkoutPath):
+		s.mu.Lock()
+		_, held := s.instances[instanceID]
+		delete(s.instances, instanceID)
+		for _, pool := range s.pools {
+			delete(pool, instanceID)
+		}
+		remaining := len(s.instances)
+		s.mu.Unlock()
+		if !held {
+			return statusNotFound, map[string]interface{}{"status": checkoutStatusNotFound, "remaining_sessions": remaining}, nil
+		}
+		return statusOK, map[string]interface{}{"status": "released", "remaining_sessions": remaining}, nil
+	case s.matches(path, s.endpoints.SeatUsage, seatUsagePath):
+		return statusOK, s.usage(), nil
+	case s.matches(path, s.endpoints.RenewalPreview, renewalPreviewPath):
+		status, resp := s.renewalPreview(token)
+		return status, resp, nil
+	case s.matches(path, s.endpoints.Renew, renewPath):
+		status, resp := s.renew(token)
+		return status, resp, nil
+	case s.matches(path, s.endpoints.Product, productPath):
+		status, resp := s.product(token)
+		return status, resp, nil
+	case s.matches(path, s.endpoints.AuditAnchor, auditAnchorPath):
+		return statusOK, nil, nil
+	case s.matches(path, s.endpoints.Health, healthPath):
+		return statusOK, nil, nil
+	}
+	return statusNotFound, map[string]string{"status": "not_found"}, nil
+}
+
+func (s *simulatedServer) matches(path, override, defaultPath string) bool {
+	if override != "" {
+		return path == override
+	}
+	return strings.HasSuffix(path, defaultPath)
+}
+
+func (s *simulatedServer) heartbeat(instanceID string, features []string) (int, interface{}) {
+	if s.scenario.Revoked {
+		return statusForbidden, HeartbeatStatus{Status: heartbeatStatusRevoked}
+	}
+	if s.scenario.RegionRestricted {
+		return statusForbidden, HeartbeatStatus{Status: heartbeatStatusRegionRestricted}
+	}
+	if s.scenario.Suspended {
+		return statusForbidden, HeartbeatStatus{Status: heartbeatStatusSuspended, Reason: "simulated suspension"}
+	}
+
+	s.mu.Lock()
+	_, known := s.instances[instanceID]
+	if !known && s.scenario.MaxSeats > 0 && len(s.instances) >= s.scenario.MaxSeats {
+		s.mu.Unlock()
+		status := s.usage()
+		return statusTooManyRequests, HeartbeatStatus{
+			Status:            "seat_limit_reached",
+			ActiveSessions:    status.ActiveSessions,
+			MaxSessions:       status.MaxSessions,
+			RemainingSessions: 0,
+		}
+	}
+	for _, feature := range features {
+		limit, limited := s.scenario.FeatureSeats[feature]
+		_, held := s.pools[feature][instanceID]
+		if limited && !held && len(s.pools[feature]) >= limit {
+			s.mu.Unlock()
+			pool := SeatPool{Feature: feature, ActiveSessions: limit, MaxSessions: limit}
+			return statusTooManyRequests, HeartbeatStatus{
+				Status: "seat_limit_reached",
+				Pools:  []SeatPool{pool},
+			}
+		}
+	}
+	s.instances[instanceID] = time.Now()
+	for _, feature := range features {
+		if s.pools[feature] == nil {
+			s.pools[feature] = make(map[string]time.Time)
+		}
+		s.pools[feature][instanceID] = time.Now()
+	}
+	s.mu.Unlock()
+
+	usage := s.usage()
+	return statusOK, HeartbeatStatus{
+		Status:            "ok",
+		ActiveSessions:    usage.ActiveSessions,
+		MaxSessions:       usage.MaxSessions,
+		RemainingSessions: usage.RemainingSessions,
+		HeartbeatInterval: s.scenario.HeartbeatInterval,
+		ClientIP:          s.scenario.ClientIP,
+		Pools:             usage.Pools,
+	}
+}
+
+func (s *simulatedServer) usage() SeatUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	active := len(s.instances)
+
+	usage := SeatUsage{ActiveSessions: active, MaxSessions: s.scenario.MaxSeats}
+	if s.scenario.MaxSeats > 0 {
+		usage.RemainingSessions = s.scenario.MaxSeats - active
+	}
+	for feature, limit := range s.scenario.FeatureSeats {
+		held := len(s.pools[feature])
+		usage.Pools = append(usage.Pools, SeatPool{Feature: feature, ActiveSessions: held, MaxSessions: limit, RemainingSessions: limit - held})
+	}
+	sort.Slice(usage.Pools, func(i, j int) bool { return usage.Pools[i].Feature < usage.Pools[j].Feature })
+	return usage
+}
+
+func (s *simulatedServer) renew(token string) (int, interface{}) {
+	if s.scenario.Revoked {
+		return statusForbidden, RenewalResult{Status: heartbeatStatusRevoked}
+	}
+	if s.scenario.Suspended {
+		return statusForbidden, RenewalResult{Status: heartbeatStatusSuspended, Reason: "simulated suspension"}
+	}
+	if s.scenario.RenewalStatus != 0 && s.scenario.RenewalStatus != statusOK {
+		return s.scenario.RenewalStatus, RenewalResult{Status: "denied"}
+	}
+
+	result := RenewalResult{Status: "renewed", SignedToken: token}
+	if s.scenario.SigningKey != nil {
+		if payload, err := decodeTokenPayload(token); err == nil {
+			period := s.scenario.RenewalPeriod
+			if period == 0 {
+				period = 30 * 24 * time.Hour
+			}
+			result.PreviousExpiresAt = payload.ExpiresAt.Format(time.RFC3339)
+			payload.IssuedAt = time.Now().UTC()
+			payload.ExpiresAt = payload.IssuedAt.Add(period)
+			if data, err := json.Marshal(payload); err == nil {
+				signed := append(ed25519.Sign(s.scenario.SigningKey, data), data...)
+				result.SignedToken = base64.StdEncoding.EncodeToString(signed)
+				result.IssuedAt = payload.IssuedAt.Format(time.RFC3339)
+				result.ExpiresAt = payload.ExpiresAt.Format(time.RFC3339)
+			}
+		}
+	}
+	return statusOK, result
+}
+
+func (s *simulatedServer) renewalPreview(token string) (int, interface{}) {
+	preview := RenewalPreview{Eligible: true}
+	switch {
+	case s.scenario.Revoked:
+		preview = RenewalPreview{Reason: heartbeatStatusRevoked}
+	case s.scenario.RenewalStatus != 0 && s.scenario.RenewalStatus != statusOK:
+		preview = RenewalPreview{Reason: "denied"}
+	}
+	if payload, err := decodeTokenPayload(token); err == nil {
+		period := s.scenario.RenewalPeriod
+		if period == 0 {
+			period = 30 * 24 * time.Hour
+		}
+		preview.Plan = payload.Plan
+		preview.PreviousExpiresAt = payload.ExpiresAt
+		if preview.Eligible {
+			preview.ExpiresAt = time.Now().UTC().Add(period)
+		}
+	}
+	return statusOK, preview
+}
+
+func (s *simulatedServer) product(token string) (int, interface{}) {
+	if s.scenario.Product != nil {
+		return statusOK, s.scenario.Product
+	}
+	payload, err := decodeTokenPayload(token)
+	if err != nil {
+		return statusBadRequest, map[string]string{"status": "invalid_token"}
+	}
+	return statusOK, ProductInfo{
+		ProductID: payload.ProductID,
+		Name:      payload.ProductID,
+		Plans:     []PlanInfo{{ID: payload.Plan, Name: payload.Plan, Features: payload.Features, MaxSeats: payload.MaxSeats}},
+	}
+}
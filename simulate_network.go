@@ -0,0 +1,49 @@
+//go:build !licenseedict_nonetwork
+
+package licenseedict
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// RoundTrip implements http.RoundTripper, so the simulated server can stand
+// in for the transport and still pass through the configured middleware.
+func (s *simulatedServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var data []byte
+	if req.Body != nil {
+		var err error
+		data, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	status, body, err := s.serve(req.Context(), req.URL.Path, data)
+	if err != nil {
+		return nil, err
+	}
+	return simulatedResponse(req, status, body)
+}
+
+func simulatedResponse(req *http.Request, status int, body interface{}) (*http.Response, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+		Request:    req,
+	}, nil
+}
@@ -0,0 +1,198 @@
+package licenseedict
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultSiteReportInterval is how often a site-licensed client reports its
+// host to the server.
+const defaultSiteReportInterval = 24 * time.Hour
+
+// HostReport is the server's acknowledgement of a host report.
+type HostReport struct {
+	// DistinctHosts is the number of distinct hosts reported for the license
+	// in the current period.
+	DistinctHosts int       `json:"distinct_hosts"`
+	PeriodStart   time.Time `json:"period_start"`
+	PeriodEnd     time.Time `json:"period_end"`
+}
+
+// SiteUsagePeriod is the distinct host count for one reporting period, as
+// used for true-up audits.
+type SiteUsagePeriod struct {
+	PeriodStart   time.Time `json:"period_start"`
+	PeriodEnd     time.Time `json:"period_end"`
+	DistinctHosts int       `json:"distinct_hosts"`
+	// LicensedHosts is the host count the site license was purchased for,
+	// if the server tracks it.
+	LicensedHosts int `json:"licensed_hosts,omitempty"`
+}
+
+// siteReporter runs periodic host reports for site licenses.
+type siteReporter struct {
+	once   sync.Once
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// hostID identifies the current host for ReportHosts by its machine ID or,
+// if that is unknown, by all of its fingerprint components.
+func (c *Client) hostID() string {
+	fp := c.hostFingerprint()
+	if id := fp[FingerprintMachineID]; id != "" {
+		return id
+	}
+	names := make([]string, 0, len(fp))
+	for name := range fp {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + fp[name]
+	}
+	return strings.Join(parts, ",")
+}
+
+// ReportHosts reports hosts in use under a site license. Host identifiers
+// are hashed with the license ID before they leave the process, so the
+// server can count distinct hosts without learning their names. Without
+// arguments, the current host's fingerprint is reported; it does not depend
+// on the instance ID, so processes sharing a host count as one host.
+func (c *Client) ReportHosts(ctx context.Context, hostIDs ...string) (*HostReport, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if err := c.requireServer(); err != nil {
+		return nil, err
+	}
+	token := c.currentToken()
+	if token == "" {
+		return nil, ErrNoToken
+	}
+	if len(hostIDs) == 0 {
+		hostIDs = []string{c.hostID()}
+	}
+
+	licenseID := ""
+	if license := c.License(); license != nil {
+		licenseID = license.LicenseID
+	}
+	hashes := make([]string, 0, len(hostIDs))
+	for _, id := range hostIDs {
+		sum := sha256.Sum256([]byte(licenseID + ":" + id))
+		hashes = append(hashes, hex.EncodeToString(sum[:]))
+	}
+
+	body := map[string]interface{}{
+		"signed_token": token,
+		"host_hashes":  hashes,
+	}
+
+	var report HostReport
+	res, err := c.callServer(ctx, methodPost, c.cfg.endpoints.SiteReport, siteReportPath, body, &report)
+	if err != nil {
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: "host report request failed", Err: err})
+	}
+	if res.StatusCode != statusOK {
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: fmt.Sprintf("host report returned status %d", res.StatusCode)})
+	}
+	return &report, nil
+}
+
+// SiteUsage returns the distinct host counts per reporting period for the
+// current site license, for true-up audits.
+func (c *Client) SiteUsage(ctx context.Context) ([]SiteUsagePeriod, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if err := c.requireServer(); err != nil {
+		return nil, err
+	}
+	token := c.currentToken()
+	if token == "" {
+		return nil, ErrNoToken
+	}
+
+	body := map[string]string{
+		"signed_token": token,
+	}
+
+	var resp struct {
+		Periods []SiteUsagePeriod `json:"periods"`
+	}
+	res, err := c.callServer(ctx, methodPost, c.cfg.endpoints.SiteUsage, siteUsagePath, body, &resp)
+	if err != nil {
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: "site usage request failed", Err: err})
+	}
+	if res.StatusCode != statusOK {
+		return nil, res.annotate(&ValidationError{Code: ServerUnreachable, Message: fmt.Sprintf("site usage returned status %d", res.StatusCode)})
+	}
+	return resp.Periods, nil
+}
+
+// maybeStartSiteReporting starts periodic host reports the first time a
+// valid site license is validated. Site licenses are not seat-enforced;
+// reports let the server count distinct hosts instead.
+func (c *Client) maybeStartSiteReporting(license *License) {
+	if license == nil || !license.Valid || license.LicenseModel != LicenseModelSite {
+		return
+	}
+	if c.cfg.offlineOnly || c.cfg.siteReportInterval < 0 || c.resolveServerURL() == "" {
+		return
+	}
+	c.site.once.Do(func() {
+		interval := c.cfg.siteReportInterval
+		if interval == 0 {
+			interval = defaultSiteReportInterval
+		}
+		c.site.stopCh = make(chan struct{})
+		c.site.doneCh = make(chan struct{})
+		go c.siteReportLoop(interval, c.site.stopCh, c.site.doneCh)
+	})
+}
+
+func (c *Client) stopSiteReporting() {
+	c.site.once.Do(func() {})
+	if c.site.stopCh == nil {
+		return
+	}
+	close(c.site.stopCh)
+	<-c.site.doneCh
+}
+
+func (c *Client) siteReportLoop(interval time.Duration, stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if !c.deferForQuietHours("host report") {
+			if _, err := c.ReportHosts(ctx); err != nil && ctx.Err() == nil {
+				c.hookError(err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
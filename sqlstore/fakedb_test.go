@@ -0,0 +1,235 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDB is an in-memory database/sql driver that understands exactly the
+// statements Store issues. It checks that every statement uses the
+// placeholder style the Store was configured with, numbered as the
+// arguments are.
+type fakeDB struct {
+	t      *testing.T
+	table  string
+	dollar bool
+
+	mu   sync.Mutex
+	rows map[[2]string][]driver.Value
+	// failNext, if set, fails the next statement whose SQL contains it.
+	failNext string
+}
+
+// Column indexes of a row.
+const (
+	colTenant = iota
+	colLicense
+	colProduct
+	colToken
+	colExpires
+	colValid
+	colLastError
+	colValidated
+	colRevoked
+	colRevokedAt
+	colReason
+	colUpdated
+)
+
+var columns = []string{"tenant_id", "license_id", "product_id", "token", "expires_at", "valid", "last_error",
+	"last_validated_at", "revoked", "revoked_at", "revocation_reason", "updated_at"}
+
+var dollarParam = regexp.MustCompile(`\$\d+`)
+
+// openFakeDB returns a database backed by a new fakeDB.
+func openFakeDB(t *testing.T, table string, dollar bool) (*sql.DB, *fakeDB) {
+	f := &fakeDB{t: t, table: table, dollar: dollar, rows: make(map[[2]string][]driver.Value)}
+	db := sql.OpenDB(f)
+	t.Cleanup(func() { db.Close() })
+	return db, f
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return f }
+func (f *fakeDB) Open(string) (driver.Conn, error)             { return fakeConn{f}, nil }
+
+// exec runs query, returning the selected rows for queries.
+func (f *fakeDB) exec(query string, args []driver.Value) (driver.Result, driver.Rows, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.checkPlaceholders(query, len(args))
+	if f.failNext != "" && strings.Contains(query, f.failNext) {
+		f.failNext = ""
+		return nil, nil, errors.New("fakedb: injected failure")
+	}
+	q := strings.Join(strings.Fields(dollarParam.ReplaceAllString(query, "?")), " ")
+	if !strings.HasPrefix(q, "CREATE") && !strings.Contains(q, " "+f.table+" ") && !strings.HasSuffix(q, " "+f.table) {
+		f.t.Errorf("statement does not use table %s: %s", f.table, q)
+	}
+	key := func(i int) [2]string { return [2]string{args[i].(string), args[i+1].(string)} }
+
+	switch {
+	case strings.HasPrefix(q, "CREATE TABLE IF NOT EXISTS "+f.table+" ("):
+		return driver.RowsAffected(0), nil, nil
+	case strings.HasPrefix(q, "SELECT revoked FROM"):
+		row, ok := f.rows[key(0)]
+		if !ok {
+			return nil, &fakeRows{cols: []string{"revoked"}}, nil
+		}
+		return nil, &fakeRows{cols: []string{"revoked"}, rows: [][]driver.Value{{row[colRevoked]}}}, nil
+	case strings.HasPrefix(q, "SELECT tenant_id"):
+		var out [][]driver.Value
+		for k, row := range f.rows {
+			switch {
+			case strings.HasSuffix(q, "WHERE tenant_id = ? AND license_id = ?"):
+				if k != key(0) {
+					continue
+				}
+			case strings.HasSuffix(q, "WHERE tenant_id = ? ORDER BY license_id"):
+				if k[0] != args[0] {
+					continue
+				}
+			case strings.HasSuffix(q, "ORDER BY tenant_id, license_id"):
+			default:
+				f.t.Errorf("unexpected query: %s", q)
+			}
+			out = append(out, append([]driver.Value(nil), row...))
+		}
+		sort.Slice(out, func(i, j int) bool {
+			if out[i][colTenant] != out[j][colTenant] {
+				return out[i][colTenant].(string) < out[j][colTenant].(string)
+			}
+			return out[i][colLicense].(string) < out[j][colLicense].(string)
+		})
+		return nil, &fakeRows{cols: columns, rows: out}, nil
+	case strings.HasPrefix(q, "INSERT INTO"):
+		k := key(0)
+		if _, ok := f.rows[k]; ok {
+			return nil, nil, errors.New("fakedb: duplicate key")
+		}
+		f.rows[k] = []driver.Value{args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7],
+			int64(0), int64(0), "", args[8]}
+		return driver.RowsAffected(1), nil, nil
+	case strings.HasPrefix(q, "UPDATE "+f.table+" SET product_id"):
+		return f.update(key(7), func(row []driver.Value) {
+			copy(row[colProduct:colValidated+1], args[:6])
+			row[colUpdated] = args[6]
+		}), nil, nil
+	case strings.HasPrefix(q, "UPDATE "+f.table+" SET revoked = 1"):
+		if !strings.HasSuffix(q, "AND revoked = 0") {
+			f.t.Errorf("revoke does not skip revoked licenses: %s", q)
+		}
+		row, ok := f.rows[key(3)]
+		if !ok || row[colRevoked].(int64) != 0 {
+			return driver.RowsAffected(0), nil, nil
+		}
+		return f.update(key(3), func(row []driver.Value) {
+			row[colRevoked], row[colRevokedAt], row[colReason] = int64(1), args[0], args[1]
+			row[colValid], row[colUpdated] = int64(0), args[2]
+		}), nil, nil
+	case strings.HasPrefix(q, "UPDATE "+f.table+" SET valid = ?"):
+		return f.update(key(3), func(row []driver.Value) {
+			row[colValid], row[colLastError], row[colValidated] = args[0], args[1], args[2]
+		}), nil, nil
+	case strings.HasPrefix(q, "DELETE FROM"):
+		if _, ok := f.rows[key(0)]; !ok {
+			return driver.RowsAffected(0), nil, nil
+		}
+		delete(f.rows, key(0))
+		return driver.RowsAffected(1), nil, nil
+	}
+	f.t.Errorf("unexpected statement: %s", q)
+	return nil, nil, fmt.Errorf("fakedb: unexpected statement %q", q)
+}
+
+func (f *fakeDB) update(k [2]string, fn func([]driver.Value)) driver.Result {
+	row, ok := f.rows[k]
+	if !ok {
+		return driver.RowsAffected(0)
+	}
+	fn(row)
+	return driver.RowsAffected(1)
+}
+
+// checkPlaceholders reports a statement whose placeholders are not in the
+// configured style or do not match the number of arguments.
+func (f *fakeDB) checkPlaceholders(query string, args int) {
+	questions := strings.Count(query, "?")
+	dollars := dollarParam.FindAllString(query, -1)
+	if !f.dollar {
+		if len(dollars) > 0 || questions != args {
+			f.t.Errorf("want %d ? placeholders: %s", args, query)
+		}
+		return
+	}
+	if questions > 0 || len(dollars) != args {
+		f.t.Errorf("want %d $n placeholders: %s", args, query)
+		return
+	}
+	for i, d := range dollars {
+		if d != fmt.Sprintf("$%d", i+1) {
+			f.t.Errorf("placeholder %s at position %d: %s", d, i+1, query)
+		}
+	}
+}
+
+type fakeConn struct{ f *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.f, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+// fakeTx does not isolate anything; Store's transactions are only tested
+// for the statements they issue.
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	f     *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	res, _, err := s.f.exec(s.query, args)
+	return res, err
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	_, rows, err := s.f.exec(s.query, args)
+	if err == nil && rows == nil {
+		err = fmt.Errorf("fakedb: %q is not a query", s.query)
+	}
+	return rows, err
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,464 @@
+// Package sqlstore keeps customer licenses in a SQL database, for SaaS
+// backends that validate licenses for many tenants. It records each
+// tenant's license tokens, when they were last validated and with what
+// result, and whether they were revoked, and provides Reconcile for a
+// periodic job that revalidates them all.
+//
+// The package uses only database/sql; register a driver in the application.
+// The schema sticks to portable types, so it works with PostgreSQL, MySQL
+// and SQLite:
+//
+//	db, _ := sql.Open("pgx", dsn)
+//	v, _ := licenseedict.NewValidator(publicKeyB64)
+//	store := sqlstore.New(db, v, sqlstore.WithDollarPlaceholders())
+//	if err := store.CreateSchema(ctx); err != nil { ... }
+//	rec, err := store.Put(ctx, "tenant-42", token)
+//	license, err := store.Validate(ctx, "tenant-42")
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
+)
+
+const defaultTable = "licenseedict_licenses"
+
+var (
+	// ErrNotFound is returned when a tenant or license has no stored record.
+	ErrNotFound = errors.New("sqlstore: license not found")
+	// ErrNoLicenseID is returned by Put for a token without a license ID,
+	// which could not be told apart from other such tokens of the tenant.
+	ErrNoLicenseID = errors.New("sqlstore: license has no license ID")
+)
+
+// Record is a stored license and the outcome of its last validation.
+type Record struct {
+	TenantID  string
+	LicenseID string
+	ProductID string
+	Token     string
+	// ExpiresAt is zero for licenses that do not expire.
+	ExpiresAt time.Time
+	// Valid and LastError are the result of the last validation, at
+	// LastValidatedAt.
+	Valid           bool
+	LastError       string
+	LastValidatedAt time.Time
+	// Revoked is set by Store.Revoke or by Reconcile.
+	Revoked          bool
+	RevokedAt        time.Time
+	RevocationReason string
+	UpdatedAt        time.Time
+}
+
+// Store persists licenses in a table of a SQL database. It is safe for
+// concurrent use.
+type Store struct {
+	db        *sql.DB
+	validator *licenseedict.Validator
+	table     string
+	dollar    bool
+	now       func() time.Time
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithTable sets the table name. The default is "licenseedict_licenses". The
+// name is used in statements as is and must not come from user input.
+func WithTable(name string) Option {
+	return func(s *Store) {
+		s.table = name
+	}
+}
+
+// WithDollarPlaceholders writes query parameters as $1, $2, ... as
+// PostgreSQL drivers require, instead of ?.
+func WithDollarPlaceholders() Option {
+	return func(s *Store) {
+		s.dollar = true
+	}
+}
+
+// WithClock sets the clock used for timestamps. It defaults to time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *Store) {
+		s.now = now
+	}
+}
+
+// New returns a Store that keeps licenses in db and validates them with v.
+func New(db *sql.DB, v *licenseedict.Validator, opts ...Option) *Store {
+	s := &Store{db: db, validator: v, table: defaultTable, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// CreateSchema creates the table if it does not exist.
+func (s *Store) CreateSchema(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+s.table+` (
+	tenant_id VARCHAR(255) NOT NULL,
+	license_id VARCHAR(255) NOT NULL,
+	product_id VARCHAR(255) NOT NULL,
+	token TEXT NOT NULL,
+	expires_at BIGINT NOT NULL,
+	valid INTEGER NOT NULL,
+	last_error TEXT NOT NULL,
+	last_validated_at BIGINT NOT NULL,
+	revoked INTEGER NOT NULL,
+	revoked_at BIGINT NOT NULL,
+	revocation_reason TEXT NOT NULL,
+	updated_at BIGINT NOT NULL,
+	PRIMARY KEY (tenant_id, license_id)
+)`)
+	if err != nil {
+		return fmt.Errorf("sqlstore: create schema: %w", err)
+	}
+	return nil
+}
+
+// Put validates token and stores it for tenantID, replacing any stored
+// token for the same license. Tokens that fail validation are stored too,
+// with Valid false, so that a license that is not valid yet can be added
+// ahead of time. Tokens whose signature cannot be verified, that have no
+// license ID, or that were issued to a tenant other than tenantID are
+// rejected. A revoked license stays revoked.
+func (s *Store) Put(ctx context.Context, tenantID, token string) (*Record, error) {
+	license, err := s.validator.Validate(token)
+	if license == nil {
+		return nil, fmt.Errorf("sqlstore: put license for tenant %q: %w", tenantID, err)
+	}
+	if license.LicenseID == "" {
+		return nil, fmt.Errorf("sqlstore: put license for tenant %q: %w", tenantID, ErrNoLicenseID)
+	}
+	if license.TenantID != "" && license.TenantID != tenantID {
+		return nil, fmt.Errorf("sqlstore: put license for tenant %q: %w", tenantID, &licenseedict.ValidationError{
+			Code:    licenseedict.TenantMismatch,
+			Message: fmt.Sprintf("license is for tenant %q", license.TenantID),
+		})
+	}
+	now := s.now()
+	rec := &Record{
+		TenantID:        tenantID,
+		LicenseID:       license.LicenseID,
+		ProductID:       license.ProductID,
+		Token:           token,
+		ExpiresAt:       license.ExpiresAt,
+		Valid:           err == nil && license.Valid,
+		LastError:       errorString(err),
+		LastValidatedAt: now,
+		UpdatedAt:       now,
+	}
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		var revoked int
+		err := tx.QueryRowContext(ctx, s.bind(`SELECT revoked FROM `+s.table+` WHERE tenant_id = ? AND license_id = ?`),
+			rec.TenantID, rec.LicenseID).Scan(&revoked)
+		switch {
+		case err == nil:
+			if revoked != 0 {
+				rec.Valid = false
+			}
+			_, err = tx.ExecContext(ctx, s.bind(`UPDATE `+s.table+` SET product_id = ?, token = ?, expires_at = ?,
+	valid = ?, last_error = ?, last_validated_at = ?, updated_at = ? WHERE tenant_id = ? AND license_id = ?`),
+				rec.ProductID, rec.Token, unix(rec.ExpiresAt), boolInt(rec.Valid), rec.LastError,
+				unix(rec.LastValidatedAt), unix(rec.UpdatedAt), rec.TenantID, rec.LicenseID)
+			return err
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+		_, err = tx.ExecContext(ctx, s.bind(`INSERT INTO `+s.table+` (tenant_id, license_id, product_id, token,
+	expires_at, valid, last_error, last_validated_at, revoked, revoked_at, revocation_reason, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, 0, 0, '', ?)`),
+			rec.TenantID, rec.LicenseID, rec.ProductID, rec.Token, unix(rec.ExpiresAt), boolInt(rec.Valid),
+			rec.LastError, unix(rec.LastValidatedAt), unix(rec.UpdatedAt))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sqlstore: put license for tenant %q: %w", tenantID, err)
+	}
+	return s.get(ctx, tenantID, rec.LicenseID)
+}
+
+// Get returns the stored licenses of tenantID, or ErrNotFound if there are
+// none.
+func (s *Store) Get(ctx context.Context, tenantID string) ([]Record, error) {
+	recs, err := s.query(ctx, ` WHERE tenant_id = ? ORDER BY license_id`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 {
+		return nil, ErrNotFound
+	}
+	return recs, nil
+}
+
+// List returns all stored licenses, ordered by tenant and license ID.
+func (s *Store) List(ctx context.Context) ([]Record, error) {
+	return s.query(ctx, ` ORDER BY tenant_id, license_id`)
+}
+
+// Delete removes a license of tenantID. It returns ErrNotFound if there was
+// none.
+func (s *Store) Delete(ctx context.Context, tenantID, licenseID string) error {
+	res, err := s.db.ExecContext(ctx, s.bind(`DELETE FROM `+s.table+` WHERE tenant_id = ? AND license_id = ?`), tenantID, licenseID)
+	return s.affected(res, err, "delete", tenantID)
+}
+
+// Revoke marks a license of tenantID as revoked. Validate then rejects it
+// with LicenseRevoked, whatever its token says, until it is deleted.
+func (s *Store) Revoke(ctx context.Context, tenantID, licenseID, reason string) error {
+	now := unix(s.now())
+	res, err := s.db.ExecContext(ctx, s.bind(`UPDATE `+s.table+` SET revoked = 1, revoked_at = ?, revocation_reason = ?,
+	valid = 0, updated_at = ? WHERE tenant_id = ? AND license_id = ? AND revoked = 0`),
+		now, reason, now, tenantID, licenseID)
+	return s.affected(res, err, "revoke", tenantID)
+}
+
+// Validate revalidates the licenses of tenantID, records the results, and
+// returns the valid license that expires last. If none is valid, it returns
+// the error of the most recently updated license; a revoked license yields a
+// *licenseedict.ValidationError with code LicenseRevoked. It returns
+// ErrNotFound if the tenant has no licenses.
+func (s *Store) Validate(ctx context.Context, tenantID string) (*licenseedict.License, error) {
+	recs, err := s.Get(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	var best *licenseedict.License
+	var lastErr error
+	for i := range recs {
+		license, err := s.revalidate(ctx, &recs[i])
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if best == nil || expiresAfter(license, best) {
+			best = license
+		}
+	}
+	if best == nil {
+		return nil, lastErr
+	}
+	return best, nil
+}
+
+// revalidate validates rec's token and records the result.
+func (s *Store) revalidate(ctx context.Context, rec *Record) (*licenseedict.License, error) {
+	license, err := s.validator.Validate(rec.Token)
+	if err == nil && rec.Revoked {
+		err = &licenseedict.ValidationError{Code: licenseedict.LicenseRevoked, Message: "license revoked: " + rec.RevocationReason}
+	}
+	rec.Valid = err == nil && license != nil && license.Valid
+	rec.LastError = errorString(err)
+	rec.LastValidatedAt = s.now()
+	if _, dbErr := s.db.ExecContext(ctx, s.bind(`UPDATE `+s.table+` SET valid = ?, last_error = ?, last_validated_at = ?
+WHERE tenant_id = ? AND license_id = ?`),
+		boolInt(rec.Valid), rec.LastError, unix(rec.LastValidatedAt), rec.TenantID, rec.LicenseID); dbErr != nil {
+		return nil, fmt.Errorf("sqlstore: record validation for tenant %q: %w", rec.TenantID, dbErr)
+	}
+	return license, err
+}
+
+// ReconcileOptions configures Store.Reconcile.
+type ReconcileOptions struct {
+	// IsRevoked, if set, reports licenses revoked elsewhere, for example by
+	// a (*licenseedict.LicenseBundle).IsRevoked method value. Reconcile
+	// marks them revoked in the store.
+	IsRevoked func(licenseID string) bool
+	// Reason is stored for licenses revoked through IsRevoked.
+	Reason string
+}
+
+// ReconcileReport summarizes a Reconcile run.
+type ReconcileReport struct {
+	Checked int
+	Valid   int
+	Invalid int
+	// Revoked counts licenses that are revoked, NewlyRevoked those revoked
+	// by this run.
+	Revoked      int
+	NewlyRevoked int
+	// Errors holds database errors for individual licenses; validation
+	// failures are counted in Invalid instead.
+	Errors []error
+}
+
+// Reconcile revalidates every stored license and records the results, for a
+// periodic job that keeps the store's view current as licenses expire or are
+// revoked. It continues past errors for individual licenses, collecting them
+// in the report, and returns an error only if the licenses cannot be listed
+// or ctx is done.
+func (s *Store) Reconcile(ctx context.Context, opts ReconcileOptions) (*ReconcileReport, error) {
+	recs, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &ReconcileReport{}
+	for i := range recs {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		rec := &recs[i]
+		report.Checked++
+		if !rec.Revoked && opts.IsRevoked != nil && opts.IsRevoked(rec.LicenseID) {
+			if err := s.Revoke(ctx, rec.TenantID, rec.LicenseID, opts.Reason); err != nil && !errors.Is(err, ErrNotFound) {
+				report.Errors = append(report.Errors, err)
+				continue
+			}
+			rec.Revoked = true
+			rec.RevocationReason = opts.Reason
+			report.NewlyRevoked++
+		}
+		license, err := s.revalidate(ctx, rec)
+		var vErr *licenseedict.ValidationError
+		switch {
+		case rec.Revoked:
+			report.Revoked++
+		case err == nil && license.Valid:
+			report.Valid++
+		case err == nil, license != nil, errors.As(err, &vErr):
+			report.Invalid++
+		default:
+			report.Errors = append(report.Errors, err)
+		}
+	}
+	return report, nil
+}
+
+func (s *Store) get(ctx context.Context, tenantID, licenseID string) (*Record, error) {
+	recs, err := s.query(ctx, ` WHERE tenant_id = ? AND license_id = ?`, tenantID, licenseID)
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 {
+		return nil, ErrNotFound
+	}
+	return &recs[0], nil
+}
+
+// query selects records with the given WHERE and ORDER BY clauses.
+func (s *Store) query(ctx context.Context, clauses string, args ...any) ([]Record, error) {
+	rows, err := s.db.QueryContext(ctx, s.bind(`SELECT tenant_id, license_id, product_id, token, expires_at, valid,
+	last_error, last_validated_at, revoked, revoked_at, revocation_reason, updated_at FROM `+s.table+clauses), args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlstore: query licenses: %w", err)
+	}
+	defer rows.Close()
+
+	var recs []Record
+	for rows.Next() {
+		var rec Record
+		var expires, validated, revokedAt, updated int64
+		var valid, revoked int
+		if err := rows.Scan(&rec.TenantID, &rec.LicenseID, &rec.ProductID, &rec.Token, &expires, &valid,
+			&rec.LastError, &validated, &revoked, &revokedAt, &rec.RevocationReason, &updated); err != nil {
+			return nil, fmt.Errorf("sqlstore: query licenses: %w", err)
+		}
+		rec.ExpiresAt = fromUnix(expires)
+		rec.Valid = valid != 0
+		rec.LastValidatedAt = fromUnix(validated)
+		rec.Revoked = revoked != 0
+		rec.RevokedAt = fromUnix(revokedAt)
+		rec.UpdatedAt = fromUnix(updated)
+		recs = append(recs, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlstore: query licenses: %w", err)
+	}
+	return recs, nil
+}
+
+func (s *Store) inTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// affected turns the result of a single-row statement into ErrNotFound if
+// no row matched.
+func (s *Store) affected(res sql.Result, err error, op, tenantID string) error {
+	if err != nil {
+		return fmt.Errorf("sqlstore: %s license for tenant %q: %w", op, tenantID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("sqlstore: %s license for tenant %q: %w", op, tenantID, err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// bind rewrites ? placeholders as $n when WithDollarPlaceholders is set.
+// Statements here contain no other question marks.
+func (s *Store) bind(query string) string {
+	if !s.dollar {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func expiresAfter(a, b *licenseedict.License) bool {
+	switch {
+	case a.ExpiresAt.IsZero():
+		return !b.ExpiresAt.IsZero()
+	case b.ExpiresAt.IsZero():
+		return false
+	}
+	return a.ExpiresAt.After(b.ExpiresAt)
+}
+
+func unix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func fromUnix(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(n, 0).UTC()
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
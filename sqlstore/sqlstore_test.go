@@ -0,0 +1,255 @@
+package sqlstore
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK"
+	"github.com/Sage-Infrastructure-Solutions-Group-Inc/LicenseEdictSDK/issuer"
+)
+
+type testStore struct {
+	*Store
+	db  *fakeDB
+	iss *issuer.Issuer
+	now time.Time
+}
+
+// forEachDialect runs fn against a store with ? placeholders and one with
+// $n placeholders, both with a table set by WithTable.
+func forEachDialect(t *testing.T, fn func(t *testing.T, s *testStore)) {
+	for _, dollar := range []bool{false, true} {
+		t.Run(fmt.Sprintf("dollar=%v", dollar), func(t *testing.T) {
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			iss := issuer.NewFromKey(key)
+			v, err := licenseedict.NewValidator(iss.PublicKey())
+			if err != nil {
+				t.Fatal(err)
+			}
+			db, fake := openFakeDB(t, "test_licenses", dollar)
+			s := &testStore{db: fake, iss: iss, now: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+			opts := []Option{WithTable("test_licenses"), WithClock(func() time.Time { return s.now })}
+			if dollar {
+				opts = append(opts, WithDollarPlaceholders())
+			}
+			s.Store = New(db, v, opts...)
+			if err := s.CreateSchema(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			fn(t, s)
+		})
+	}
+}
+
+func (s *testStore) sign(t *testing.T, claims issuer.Claims) string {
+	t.Helper()
+	if claims.ProductID == "" {
+		claims.ProductID = "prod_1"
+	}
+	if claims.ExpiresAt.IsZero() {
+		claims.ExpiresAt = time.Now().Add(24 * time.Hour)
+	}
+	token, err := s.iss.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestBind(t *testing.T) {
+	const query = `UPDATE t SET a = ?, b = ? WHERE c = ?`
+	if got := (&Store{}).bind(query); got != query {
+		t.Fatalf("bind = %q, want the query unchanged", got)
+	}
+	if got, want := (&Store{dollar: true}).bind(query), `UPDATE t SET a = $1, b = $2 WHERE c = $3`; got != want {
+		t.Fatalf("bind with dollar placeholders = %q, want %q", got, want)
+	}
+}
+
+func TestPut(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *testStore) {
+		ctx := context.Background()
+		expires := time.Now().Add(time.Hour).Truncate(time.Second)
+		rec, err := s.Put(ctx, "tenant-a", s.sign(t, issuer.Claims{LicenseID: "lic_1", ExpiresAt: expires}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.TenantID != "tenant-a" || rec.LicenseID != "lic_1" || rec.ProductID != "prod_1" || !rec.Valid || rec.Revoked {
+			t.Fatalf("record %+v does not match the license", rec)
+		}
+		if !rec.ExpiresAt.Equal(expires) || !rec.UpdatedAt.Equal(s.now) || !rec.LastValidatedAt.Equal(s.now) {
+			t.Fatalf("record times: expires %v, updated %v, validated %v", rec.ExpiresAt, rec.UpdatedAt, rec.LastValidatedAt)
+		}
+
+		// A second token for the same license replaces the first, and an
+		// expired one is stored as not valid.
+		s.now = s.now.Add(time.Minute)
+		expired := s.sign(t, issuer.Claims{LicenseID: "lic_1", IssuedAt: time.Now().Add(-2 * time.Hour), ExpiresAt: time.Now().Add(-time.Hour)})
+		rec, err = s.Put(ctx, "tenant-a", expired)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.Token != expired || rec.Valid || rec.LastError == "" || !rec.UpdatedAt.Equal(s.now) {
+			t.Fatalf("replaced record %+v, want the expired token, not valid", rec)
+		}
+		if recs, err := s.Get(ctx, "tenant-a"); err != nil || len(recs) != 1 {
+			t.Fatalf("Get = %d records, %v, want 1", len(recs), err)
+		}
+	})
+}
+
+func TestPutRejects(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *testStore) {
+		ctx := context.Background()
+		if _, err := s.Put(ctx, "tenant-a", s.iss.SignPayload([]byte(`{"product_id":"prod_1"}`))); !errors.Is(err, ErrNoLicenseID) {
+			t.Fatalf("no license ID: err = %v, want ErrNoLicenseID", err)
+		}
+		other := s.sign(t, issuer.Claims{LicenseID: "lic_1", TenantID: "tenant-b"})
+		if _, err := s.Put(ctx, "tenant-a", other); !errors.Is(err, licenseedict.ErrTenantMismatch) {
+			t.Fatalf("other tenant: err = %v, want ErrTenantMismatch", err)
+		}
+		if _, err := s.Put(ctx, "tenant-a", "not a token"); err == nil {
+			t.Fatal("stored a malformed token")
+		}
+		if _, err := s.Get(ctx, "tenant-a"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Get: err = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *testStore) {
+		ctx := context.Background()
+		token := s.sign(t, issuer.Claims{LicenseID: "lic_1"})
+		if _, err := s.Put(ctx, "tenant-a", token); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Revoke(ctx, "tenant-a", "lic_1", "refunded"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Revoke(ctx, "tenant-a", "lic_1", "again"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("second Revoke: err = %v, want ErrNotFound", err)
+		}
+		if err := s.Revoke(ctx, "tenant-b", "lic_1", ""); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Revoke of another tenant: err = %v, want ErrNotFound", err)
+		}
+		if _, err := s.Validate(ctx, "tenant-a"); !errors.Is(err, licenseedict.ErrLicenseRevoked) {
+			t.Fatalf("Validate: err = %v, want ErrLicenseRevoked", err)
+		}
+
+		// Storing the token again keeps the license revoked.
+		rec, err := s.Put(ctx, "tenant-a", token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rec.Revoked || rec.Valid || rec.RevocationReason != "refunded" || !rec.RevokedAt.Equal(s.now) {
+			t.Fatalf("record %+v, want it to stay revoked", rec)
+		}
+
+		if err := s.Delete(ctx, "tenant-a", "lic_1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Delete(ctx, "tenant-a", "lic_1"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("second Delete: err = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *testStore) {
+		ctx := context.Background()
+		for _, c := range []issuer.Claims{
+			{LicenseID: "lic_1", ExpiresAt: time.Now().Add(time.Hour)},
+			{LicenseID: "lic_2", ExpiresAt: time.Now().Add(48 * time.Hour)},
+			{LicenseID: "lic_3", IssuedAt: time.Now().Add(-2 * time.Hour), ExpiresAt: time.Now().Add(-time.Hour)},
+		} {
+			if _, err := s.Put(ctx, "tenant-a", s.sign(t, c)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s.now = s.now.Add(time.Hour)
+		license, err := s.Validate(ctx, "tenant-a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if license.LicenseID != "lic_2" {
+			t.Fatalf("Validate returned %s, want the license that expires last", license.LicenseID)
+		}
+		recs, err := s.Get(ctx, "tenant-a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rec := range recs {
+			if want := rec.LicenseID != "lic_3"; rec.Valid != want || !rec.LastValidatedAt.Equal(s.now) {
+				t.Fatalf("%s: valid %v at %v, want %v at %v", rec.LicenseID, rec.Valid, rec.LastValidatedAt, want, s.now)
+			}
+		}
+		if _, err := s.Validate(ctx, "tenant-b"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("unknown tenant: err = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestReconcile(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, s *testStore) {
+		ctx := context.Background()
+		put := func(tenant string, c issuer.Claims) {
+			t.Helper()
+			if _, err := s.Put(ctx, tenant, s.sign(t, c)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		put("tenant-a", issuer.Claims{LicenseID: "lic_valid"})
+		put("tenant-a", issuer.Claims{LicenseID: "lic_expired", IssuedAt: time.Now().Add(-2 * time.Hour), ExpiresAt: time.Now().Add(-time.Hour)})
+		put("tenant-b", issuer.Claims{LicenseID: "lic_revoked"})
+		put("tenant-b", issuer.Claims{LicenseID: "lic_refunded"})
+		put("tenant-c", issuer.Claims{LicenseID: "lic_other"})
+		if err := s.Revoke(ctx, "tenant-b", "lic_revoked", "chargeback"); err != nil {
+			t.Fatal(err)
+		}
+
+		s.db.failNext = "SET valid ="
+		report, err := s.Reconcile(ctx, ReconcileOptions{
+			IsRevoked: func(id string) bool { return id == "lic_refunded" || id == "lic_revoked" },
+			Reason:    "refunded",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// List orders tenant-a before tenant-c, so the injected failure
+		// hits the first record: lic_expired.
+		want := ReconcileReport{Checked: 5, Valid: 2, Invalid: 0, Revoked: 2, NewlyRevoked: 1}
+		if report.Checked != want.Checked || report.Valid != want.Valid || report.Invalid != want.Invalid ||
+			report.Revoked != want.Revoked || report.NewlyRevoked != want.NewlyRevoked || len(report.Errors) != 1 {
+			t.Fatalf("report %+v, want %+v and one error", report, want)
+		}
+
+		recs, err := s.Get(ctx, "tenant-b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rec := range recs {
+			reason := map[string]string{"lic_revoked": "chargeback", "lic_refunded": "refunded"}[rec.LicenseID]
+			if !rec.Revoked || rec.Valid || rec.RevocationReason != reason {
+				t.Fatalf("%s: revoked %v, valid %v, reason %q, want revoked for %q", rec.LicenseID, rec.Revoked, rec.Valid, rec.RevocationReason, reason)
+			}
+		}
+
+		// Without the failure every license is accounted for.
+		report, err = s.Reconcile(ctx, ReconcileOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Checked != 5 || report.Valid != 2 || report.Invalid != 1 || report.Revoked != 2 || report.NewlyRevoked != 0 || len(report.Errors) != 0 {
+			t.Fatalf("second report %+v", report)
+		}
+	})
+}
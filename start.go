@@ -0,0 +1,152 @@
+package licenseedict
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// defaultWatchdogInterval is how often the watchdog started by Start
+// rechecks the license when StartOptions.WatchdogInterval is zero.
+const defaultWatchdogInterval = time.Hour
+
+// StartOptions configures Client.Start.
+type StartOptions struct {
+	// Token is the token to validate. It defaults to the configured token.
+	Token string
+	// Heartbeat acquires a seat with an initial heartbeat and keeps it with
+	// the background heartbeat, as StartHeartbeat does.
+	Heartbeat        bool
+	HeartbeatOptions HeartbeatOptions
+	// RequiredFeatures are features the license must include; Start fails
+	// with a *MissingFeaturesError otherwise.
+	RequiredFeatures []string
+	// WatchdogInterval is how often the license is rechecked locally, so
+	// that expiry is noticed and auto-renewal triggered even without
+	// heartbeats. The default is one hour; a negative value disables the
+	// watchdog.
+	WatchdogInterval time.Duration
+}
+
+// Status is the outcome of Client.Start.
+type Status struct {
+	License *License `json:"license"`
+	// Heartbeat is the server's response to the initial heartbeat. It is nil
+	// if heartbeats were not requested or the server could not be reached.
+	Heartbeat *HeartbeatStatus `json:"heartbeat,omitempty"`
+	// Health is the license health once startup completed. A server that
+	// could not be reached does not fail Start; it is reported here as
+	// HealthDegraded.
+	Health HealthStatus `json:"health"`
+}
+
+// watchdog periodically revalidates the license after Start.
+type watchdog struct {
+	once   sync.Once
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// Start runs the usual startup sequence in one call: it validates the
+// token, checks the required features, acquires a seat and starts the
+// heartbeat if requested, and starts the watchdog that keeps the license
+// checked and auto-renewed. It returns the resulting Status and a single
+// error for anything that should stop the application from starting, such
+// as an invalid license or a reached seat limit; background tasks started
+// before the failure are stopped again.
+//
+// A heartbeat that Validate started for a floating license is restarted with
+// opts.HeartbeatOptions. If the caller already started one, Start fails with
+// ErrAlreadyRunning.
+//
+// ctx bounds the startup calls only; the heartbeat runs until Checkout,
+// StopHeartbeat or Close, and the watchdog until Close.
+func (c *Client) Start(ctx context.Context, opts StartOptions) (*Status, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+
+	license, err := c.Validate(opts.Token)
+	status := &Status{License: license}
+	if err != nil {
+		return status, err
+	}
+	if license.IsExpired() {
+		return status, &ValidationError{Code: LicenseNotValidAfter, Message: "license has expired"}
+	}
+	if !license.Valid {
+		return status, ErrLicenseInvalid
+	}
+	if len(opts.RequiredFeatures) > 0 {
+		if err := c.RequireFeatures(opts.RequiredFeatures...); err != nil {
+			return status, err
+		}
+	}
+
+	if opts.Heartbeat {
+		first := make(chan error, 1)
+		if _, err := c.startHeartbeat(context.WithoutCancel(ctx), first, false, opts.HeartbeatOptions); err != nil {
+			return status, err
+		}
+		select {
+		case err = <-first:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		var vErr *ValidationError
+		if err != nil && !(errors.As(err, &vErr) && vErr.Code == ServerUnreachable) {
+			c.StopHeartbeat()
+			return status, err
+		}
+		status.Heartbeat, _ = c.LastHeartbeat()
+	}
+
+	c.startWatchdog(opts.WatchdogInterval)
+	status.License = c.License()
+	status.Health = c.HealthCheck(ctx)
+	return status, nil
+}
+
+func (c *Client) startWatchdog(interval time.Duration) {
+	if interval < 0 {
+		return
+	}
+	if interval == 0 {
+		interval = defaultWatchdogInterval
+	}
+	c.watchdog.once.Do(func() {
+		c.watchdog.stopCh = make(chan struct{})
+		c.watchdog.doneCh = make(chan struct{})
+		go c.watchdogLoop(interval, c.watchdog.stopCh, c.watchdog.doneCh)
+	})
+}
+
+func (c *Client) stopWatchdog() {
+	c.watchdog.once.Do(func() {})
+	if c.watchdog.stopCh == nil {
+		return
+	}
+	close(c.watchdog.stopCh)
+	<-c.watchdog.doneCh
+}
+
+// watchdogLoop revalidates the current token on every tick. Validation is
+// local; it refreshes the license's validity, runs the validation hooks and
+// triggers auto-renewal when expiry approaches.
+func (c *Client) watchdogLoop(interval time.Duration, stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			if _, err := c.Validate(); err != nil && !errors.Is(err, ErrClientClosed) {
+				c.hookError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package licenseedict
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// stateSnapshotVersion is the current SnapshotState format. Version 1
+// snapshots carried the decoded license and are no longer accepted.
+const stateSnapshotVersion = 2
+
+// ErrInvalidState is returned by RestoreState for data that is not a usable
+// snapshot: malformed or from a different SDK version.
+var ErrInvalidState = errors.New("licenseedict: invalid state snapshot")
+
+// stateSnapshot is the serialized form produced by SnapshotState.
+type stateSnapshot struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Token is the signed token of the license. The license is rebuilt
+	// from it on restore, so the snapshot carries nothing that is not
+	// covered by the signature.
+	Token            string           `json:"token"`
+	TelemetryConsent TelemetryConsent `json:"telemetry_consent,omitempty"`
+}
+
+// SnapshotState serializes the client's license state, for serverless
+// functions that keep it between invocations, for example in a global
+// variable, an environment variable or a warm-start cache. Pass the result
+// to RestoreState in a later invocation to skip the cache IO and fallback
+// logic of Validate. It returns ErrNoToken if no license has been validated
+// and ErrLicenseInvalid if the current license is not valid.
+//
+// The snapshot carries the signed token and the telemetry consent. Unsent
+// usage is not included, so restoring one snapshot in many invocations
+// cannot report it twice. Set WithInstanceID if seats should be tracked
+// under a stable ID across cold starts.
+func (c *Client) SnapshotState() ([]byte, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if c.cfg.publicKey == nil {
+		return nil, ErrNoPublicKey
+	}
+	license := c.License()
+	if license == nil || license.SignedToken == "" {
+		return nil, ErrNoToken
+	}
+	if !license.Valid {
+		return nil, ErrLicenseInvalid
+	}
+	return json.Marshal(stateSnapshot{
+		Version:          stateSnapshotVersion,
+		CreatedAt:        time.Now(),
+		Token:            license.SignedToken,
+		TelemetryConsent: c.cache.storedConsent(),
+	})
+}
+
+// RestoreState installs state produced by SnapshotState as the current
+// license without touching the cache, and returns the license. Call it
+// before using the client, then use License, RequireFeatures and feature
+// gates as usual.
+//
+// The license is rebuilt from the snapshot's token, which is verified with
+// the configured public key and checked against the same temporal, policy
+// and offline rules as SetToken, so an edited snapshot grants nothing. A
+// license that fails them is not installed and is returned with a
+// *ValidationError, so the caller can fall back to Validate. Malformed
+// snapshots, and snapshots from an earlier format, return an error wrapping
+// ErrInvalidState.
+func (c *Client) RestoreState(data []byte) (*License, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if c.cfg.publicKey == nil {
+		return nil, ErrNoPublicKey
+	}
+
+	var snap stateSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidState, err)
+	}
+	switch {
+	case snap.Version != stateSnapshotVersion:
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidState, snap.Version)
+	case snap.Token == "":
+		return nil, fmt.Errorf("%w: no token", ErrInvalidState)
+	}
+
+	license, err := c.checkToken(snap.Token)
+	if err != nil {
+		return license, err
+	}
+	license.Suspended = c.suspended.Load()
+
+	c.mu.Lock()
+	if c.tokenServerURL == "" && license.ServerURL != "" {
+		c.tokenServerURL = license.ServerURL
+	}
+	c.license = license
+	c.signedToken = snap.Token
+	c.mu.Unlock()
+	c.updateGates(license)
+
+	if snap.TelemetryConsent.valid() && c.cache.storedConsent() == "" {
+		c.cache.adoptConsent(snap.TelemetryConsent)
+	}
+	return license, nil
+}
@@ -0,0 +1,144 @@
+package licenseedict
+
+import (
+	"context"
+	"errors"
+)
+
+// Exit codes recommended for command-line tools that fail on a license
+// error, so scripts can tell the causes apart consistently across products.
+// The values follow the BSD sysexits convention where one applies; 2 is left
+// for command-line usage errors.
+const (
+	ExitOK = 0
+	// ExitError is a failure not covered by a more specific code.
+	ExitError = 1
+	// ExitNoLicense means no license token was provided.
+	ExitNoLicense = 3
+	// ExitLicenseInvalid means the token is malformed, has a bad signature,
+	// or is for a different product, application, tenant or host.
+	ExitLicenseInvalid = 4
+	// ExitLicenseExpired means the license is expired, not yet valid, or
+	// does not cover this build.
+	ExitLicenseExpired = 5
+	// ExitLicenseRevoked means the license was revoked or suspended, or must
+	// be reactivated.
+	ExitLicenseRevoked = 6
+	// ExitFeatureMissing means the license lacks a required feature.
+	ExitFeatureMissing = 7
+	// ExitUnavailable (EX_UNAVAILABLE) means the license server could not be
+	// reached or a renewal failed.
+	ExitUnavailable = 69
+	// ExitTempFail (EX_TEMPFAIL) means no seat is free; retrying later may
+	// succeed.
+	ExitTempFail = 75
+	// ExitNoPerm (EX_NOPERM) means the environment is not permitted, such as
+	// a restricted region, a virtual machine, or a rejected attestation.
+	ExitNoPerm = 77
+	// ExitConfig (EX_CONFIG) means the SDK is misconfigured, for example a
+	// missing or malformed public key or environment variable.
+	ExitConfig = 78
+)
+
+// HTTPStatus returns the HTTP status a backend should respond with when a
+// request fails because of err, for services that gate requests on a
+// license. It returns 200 for a nil error and 500 for errors it does not
+// recognize:
+//
+//   - 401 Unauthorized: no token, or a malformed or forged token
+//   - 402 Payment Required: the license expired or does not cover this build
+//   - 403 Forbidden: revoked, suspended, mismatched or restricted licenses,
+//     and missing features
+//   - 429 Too Many Requests: the seat limit was reached
+//   - 502 Bad Gateway: the license server refused a renewal
+//   - 503 Service Unavailable: the license server is unreachable
+//   - 500 Internal Server Error: the SDK is misconfigured
+func HTTPStatus(err error) int {
+	if err == nil {
+		return statusOK
+	}
+	var vErr *ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		return vErr.HTTPStatus()
+	case errors.Is(err, ErrMissingFeatures), errors.Is(err, ErrInvalidLicenseKey):
+		return statusForbidden
+	case errors.Is(err, ErrNoToken):
+		return statusUnauthorized
+	case errors.Is(err, ErrServerUnreachable), errors.Is(err, context.DeadlineExceeded):
+		return statusServiceUnavailable
+	}
+	return statusInternalServerError
+}
+
+// HTTPStatus returns the recommended HTTP status for the error's code. See
+// HTTPStatus.
+func (e *ValidationError) HTTPStatus() int {
+	switch e.Code {
+	case LicenseDecodeError, InvalidLicenseSignature:
+		return statusUnauthorized
+	case LicenseNotValidAfter, MaintenanceExpired:
+		return statusPaymentRequired
+	case LicenseNotValidBefore, LicenseRevoked, LicenseSuspended, ReactivationRequired,
+		ProductMismatch, AppMismatch, TenantMismatch, FingerprintMismatch,
+		RegionRestricted, VirtualizedEnvironment, AttestationRejected:
+		return statusForbidden
+	case SeatLimitReached:
+		return statusTooManyRequests
+	case RenewalFailed, RequestRejected:
+		return statusBadGateway
+	case ServerUnreachable:
+		return statusServiceUnavailable
+	}
+	return statusInternalServerError
+}
+
+// ExitCode returns the process exit code a command-line tool should use when
+// it fails because of err: one of the Exit* constants. It returns ExitOK for
+// a nil error and ExitError for errors it does not recognize.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var vErr *ValidationError
+	var envErr *EnvError
+	switch {
+	case errors.As(err, &vErr):
+		return vErr.ExitCode()
+	case errors.As(err, &envErr), errors.Is(err, ErrNoPublicKey), errors.Is(err, ErrNoServerURL),
+		errors.Is(err, ErrNetworkDisabled):
+		return ExitConfig
+	case errors.Is(err, ErrMissingFeatures):
+		return ExitFeatureMissing
+	case errors.Is(err, ErrNoToken):
+		return ExitNoLicense
+	case errors.Is(err, ErrInvalidLicenseKey):
+		return ExitLicenseInvalid
+	case errors.Is(err, ErrServerUnreachable), errors.Is(err, context.DeadlineExceeded):
+		return ExitUnavailable
+	}
+	return ExitError
+}
+
+// ExitCode returns the recommended exit code for the error's code. See
+// ExitCode.
+func (e *ValidationError) ExitCode() int {
+	switch e.Code {
+	case LicenseDecodeError, InvalidLicenseSignature, ProductMismatch, AppMismatch,
+		TenantMismatch, FingerprintMismatch:
+		return ExitLicenseInvalid
+	case LicenseNotValidAfter, LicenseNotValidBefore, MaintenanceExpired:
+		return ExitLicenseExpired
+	case LicenseRevoked, LicenseSuspended, ReactivationRequired:
+		return ExitLicenseRevoked
+	case SeatLimitReached:
+		return ExitTempFail
+	case ServerUnreachable, RenewalFailed:
+		return ExitUnavailable
+	case RegionRestricted, VirtualizedEnvironment, AttestationRejected:
+		return ExitNoPerm
+	case PubKeyDecodeError:
+		return ExitConfig
+	}
+	return ExitError
+}
@@ -0,0 +1,41 @@
+package licenseedict
+
+// IsSuspended reports whether the server last reported the license as
+// suspended. It clears on the next accepted heartbeat.
+func (c *Client) IsSuspended() bool {
+	return c.suspended.Load()
+}
+
+func suspendedError(reason string) *ValidationError {
+	msg := "license is suspended"
+	if reason != "" {
+		msg += ": " + reason
+	}
+	return &ValidationError{Code: LicenseSuspended, Message: msg}
+}
+
+// setSuspended records a change in suspension state, marks the current
+// license, emits EventLicenseSuspended or EventLicenseReinstated, and calls
+// the suspension handler. It does nothing if the state is unchanged.
+func (c *Client) setSuspended(res apiResponse, suspended bool, reason string) {
+	if c.suspended.Swap(suspended) == suspended {
+		return
+	}
+
+	c.mu.Lock()
+	if c.license != nil {
+		license := *c.license
+		license.Suspended = suspended
+		c.license = &license
+	}
+	c.mu.Unlock()
+
+	if suspended {
+		c.emitEvent(res.event(Event{Type: EventLicenseSuspended, Message: suspendedError(reason).Message, Data: reason}))
+	} else {
+		c.emitEvent(res.event(Event{Type: EventLicenseReinstated, Message: "license suspension lifted"}))
+	}
+	if fn := c.cfg.onSuspension; fn != nil {
+		c.safeCall("SuspensionHandler", func() { fn(suspended, reason) })
+	}
+}
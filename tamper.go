@@ -0,0 +1,96 @@
+package licenseedict
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// TamperCheckOptions configures the integrity checks enabled by
+// WithTamperChecks.
+type TamperCheckOptions struct {
+	// PublicKeySHA256 is the hex SHA-256 of the raw Ed25519 public key the
+	// application embeds. If set, the configured key is compared against it,
+	// detecting a binary patched to trust a different key.
+	PublicKeySHA256 string
+	// DisableDebuggerCheck skips debugger detection.
+	DisableDebuggerCheck bool
+	// DisableHookCheck skips detection of library injection such as
+	// LD_PRELOAD.
+	DisableHookCheck bool
+}
+
+// Tamper check names reported in TamperFinding.Check.
+const (
+	TamperCheckPublicKey = "public_key"
+	TamperCheckDebugger  = "debugger"
+	TamperCheckHook      = "hook"
+)
+
+// TamperFinding describes a failed integrity check.
+type TamperFinding struct {
+	Check   string
+	Message string
+}
+
+// RunTamperChecks runs the checks enabled by WithTamperChecks and returns
+// any findings. Each finding is also emitted as an EventTamperDetected
+// event. Findings never stop the client; the application decides how to
+// respond. It returns nil if tamper checks are not enabled.
+//
+// These checks raise the bar against casual patching and debugging; they
+// are not a defense against a determined attacker.
+func (c *Client) RunTamperChecks() []TamperFinding {
+	opts := c.cfg.tamperChecks
+	if opts == nil {
+		return nil
+	}
+
+	var findings []TamperFinding
+	if opts.PublicKeySHA256 != "" {
+		sum := sha256.Sum256(c.cfg.publicKey)
+		got := hex.EncodeToString(sum[:])
+		want := strings.ToLower(strings.TrimSpace(opts.PublicKeySHA256))
+		if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			findings = append(findings, TamperFinding{Check: TamperCheckPublicKey, Message: "configured public key does not match the expected checksum"})
+		}
+	}
+	if !opts.DisableDebuggerCheck {
+		if attached, detail := debuggerAttached(); attached {
+			findings = append(findings, TamperFinding{Check: TamperCheckDebugger, Message: "debugger attached: " + detail})
+		}
+	}
+	if !opts.DisableHookCheck {
+		if injected, detail := libraryInjected(); injected {
+			findings = append(findings, TamperFinding{Check: TamperCheckHook, Message: "library injection detected: " + detail})
+		}
+	}
+
+	for _, f := range findings {
+		c.emitEvent(Event{Type: EventTamperDetected, Message: f.Message, Data: f})
+	}
+	return findings
+}
+
+// libraryInjected reports whether the dynamic loader was asked to inject
+// libraries into the process.
+func libraryInjected() (bool, string) {
+	var vars []string
+	switch runtime.GOOS {
+	case "darwin":
+		vars = []string{"DYLD_INSERT_LIBRARIES"}
+	case "windows":
+		return false, ""
+	default:
+		vars = []string{"LD_PRELOAD", "LD_AUDIT"}
+	}
+	for _, v := range vars {
+		if val := os.Getenv(v); val != "" {
+			return true, v + "=" + val
+		}
+	}
+	return false, ""
+}
@@ -0,0 +1,26 @@
+package licenseedict
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// debuggerAttached reports whether a tracer such as gdb, delve, or strace is
+// attached, based on TracerPid in /proc/self/status.
+func debuggerAttached() (bool, string) {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return false, ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if pid, ok := strings.CutPrefix(scanner.Text(), "TracerPid:"); ok {
+			pid = strings.TrimSpace(pid)
+			return pid != "0", "tracer pid " + pid
+		}
+	}
+	return false, ""
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !windows
+
+package licenseedict
+
+// debuggerAttached is not implemented on this platform.
+func debuggerAttached() (bool, string) {
+	return false, ""
+}
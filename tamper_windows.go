@@ -0,0 +1,15 @@
+package licenseedict
+
+import "syscall"
+
+var procIsDebuggerPresent = syscall.NewLazyDLL("kernel32.dll").NewProc("IsDebuggerPresent")
+
+// debuggerAttached reports whether a user-mode debugger is attached, using
+// IsDebuggerPresent.
+func debuggerAttached() (bool, string) {
+	if procIsDebuggerPresent.Find() != nil {
+		return false, ""
+	}
+	ret, _, _ := procIsDebuggerPresent.Call()
+	return ret != 0, "IsDebuggerPresent"
+}
@@ -0,0 +1,86 @@
+package licenseedict
+
+import "fmt"
+
+// TelemetryConsent is the user's choice of how much metadata about the
+// machine and application the client sends to the license server.
+//
+// Data the licensing protocol depends on is sent at every level: the signed
+// token, the instance ID, checked-out features, reservation IDs, usage
+// quantities for metered licenses, hashed host IDs for site licenses, and
+// the binary hash when WithBinaryAttestation is set. The HTTP User-Agent
+// header is not affected.
+type TelemetryConsent string
+
+const (
+	// TelemetryNone sends no heartbeat metadata.
+	TelemetryNone TelemetryConsent = "none"
+	// TelemetryMinimal sends the application name and version and the
+	// configured region, but nothing that identifies the machine or user.
+	TelemetryMinimal TelemetryConsent = "minimal"
+	// TelemetryFull additionally sends the hostname, IP address, user agent,
+	// user hash, virtualization details and WithHeartbeatMetadata fields. It
+	// is the default.
+	TelemetryFull TelemetryConsent = "full"
+)
+
+func (t TelemetryConsent) valid() bool {
+	switch t {
+	case TelemetryNone, TelemetryMinimal, TelemetryFull:
+		return true
+	}
+	return false
+}
+
+// ParseTelemetryConsent parses "none", "minimal" or "full".
+func ParseTelemetryConsent(s string) (TelemetryConsent, error) {
+	if t := TelemetryConsent(s); t.valid() {
+		return t, nil
+	}
+	return "", fmt.Errorf("licenseedict: unknown telemetry consent level %q", s)
+}
+
+// WithTelemetryConsent sets the consent level used until the user makes a
+// choice with Client.SetTelemetryConsent, for example TelemetryNone for
+// products that ask for consent on first run. An unrecognized level is
+// treated as TelemetryNone.
+func WithTelemetryConsent(level TelemetryConsent) Option {
+	return func(c *clientConfig) {
+		c.telemetryConsent = level
+	}
+}
+
+// SetTelemetryConsent records the user's consent level and applies it to all
+// later heartbeat, checkout and usage requests. The choice is stored with
+// the license cache, so it survives restarts and takes precedence over
+// WithTelemetryConsent; with caching disabled it lasts for the life of the
+// client. An error is returned for an unknown level or if the choice could
+// not be stored, in which case it still applies to this client.
+func (c *Client) SetTelemetryConsent(level TelemetryConsent) error {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+	if !level.valid() {
+		return fmt.Errorf("licenseedict: unknown telemetry consent level %q", level)
+	}
+	if err := c.cache.saveConsent(level); err != nil {
+		return fmt.Errorf("licenseedict: store telemetry consent: %w", err)
+	}
+	return nil
+}
+
+// TelemetryConsent returns the consent level in effect: the one recorded
+// with SetTelemetryConsent, else the WithTelemetryConsent default, else
+// TelemetryFull.
+func (c *Client) TelemetryConsent() TelemetryConsent {
+	if level := c.cache.storedConsent(); level != "" {
+		return level
+	}
+	switch {
+	case c.cfg.telemetryConsent == "":
+		return TelemetryFull
+	case !c.cfg.telemetryConsent.valid():
+		return TelemetryNone
+	}
+	return c.cfg.telemetryConsent
+}
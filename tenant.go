@@ -0,0 +1,74 @@
+package licenseedict
+
+// SelectLicense installs the first of tokens that is valid for this client,
+// as SetToken does. With WithTenant, a token issued to the active tenant is
+// preferred over one without a tenant. It returns the last error if no token
+// is valid.
+//
+// MSPs that hold one token per end client can pass them all and let the
+// client pick the one for the tenant it is running for.
+func (c *Client) SelectLicense(tokens ...string) (*License, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if len(tokens) == 0 {
+		return nil, ErrNoToken
+	}
+
+	var (
+		fallback string
+		lastErr  error
+	)
+	for _, token := range tokens {
+		license, err := c.checkToken(token)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if c.cfg.tenantID == "" || license.TenantID == c.cfg.tenantID {
+			return c.SetToken(token)
+		}
+		if fallback == "" {
+			fallback = token
+		}
+	}
+	if fallback != "" {
+		return c.SetToken(fallback)
+	}
+	return nil, lastErr
+}
+
+// SelectTenant returns the first valid license issued to tenantID, or nil if
+// there is none.
+func SelectTenant(tenantID string, licenses ...*License) *License {
+	for _, l := range licenses {
+		if l != nil && l.Valid && l.TenantID == tenantID {
+			return l
+		}
+	}
+	return nil
+}
+
+// GroupByTenant groups licenses by TenantID. Licenses without a tenant are
+// grouped under the empty string.
+func GroupByTenant(licenses ...*License) map[string][]*License {
+	return groupLicenses(licenses, func(l *License) string { return l.TenantID })
+}
+
+// GroupByOrg groups licenses by OrgID. Licenses without an organization are
+// grouped under the empty string.
+func GroupByOrg(licenses ...*License) map[string][]*License {
+	return groupLicenses(licenses, func(l *License) string { return l.OrgID })
+}
+
+func groupLicenses(licenses []*License, key func(*License) string) map[string][]*License {
+	groups := make(map[string][]*License)
+	for _, l := range licenses {
+		if l == nil {
+			continue
+		}
+		k := key(l)
+		groups[k] = append(groups[k], l)
+	}
+	return groups
+}
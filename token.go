@@ -4,26 +4,65 @@ import (
 	"crypto/ed25519"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
 	"time"
 )
 
 // tokenPayload mirrors the server's LicenseTokenPayload.
 type tokenPayload struct {
-	LicenseID  string    `json:"license_id"`
-	ProductID  string    `json:"product_id"`
-	LicenseKey string    `json:"license_key"`
-	Licensee   string    `json:"licensee,omitempty"`
-	Plan       string    `json:"plan"`
-	Features   []string  `json:"features,omitempty"`
-	MaxSeats   int       `json:"max_seats"`
-	IssuedAt   time.Time `json:"issued_at"`
-	ExpiresAt  time.Time `json:"expires_at,omitempty"`
-	ServerURL  string    `json:"server_url,omitempty"`
+	LicenseID        string    `json:"license_id"`
+	ProductID        string    `json:"product_id"`
+	LicenseKey       string    `json:"license_key"`
+	Licensee         string    `json:"licensee,omitempty"`
+	Plan             string    `json:"plan"`
+	Features         []string  `json:"features,omitempty"`
+	MaxSeats         int       `json:"max_seats"`
+	IssuedAt         time.Time `json:"issued_at"`
+	ExpiresAt        time.Time `json:"expires_at,omitempty"`
+	ServerURL        string    `json:"server_url,omitempty"`
+	MaintenanceUntil time.Time `json:"maintenance_until,omitempty"`
+	AllowedApps      []string  `json:"allowed_apps,omitempty"`
+	AllowedRegions   []string  `json:"allowed_regions,omitempty"`
+	AllowedIPRanges  []string  `json:"allowed_ip_ranges,omitempty"`
+	ServerURLs       []string  `json:"server_urls,omitempty"`
+
+	Fingerprint  HostFingerprint `json:"fingerprint,omitempty"`
+	LicenseModel LicenseModel    `json:"license_model,omitempty"`
+	OrgID        string          `json:"org_id,omitempty"`
+	TenantID     string          `json:"tenant_id,omitempty"`
+	ContractID   string          `json:"contract_id,omitempty"`
+
+	// DelegationKey is the base64 Ed25519 public key with which the
+	// licensee may sign child licenses. See verifyDelegatedToken.
+	DelegationKey string `json:"delegation_key,omitempty"`
+
+	// parent is set on the payload of a delegated token.
+	parent      *tokenPayload
+	parentToken string
+
+	Entitlements map[string]json.RawMessage `json:"entitlements,omitempty"`
 }
 
+// Payload fields that must be present when strict payload validation is enabled.
+var (
+	requiredPayloadFields  = []string{"license_id", "product_id", "issued_at"}
+	timestampPayloadFields = []string{"issued_at", "expires_at", "maintenance_until"}
+)
+
 // verifyToken verifies the Ed25519 signature and returns the decoded payload.
 // Token format: base64(signature_64bytes + json_payload)
-func verifyToken(pubKey ed25519.PublicKey, signedToken string) (*tokenPayload, error) {
+//
+// When strict is true, the payload is additionally checked against the schema
+// and a *PayloadSchemaError is wrapped in the returned ValidationError if
+// required fields are missing or timestamps are malformed.
+func verifyToken(pubKey ed25519.PublicKey, signedToken string, strict bool) (*tokenPayload, error) {
+	if i := strings.LastIndex(signedToken, delegationSeparator); i >= 0 {
+		return verifyDelegatedToken(pubKey, signedToken[:i], signedToken[i+1:], strict)
+	}
+
 	combined, err := base64.StdEncoding.DecodeString(signedToken)
 	if err != nil {
 		return nil, &ValidationError{
@@ -50,6 +89,16 @@ func verifyToken(pubKey ed25519.PublicKey, signedToken string) (*tokenPayload, e
 		}
 	}
 
+	if strict {
+		if err := checkPayloadSchema(payloadBytes); err != nil {
+			return nil, &ValidationError{
+				Code:    LicenseDecodeError,
+				Message: "token payload does not match schema",
+				Err:     err,
+			}
+		}
+	}
+
 	var payload tokenPayload
 	if err := json.Unmarshal(payloadBytes, &payload); err != nil {
 		return nil, &ValidationError{
@@ -62,9 +111,294 @@ func verifyToken(pubKey ed25519.PublicKey, signedToken string) (*tokenPayload, e
 	return &payload, nil
 }
 
+// delegationSeparator joins a parent token and a child token signed with the
+// parent's delegation key. It cannot occur in standard base64, and chains of
+// more than two licenses repeat it.
+const delegationSeparator = "."
+
+// verifyDelegatedToken verifies a delegation chain: the parent token against
+// pubKey, and the child token against the parent's delegation key. The child
+// is constrained to the parent: it must be for the same product and issued
+// while the parent was valid, and it cannot widen any of the parent's
+// restrictions; see constrainToParent.
+func verifyDelegatedToken(pubKey ed25519.PublicKey, parentToken, childToken string, strict bool) (*tokenPayload, error) {
+	parent, err := verifyToken(pubKey, parentToken, strict)
+	if err != nil {
+		return nil, err
+	}
+	if parent.DelegationKey == "" {
+		return nil, &ValidationError{
+			Code:    InvalidLicenseSignature,
+			Message: "parent license does not permit delegation",
+		}
+	}
+	key, err := DecodePublicKey(parent.DelegationKey)
+	if err != nil {
+		return nil, &ValidationError{
+			Code:    InvalidLicenseSignature,
+			Message: "parent license has an invalid delegation key",
+			Err:     err,
+		}
+	}
+	child, err := verifyToken(key, childToken, strict)
+	if err != nil {
+		return nil, err
+	}
+
+	if child.ProductID != parent.ProductID {
+		return nil, &ValidationError{
+			Code:    ProductMismatch,
+			Message: fmt.Sprintf("delegated license is for product %q, parent is for %q", child.ProductID, parent.ProductID),
+		}
+	}
+	if err := constrainToParent(child, parent); err != nil {
+		return nil, err
+	}
+	child.parent = parent
+	child.parentToken = parentToken
+	return child, nil
+}
+
+// constrainToParent limits a delegated license to the scope of its parent.
+// Restrictions the child omits are inherited from the parent and those it
+// sets are narrowed to the parent's: features, app, region and IP-range
+// lists to their intersection, seats, expiry and maintenance to the smaller
+// value, and entitlements to the parent's keys with numbers and booleans
+// capped at the parent's values. The child's fingerprint, tenant and
+// organization must agree with the parent's, and it always takes the
+// parent's license model. A child whose lists do not overlap the parent's
+// is rejected rather than left unrestricted.
+//
+// The license ID, server URLs and delegation key are the parent's: a child
+// that omits them inherits them and one that sets them differently is
+// rejected, so a sub-issuer cannot claim another license, point heartbeats
+// and renewals at a server of its own or delegate with a key of its own.
+func constrainToParent(child, parent *tokenPayload) error {
+	if !sameID(&child.LicenseID, parent.LicenseID) {
+		return &ValidationError{Code: InvalidLicenseSignature, Message: fmt.Sprintf("delegated license claims license %q, parent is %q", child.LicenseID, parent.LicenseID)}
+	}
+	if !sameID(&child.ServerURL, parent.ServerURL) || !sameList(&child.ServerURLs, parent.ServerURLs) {
+		return &ValidationError{Code: InvalidLicenseSignature, Message: "delegated license names servers other than its parent's"}
+	}
+	if !sameID(&child.DelegationKey, parent.DelegationKey) {
+		return &ValidationError{Code: InvalidLicenseSignature, Message: "delegated license carries a delegation key other than its parent's"}
+	}
+
+	if child.IssuedAt.IsZero() {
+		child.IssuedAt = parent.IssuedAt
+	}
+	if child.IssuedAt.Before(parent.IssuedAt) {
+		return &ValidationError{Code: LicenseNotValidBefore, Message: "delegated license was issued before its parent"}
+	}
+	if !parent.ExpiresAt.IsZero() && child.IssuedAt.After(parent.ExpiresAt) {
+		return &ValidationError{Code: LicenseNotValidAfter, Message: "delegated license was issued after its parent expired"}
+	}
+
+	allowed := NewFeatureSet(parent.Features...)
+	features := child.Features[:0:0]
+	for _, f := range child.Features {
+		if allowed.Has(f) {
+			features = append(features, f)
+		}
+	}
+	child.Features = features
+	if parent.MaxSeats > 0 && (child.MaxSeats == 0 || child.MaxSeats > parent.MaxSeats) {
+		child.MaxSeats = parent.MaxSeats
+	}
+	child.ExpiresAt = earlierDeadline(child.ExpiresAt, parent.ExpiresAt)
+	child.MaintenanceUntil = earlierDeadline(child.MaintenanceUntil, parent.MaintenanceUntil)
+
+	var ok bool
+	if child.AllowedApps, ok = narrowList(child.AllowedApps, parent.AllowedApps, func(a, b string) bool { return a == b }); !ok {
+		return &ValidationError{Code: AppMismatch, Message: "delegated license allows no application its parent allows"}
+	}
+	if child.AllowedRegions, ok = narrowList(child.AllowedRegions, parent.AllowedRegions, strings.EqualFold); !ok {
+		return &ValidationError{Code: RegionRestricted, Message: "delegated license allows no region its parent allows"}
+	}
+	if child.AllowedIPRanges, ok = narrowList(child.AllowedIPRanges, parent.AllowedIPRanges, cidrWithin); !ok {
+		return &ValidationError{Code: RegionRestricted, Message: "delegated license allows no IP range its parent allows"}
+	}
+
+	if len(parent.Fingerprint) > 0 {
+		fp := make(HostFingerprint, len(child.Fingerprint)+len(parent.Fingerprint))
+		for name, value := range child.Fingerprint {
+			fp[name] = value
+		}
+		for name, value := range parent.Fingerprint {
+			if v, ok := fp[name]; ok && v != value {
+				return &ValidationError{Code: FingerprintMismatch, Message: fmt.Sprintf("delegated license binds %s differently from its parent", name)}
+			}
+			fp[name] = value
+		}
+		child.Fingerprint = fp
+	}
+	if !inheritID(&child.TenantID, parent.TenantID) {
+		return &ValidationError{Code: TenantMismatch, Message: fmt.Sprintf("delegated license is for tenant %q, parent is for %q", child.TenantID, parent.TenantID)}
+	}
+	if !inheritID(&child.OrgID, parent.OrgID) {
+		return &ValidationError{Code: TenantMismatch, Message: fmt.Sprintf("delegated license is for organization %q, parent is for %q", child.OrgID, parent.OrgID)}
+	}
+	if parent.LicenseModel != "" {
+		child.LicenseModel = parent.LicenseModel
+	}
+	child.Entitlements = narrowEntitlements(child.Entitlements, parent.Entitlements)
+	return nil
+}
+
+// narrowList returns the entries of child that match an entry of parent.
+// An empty list is unrestricted, so an empty child inherits parent, and
+// ok is false if a restricted child has no entry in common with parent.
+func narrowList(child, parent []string, within func(c, p string) bool) (out []string, ok bool) {
+	if len(parent) == 0 {
+		return child, true
+	}
+	if len(child) == 0 {
+		return parent, true
+	}
+	for _, c := range child {
+		for _, p := range parent {
+			if within(c, p) {
+				out = append(out, c)
+				break
+			}
+		}
+	}
+	return out, len(out) > 0
+}
+
+// cidrWithin reports whether the CIDR range child lies inside parent.
+func cidrWithin(child, parent string) bool {
+	_, c, err := net.ParseCIDR(child)
+	if err != nil {
+		return false
+	}
+	_, p, err := net.ParseCIDR(parent)
+	if err != nil {
+		return false
+	}
+	cBits, cLen := c.Mask.Size()
+	pBits, pLen := p.Mask.Size()
+	return cLen == pLen && cBits >= pBits && p.Contains(c.IP)
+}
+
+// inheritID sets an empty *child to parent and reports whether the two
+// agree.
+func inheritID(child *string, parent string) bool {
+	if parent == "" {
+		return true
+	}
+	if *child == "" {
+		*child = parent
+	}
+	return *child == parent
+}
+
+// sameID sets an empty child to parent and reports whether they are equal.
+// Unlike inheritID, an unset parent does not leave the child free.
+func sameID(child *string, parent string) bool {
+	if *child == "" {
+		*child = parent
+	}
+	return *child == parent
+}
+
+// sameList is sameID for lists.
+func sameList(child *[]string, parent []string) bool {
+	if len(*child) == 0 {
+		*child = parent
+	}
+	return slices.Equal(*child, parent)
+}
+
+// narrowEntitlements keeps the child's entitlements that the parent also
+// grants. Numeric and boolean values are capped at the parent's; other
+// values, and entitlements the child omits, take the parent's value.
+func narrowEntitlements(child, parent map[string]json.RawMessage) map[string]json.RawMessage {
+	if len(parent) == 0 {
+		return nil
+	}
+	out := make(map[string]json.RawMessage, len(parent))
+	for key, p := range parent {
+		out[key] = p
+		c, ok := child[key]
+		if !ok {
+			continue
+		}
+		var cNum, pNum float64
+		var cBool, pBool bool
+		switch {
+		case json.Unmarshal(c, &cNum) == nil && json.Unmarshal(p, &pNum) == nil:
+			if cNum < pNum {
+				out[key] = c
+			}
+		case json.Unmarshal(c, &cBool) == nil && json.Unmarshal(p, &pBool) == nil:
+			if !cBool {
+				out[key] = c
+			}
+		}
+	}
+	return out
+}
+
+// checkPayloadSchema reports required fields that are missing or empty and
+// timestamp fields that are not RFC 3339 strings.
+func checkPayloadSchema(payloadBytes []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(payloadBytes, &raw); err != nil {
+		return err
+	}
+
+	schemaErr := &PayloadSchemaError{}
+	for _, field := range requiredPayloadFields {
+		v, ok := raw[field]
+		if !ok || string(v) == "null" || string(v) == `""` {
+			schemaErr.Missing = append(schemaErr.Missing, field)
+		}
+	}
+	for _, field := range timestampPayloadFields {
+		v, ok := raw[field]
+		if !ok || string(v) == "null" {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(v, &s); err != nil {
+			schemaErr.Malformed = append(schemaErr.Malformed, field)
+			continue
+		}
+		if s == "" {
+			continue // reported as missing if required
+		}
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			schemaErr.Malformed = append(schemaErr.Malformed, field)
+		}
+	}
+
+	if len(schemaErr.Missing) == 0 && len(schemaErr.Malformed) == 0 {
+		return nil
+	}
+	return schemaErr
+}
+
 // decodeTokenPayload extracts the payload without verifying the signature.
 // Useful for extracting server_url or license_key before full verification.
 func decodeTokenPayload(signedToken string) (*tokenPayload, error) {
+	if i := strings.LastIndex(signedToken, delegationSeparator); i >= 0 {
+		parent, err := decodeTokenPayload(signedToken[:i])
+		if err != nil {
+			return nil, err
+		}
+		child, err := decodeTokenPayload(signedToken[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		if child.ServerURL == "" {
+			child.ServerURL = parent.ServerURL
+		}
+		child.parent = parent
+		child.parentToken = signedToken[:i]
+		return child, nil
+	}
+
 	combined, err := base64.StdEncoding.DecodeString(signedToken)
 	if err != nil {
 		return nil, &ValidationError{
@@ -120,18 +454,35 @@ func payloadToLicense(p *tokenPayload, signedToken string, valid bool) *License
 	if features == nil {
 		features = []string{}
 	}
-	return &License{
-		Valid:       valid,
-		LicenseID:   p.LicenseID,
-		ProductID:   p.ProductID,
-		LicenseKey:  p.LicenseKey,
-		Licensee:    p.Licensee,
-		Plan:        p.Plan,
-		Features:    features,
-		MaxSeats:    p.MaxSeats,
-		IssuedAt:    p.IssuedAt,
-		ExpiresAt:   p.ExpiresAt,
-		ServerURL:   p.ServerURL,
-		SignedToken: signedToken,
+	license := &License{
+		Valid:            valid,
+		LicenseID:        p.LicenseID,
+		ProductID:        p.ProductID,
+		LicenseKey:       p.LicenseKey,
+		Licensee:         p.Licensee,
+		Plan:             p.Plan,
+		Features:         features,
+		MaxSeats:         p.MaxSeats,
+		IssuedAt:         p.IssuedAt,
+		ExpiresAt:        p.ExpiresAt,
+		ServerURL:        p.ServerURL,
+		SignedToken:      signedToken,
+		MaintenanceUntil: p.MaintenanceUntil,
+		AllowedApps:      p.AllowedApps,
+		AllowedRegions:   p.AllowedRegions,
+		AllowedIPRanges:  p.AllowedIPRanges,
+		ServerURLs:       p.ServerURLs,
+		Fingerprint:      p.Fingerprint,
+		LicenseModel:     p.LicenseModel,
+		Entitlements:     p.Entitlements,
+		OrgID:            p.OrgID,
+		TenantID:         p.TenantID,
+		ContractID:       p.ContractID,
+		DelegationKey:    p.DelegationKey,
+	}
+	if p.parent != nil {
+		license.Parent = payloadToLicense(p.parent, p.parentToken, valid)
 	}
+	license.indexFeatures()
+	return license
 }
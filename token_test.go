@@ -0,0 +1,321 @@
+package licenseedict
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var (
+	testIssued = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	testExpiry = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// testParent returns a parent payload with every restriction set.
+func testParent() *tokenPayload {
+	return &tokenPayload{
+		LicenseID:        "lic-parent",
+		ProductID:        "prod",
+		Features:         []string{"A", "B"},
+		MaxSeats:         10,
+		IssuedAt:         testIssued,
+		ExpiresAt:        testExpiry,
+		MaintenanceUntil: testExpiry,
+		ServerURL:        "https://license.example.com",
+		ServerURLs:       []string{"https://a.example.com", "https://b.example.com"},
+		AllowedApps:      []string{"editor", "viewer"},
+		AllowedRegions:   []string{"DE", "FR"},
+		AllowedIPRanges:  []string{"10.0.0.0/8"},
+		Fingerprint:      HostFingerprint{"machine_id": "m1"},
+		LicenseModel:     LicenseModelFloating,
+		OrgID:            "org",
+		TenantID:         "tenant",
+		DelegationKey:    "parent-key",
+		Entitlements: map[string]json.RawMessage{
+			"storage_gb": json.RawMessage(`100`),
+			"api":        json.RawMessage(`true`),
+		},
+	}
+}
+
+func TestConstrainToParentNarrows(t *testing.T) {
+	tests := []struct {
+		name  string
+		child func(*tokenPayload)
+		check func(t *testing.T, got *tokenPayload)
+	}{
+		{
+			name:  "features are intersected",
+			child: func(c *tokenPayload) { c.Features = []string{"B", "C"} },
+			check: func(t *testing.T, got *tokenPayload) {
+				if !reflect.DeepEqual(got.Features, []string{"B"}) {
+					t.Errorf("Features = %v, want [B]", got.Features)
+				}
+			},
+		},
+		{
+			name:  "seats are capped",
+			child: func(c *tokenPayload) { c.MaxSeats = 50 },
+			check: func(t *testing.T, got *tokenPayload) {
+				if got.MaxSeats != 10 {
+					t.Errorf("MaxSeats = %d, want 10", got.MaxSeats)
+				}
+			},
+		},
+		{
+			name:  "unlimited seats take the parent's",
+			child: func(c *tokenPayload) { c.MaxSeats = 0 },
+			check: func(t *testing.T, got *tokenPayload) {
+				if got.MaxSeats != 10 {
+					t.Errorf("MaxSeats = %d, want 10", got.MaxSeats)
+				}
+			},
+		},
+		{
+			name: "expiry and maintenance are capped",
+			child: func(c *tokenPayload) {
+				c.ExpiresAt = testExpiry.AddDate(1, 0, 0)
+				c.MaintenanceUntil = time.Time{}
+			},
+			check: func(t *testing.T, got *tokenPayload) {
+				if !got.ExpiresAt.Equal(testExpiry) || !got.MaintenanceUntil.Equal(testExpiry) {
+					t.Errorf("ExpiresAt = %v, MaintenanceUntil = %v, want %v", got.ExpiresAt, got.MaintenanceUntil, testExpiry)
+				}
+			},
+		},
+		{
+			name:  "earlier expiry is kept",
+			child: func(c *tokenPayload) { c.ExpiresAt = testExpiry.AddDate(0, -1, 0) },
+			check: func(t *testing.T, got *tokenPayload) {
+				if !got.ExpiresAt.Equal(testExpiry.AddDate(0, -1, 0)) {
+					t.Errorf("ExpiresAt = %v", got.ExpiresAt)
+				}
+			},
+		},
+		{
+			name: "lists are narrowed",
+			child: func(c *tokenPayload) {
+				c.AllowedApps = []string{"viewer", "admin"}
+				c.AllowedRegions = []string{"fr"}
+				c.AllowedIPRanges = []string{"10.1.0.0/16", "192.168.0.0/16"}
+			},
+			check: func(t *testing.T, got *tokenPayload) {
+				if !reflect.DeepEqual(got.AllowedApps, []string{"viewer"}) {
+					t.Errorf("AllowedApps = %v", got.AllowedApps)
+				}
+				if !reflect.DeepEqual(got.AllowedRegions, []string{"fr"}) {
+					t.Errorf("AllowedRegions = %v", got.AllowedRegions)
+				}
+				if !reflect.DeepEqual(got.AllowedIPRanges, []string{"10.1.0.0/16"}) {
+					t.Errorf("AllowedIPRanges = %v", got.AllowedIPRanges)
+				}
+			},
+		},
+		{
+			name: "omitted lists are inherited",
+			child: func(c *tokenPayload) {
+				c.AllowedApps, c.AllowedRegions, c.AllowedIPRanges = nil, nil, nil
+			},
+			check: func(t *testing.T, got *tokenPayload) {
+				p := testParent()
+				if !reflect.DeepEqual(got.AllowedApps, p.AllowedApps) || !reflect.DeepEqual(got.AllowedRegions, p.AllowedRegions) ||
+					!reflect.DeepEqual(got.AllowedIPRanges, p.AllowedIPRanges) {
+					t.Errorf("lists = %v %v %v, want the parent's", got.AllowedApps, got.AllowedRegions, got.AllowedIPRanges)
+				}
+			},
+		},
+		{
+			name:  "fingerprint is merged",
+			child: func(c *tokenPayload) { c.Fingerprint = HostFingerprint{"mac": "aa"} },
+			check: func(t *testing.T, got *tokenPayload) {
+				want := HostFingerprint{"machine_id": "m1", "mac": "aa"}
+				if !reflect.DeepEqual(got.Fingerprint, want) {
+					t.Errorf("Fingerprint = %v, want %v", got.Fingerprint, want)
+				}
+			},
+		},
+		{
+			name: "tenant, organization and model are inherited",
+			child: func(c *tokenPayload) {
+				c.TenantID, c.OrgID, c.LicenseModel = "", "", LicenseModelNodeLocked
+			},
+			check: func(t *testing.T, got *tokenPayload) {
+				if got.TenantID != "tenant" || got.OrgID != "org" || got.LicenseModel != LicenseModelFloating {
+					t.Errorf("TenantID = %q, OrgID = %q, LicenseModel = %q", got.TenantID, got.OrgID, got.LicenseModel)
+				}
+			},
+		},
+		{
+			name: "entitlements are capped",
+			child: func(c *tokenPayload) {
+				c.Entitlements = map[string]json.RawMessage{
+					"storage_gb": json.RawMessage(`500`),
+					"api":        json.RawMessage(`false`),
+					"extra":      json.RawMessage(`1`),
+				}
+			},
+			check: func(t *testing.T, got *tokenPayload) {
+				want := map[string]json.RawMessage{
+					"storage_gb": json.RawMessage(`100`),
+					"api":        json.RawMessage(`false`),
+				}
+				if !reflect.DeepEqual(got.Entitlements, want) {
+					t.Errorf("Entitlements = %s, want %s", got.Entitlements, want)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			child := testParent()
+			child.DelegationKey = ""
+			tt.child(child)
+			if err := constrainToParent(child, testParent()); err != nil {
+				t.Fatalf("constrainToParent: %v", err)
+			}
+			tt.check(t, child)
+		})
+	}
+}
+
+func TestConstrainToParentRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		child func(*tokenPayload)
+		code  string
+	}{
+		{"issued before parent", func(c *tokenPayload) { c.IssuedAt = testIssued.Add(-time.Hour) }, LicenseNotValidBefore},
+		{"issued after parent expired", func(c *tokenPayload) { c.IssuedAt = testExpiry.Add(time.Hour) }, LicenseNotValidAfter},
+		{"no common app", func(c *tokenPayload) { c.AllowedApps = []string{"admin"} }, AppMismatch},
+		{"no common region", func(c *tokenPayload) { c.AllowedRegions = []string{"US"} }, RegionRestricted},
+		{"IP range outside parent", func(c *tokenPayload) { c.AllowedIPRanges = []string{"0.0.0.0/0"} }, RegionRestricted},
+		{"different fingerprint", func(c *tokenPayload) { c.Fingerprint = HostFingerprint{"machine_id": "m2"} }, FingerprintMismatch},
+		{"different tenant", func(c *tokenPayload) { c.TenantID = "other" }, TenantMismatch},
+		{"different organization", func(c *tokenPayload) { c.OrgID = "other" }, TenantMismatch},
+		{"different license ID", func(c *tokenPayload) { c.LicenseID = "lic-other" }, InvalidLicenseSignature},
+		{"different server URL", func(c *tokenPayload) { c.ServerURL = "https://evil.example.com" }, InvalidLicenseSignature},
+		{"different server URLs", func(c *tokenPayload) { c.ServerURLs = []string{"https://evil.example.com"} }, InvalidLicenseSignature},
+		{"extra server URL", func(c *tokenPayload) {
+			c.ServerURLs = []string{"https://a.example.com", "https://b.example.com", "https://evil.example.com"}
+		}, InvalidLicenseSignature},
+		{"different delegation key", func(c *tokenPayload) { c.DelegationKey = "child-key" }, InvalidLicenseSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			child := testParent()
+			tt.child(child)
+			err := constrainToParent(child, testParent())
+			var vErr *ValidationError
+			if !errors.As(err, &vErr) || vErr.Code != tt.code {
+				t.Errorf("constrainToParent = %v, want code %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestConstrainToParentInheritsIdentity(t *testing.T) {
+	for _, name := range []string{"omitted", "equal"} {
+		t.Run(name, func(t *testing.T) {
+			child := &tokenPayload{ProductID: "prod", IssuedAt: testIssued}
+			if name == "equal" {
+				p := testParent()
+				child.LicenseID, child.ServerURL, child.ServerURLs, child.DelegationKey = p.LicenseID, p.ServerURL, p.ServerURLs, p.DelegationKey
+			}
+			if err := constrainToParent(child, testParent()); err != nil {
+				t.Fatalf("constrainToParent: %v", err)
+			}
+			p := testParent()
+			if child.LicenseID != p.LicenseID || child.ServerURL != p.ServerURL ||
+				!reflect.DeepEqual(child.ServerURLs, p.ServerURLs) || child.DelegationKey != p.DelegationKey {
+				t.Errorf("child = %q %q %v %q, want the parent's", child.LicenseID, child.ServerURL, child.ServerURLs, child.DelegationKey)
+			}
+		})
+	}
+
+	// A parent without servers does not let the child name any.
+	parent := testParent()
+	parent.ServerURL, parent.ServerURLs = "", nil
+	child := &tokenPayload{ProductID: "prod", ServerURL: "https://evil.example.com"}
+	if err := constrainToParent(child, parent); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("constrainToParent with a server the parent lacks = %v, want ErrInvalidSignature", err)
+	}
+}
+
+// signTestPayload signs payload as the issuer package does.
+func signTestPayload(t *testing.T, key ed25519.PrivateKey, payload interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(append(ed25519.Sign(key, data), data...))
+}
+
+func TestVerifyDelegatedToken(t *testing.T) {
+	vendorPub, vendorKey, _ := ed25519.GenerateKey(rand.Reader)
+	resellerPub, resellerKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	parent := testParent()
+	parent.DelegationKey = base64.StdEncoding.EncodeToString(resellerPub)
+	parentToken := signTestPayload(t, vendorKey, parent)
+
+	// Strict mode requires the child to name its license, which must be
+	// the parent's.
+	child := map[string]interface{}{"license_id": parent.LicenseID, "product_id": "prod", "issued_at": testIssued.Add(time.Hour), "max_seats": 3}
+	if _, err := verifyToken(vendorPub, parentToken+delegationSeparator+signTestPayload(t, resellerKey, child), true); err != nil {
+		t.Fatalf("verifyToken strict: %v", err)
+	}
+
+	delete(child, "license_id")
+	payload, err := verifyToken(vendorPub, parentToken+delegationSeparator+signTestPayload(t, resellerKey, child), false)
+	if err != nil {
+		t.Fatalf("verifyToken: %v", err)
+	}
+	if payload.LicenseID != parent.LicenseID || payload.ServerURL != parent.ServerURL || payload.MaxSeats != 3 {
+		t.Errorf("payload = %q %q %d, want the parent's license and servers with 3 seats", payload.LicenseID, payload.ServerURL, payload.MaxSeats)
+	}
+
+	child["server_url"] = "https://evil.example.com"
+	if _, err := verifyToken(vendorPub, parentToken+delegationSeparator+signTestPayload(t, resellerKey, child), false); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("verifyToken with a child server URL = %v, want ErrInvalidSignature", err)
+	}
+
+	delete(child, "server_url")
+	if _, err := verifyToken(vendorPub, parentToken+delegationSeparator+signTestPayload(t, vendorKey, child), false); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("verifyToken with a child not signed by the delegation key = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestTokenRejected(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	good := signTestPayload(t, key, testParent())
+
+	tests := []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{"bad signature", signTestPayload(t, otherKey, testParent()), true},
+		{"schema", signTestPayload(t, key, map[string]string{"product_id": "prod"}), true},
+		{"not base64", "not a token!", false},
+		{"truncated", good[:40], false},
+		{"payload not JSON", base64.StdEncoding.EncodeToString(append(ed25519.Sign(key, []byte("{")), '{')), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifyToken(pub, tt.token, true)
+			if err == nil {
+				t.Fatal("verifyToken succeeded")
+			}
+			if got := tokenRejected(err); got != tt.want {
+				t.Fatalf("tokenRejected(%v) = %v, want %v", err, got, tt.want)
+			}
+		})
+	}
+}
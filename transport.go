@@ -0,0 +1,47 @@
+package licenseedict
+
+import (
+	"net"
+	"time"
+)
+
+// Transport defaults tuned for long-lived clients that heartbeat frequently
+// against a single license server.
+const (
+	defaultDialTimeout           = 5 * time.Second
+	defaultKeepAlive             = 30 * time.Second
+	defaultTLSHandshakeTimeout   = 5 * time.Second
+	defaultIdleConnTimeout       = 90 * time.Second
+	defaultMaxIdleConns          = 10
+	defaultMaxIdleConnsPerHost   = 4
+	defaultResponseHeaderTimeout = 0 // bounded by the overall HTTP timeout
+)
+
+// TransportOptions tunes the HTTP transport used when no custom client is
+// supplied via WithHTTPClient. Zero fields keep the SDK defaults.
+type TransportOptions struct {
+	// DialTimeout bounds establishing a TCP connection (default 5s).
+	DialTimeout time.Duration
+	// KeepAlive is the TCP keep-alive period (default 30s).
+	KeepAlive time.Duration
+	// TLSHandshakeTimeout bounds the TLS handshake (default 5s).
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout bounds waiting for response headers after the
+	// request is written (default: no limit beyond the overall timeout).
+	ResponseHeaderTimeout time.Duration
+	// IdleConnTimeout is how long idle connections stay pooled (default 90s).
+	IdleConnTimeout time.Duration
+	// MaxIdleConns limits idle connections across all hosts (default 10).
+	MaxIdleConns int
+	// MaxIdleConnsPerHost limits idle connections per host (default 4).
+	MaxIdleConnsPerHost int
+
+	// Resolver is used for hostname lookups (default net.DefaultResolver).
+	Resolver *net.Resolver
+	// DNSCacheTTL is how long successful lookups are cached (default 5m).
+	// A negative value disables caching of successful lookups.
+	DNSCacheTTL time.Duration
+	// DNSNegativeTTL is how long failed lookups are cached (default 30s).
+	// A negative value disables caching of failures.
+	DNSNegativeTTL time.Duration
+}
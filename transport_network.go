@@ -0,0 +1,65 @@
+//go:build !licenseedict_nonetwork
+
+package licenseedict
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// networkEnabled reports whether the binary was built with network support,
+// that is, without the licenseedict_nonetwork tag.
+const networkEnabled = true
+
+// newTransport builds an *http.Transport with keep-alives and connection
+// pooling so periodic heartbeats reuse connections instead of churning them.
+func newTransport(opts TransportOptions) http.RoundTripper {
+	if opts.DialTimeout == 0 {
+		opts.DialTimeout = defaultDialTimeout
+	}
+	if opts.KeepAlive == 0 {
+		opts.KeepAlive = defaultKeepAlive
+	}
+	if opts.TLSHandshakeTimeout == 0 {
+		opts.TLSHandshakeTimeout = defaultTLSHandshakeTimeout
+	}
+	if opts.ResponseHeaderTimeout == 0 {
+		opts.ResponseHeaderTimeout = defaultResponseHeaderTimeout
+	}
+	if opts.IdleConnTimeout == 0 {
+		opts.IdleConnTimeout = defaultIdleConnTimeout
+	}
+	if opts.MaxIdleConns == 0 {
+		opts.MaxIdleConns = defaultMaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost == 0 {
+		opts.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+
+	if opts.DNSCacheTTL == 0 {
+		opts.DNSCacheTTL = defaultDNSCacheTTL
+	}
+	if opts.DNSNegativeTTL == 0 {
+		opts.DNSNegativeTTL = defaultDNSNegativeTTL
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   opts.DialTimeout,
+		KeepAlive: opts.KeepAlive,
+		Resolver:  opts.Resolver,
+	}
+	dns := newDNSCache(opts.Resolver, opts.DNSCacheTTL, opts.DNSNegativeTTL)
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dns.dialContext(dialer),
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
@@ -0,0 +1,7 @@
+//go:build licenseedict_nonetwork
+
+package licenseedict
+
+// networkEnabled reports whether the binary was built with network support,
+// that is, without the licenseedict_nonetwork tag.
+const networkEnabled = false
@@ -1,6 +1,9 @@
 package licenseedict
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"time"
 )
 
@@ -14,11 +17,23 @@ const defaultRenewBefore = 7 * 24 * time.Hour
 //
 // This method follows the offline-first philosophy: it never returns an error
 // that should block the host application. Check license.Valid instead.
-// On verification failure, it falls back to the cached license if available.
+// On verification failure, it falls back to the cached license if available;
+// WithOfflinePolicy adjusts these fallback rules. Policy violations (such as
+// a product mismatch or a build outside the maintenance window) return the
+// decoded license with Valid set to false alongside a *ValidationError.
+//
+// With WithStaleWhileRevalidate, a verified cached license for the same token
+// that still passes the expiry, offline and policy checks is returned
+// immediately and the token is revalidated in the background. Otherwise the
+// token is validated before returning.
 func (c *Client) Validate(signedToken ...string) (*License, error) {
-	if c.closed {
+	if c.closed.Load() {
 		return &License{}, ErrClientClosed
 	}
+	c.stats.validations.Add(1)
+	if c.devLicense != nil {
+		return c.validateDev()
+	}
 
 	// Use provided token, fall back to stored token
 	token := ""
@@ -42,17 +57,60 @@ func (c *Client) Validate(signedToken ...string) (*License, error) {
 		return &License{}, ErrNoPublicKey
 	}
 
+	if c.cfg.serveStale {
+		if cached, ok := c.staleLicense(token); ok {
+			c.mu.Lock()
+			c.license = cached
+			c.signedToken = token
+			c.mu.Unlock()
+			c.updateGates(cached)
+			go c.revalidate(token, cached)
+			return cached, nil
+		}
+	}
+
+	return c.validate(token)
+}
+
+// staleLicense returns the cached license of token if it may be served while
+// the token is revalidated: it was valid when cached, has not expired, and
+// still passes the offline and policy checks.
+func (c *Client) staleLicense(token string) (*License, bool) {
+	cached, err := c.loadVerifiedCache(token)
+	if err != nil || cached.SignedToken != token || !cached.Valid || cached.IsExpired() {
+		return nil, false
+	}
+	if cached, err = c.checkCached(cached); err != nil {
+		return nil, false
+	}
+	return cached, true
+}
+
+// validate verifies token, applies temporal and policy checks, and stores and
+// caches the resulting license.
+func (c *Client) validate(token string) (*License, error) {
 	// Verify signature
-	payload, err := verifyToken(c.cfg.publicKey, token)
+	payload, err := c.verify(token)
 	if err != nil {
+		if c.cfg.strictPayload && tokenRejected(err) {
+			return &License{}, err
+		}
 		// Attempt cache fallback
-		cached, cacheErr := c.cache.load()
+		cached, cacheErr := c.cacheFallback(token)
 		if cacheErr == nil && cached != nil {
-			return cached, nil
+			return c.checkCached(cached)
 		}
-		return &License{}, err
+		return &License{}, withCacheFallbackError(err, cacheErr)
 	}
 
+	license, policyErr := c.evaluate(payload, token)
+	c.commit(license, token, policyErr)
+	return license, policyErr
+}
+
+// evaluate builds the license for a verified payload and applies temporal
+// and policy checks without changing client state.
+func (c *Client) evaluate(payload *tokenPayload, token string) (*License, error) {
 	license := payloadToLicense(payload, token, true)
 
 	// Temporal checks
@@ -64,37 +122,75 @@ func (c *Client) Validate(signedToken ...string) (*License, error) {
 		license.Valid = false
 	}
 
-	// Update server URL from token if not explicitly set
-	if c.cfg.serverURL == "" && license.ServerURL != "" {
-		c.cfg.serverURL = license.ServerURL
+	// Binding and policy checks
+	policyErr := c.checkPolicy(license)
+	if policyErr == nil {
+		policyErr = c.checkOffline()
+	}
+	if policyErr != nil {
+		license.Valid = false
 	}
+	return license, policyErr
+}
+
+// commit makes license the client's current license, caches it, and runs the
+// validation hooks and auto-renewal check.
+func (c *Client) commit(license *License, token string, policyErr error) {
+	license.Suspended = c.suspended.Load()
 
 	// Store the current license and token
 	c.mu.Lock()
+	// Adopt the server URL from the first token if none was configured.
+	if c.tokenServerURL == "" && license.ServerURL != "" {
+		c.tokenServerURL = license.ServerURL
+	}
 	c.license = license
 	c.signedToken = token
 	c.mu.Unlock()
+	c.updateGates(license)
 
 	// Cache the license
 	_ = c.cache.save(license)
 
+	c.hookValidate(license, policyErr)
+
 	// Trigger auto-renewal if approaching expiry
 	c.maybeAutoRenew(license)
+	c.applyLicenseModel(license)
+}
 
-	return license, nil
+// revalidate runs a full validation in the background after a cached license
+// was served, emitting EventLicenseChanged if the result differs.
+func (c *Client) revalidate(token string, cached *License) {
+	fresh, _ := c.validate(token)
+	if fresh == nil || licensesEqual(cached, fresh) {
+		return
+	}
+	c.emitEvent(Event{Type: EventLicenseChanged, Message: "license changed on revalidation", Data: fresh})
+}
+
+// licensesEqual reports whether two licenses carry the same data.
+func licensesEqual(a, b *License) bool {
+	aj, aErr := json.Marshal(a)
+	bj, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aj, bj)
 }
 
-// ValidateFromCache loads and returns the cached license without network calls
-// or re-verification. Returns nil if no cached license exists.
+// ValidateFromCache loads and returns the cached license without network calls.
+// When a public key is configured, the cached license is checked against its
+// embedded signed token and rejected if it was tampered with. The client's
+// binding and policy rules apply as in Validate.
+// Returns nil if no cached license exists.
 func (c *Client) ValidateFromCache() (*License, error) {
-	if c.closed {
+	if c.closed.Load() {
 		return nil, ErrClientClosed
 	}
 
-	cached, err := c.cache.load()
+	cached, err := c.loadVerifiedCache(c.currentToken())
 	if err != nil {
 		return nil, err
 	}
+	cached, checkErr := c.checkCached(cached)
 
 	c.mu.Lock()
 	c.license = cached
@@ -102,8 +198,9 @@ func (c *Client) ValidateFromCache() (*License, error) {
 		c.signedToken = cached.SignedToken
 	}
 	c.mu.Unlock()
+	c.updateGates(cached)
 
-	return cached, nil
+	return cached, checkErr
 }
 
 // maybeAutoRenew checks if the license is approaching expiry and triggers
@@ -116,24 +213,149 @@ func (c *Client) maybeAutoRenew(license *License) {
 		return
 	}
 
-	threshold := c.cfg.renewBefore
-	if threshold == 0 {
-		threshold = defaultRenewBefore
-	}
-
+	threshold := c.renewThreshold()
 	timeLeft := time.Until(license.ExpiresAt)
 	if timeLeft > threshold {
 		return
 	}
 
+	if c.renewSchedule != nil || len(c.quietHours) > 0 {
+		c.scheduleRenewal(timeLeft)
+		return
+	}
+
 	// Spawn background renewal
-	go func() {
-		result, err := c.Renew()
-		if err != nil {
-			return
-		}
-		if c.cfg.onRenew != nil && result != nil {
-			c.cfg.onRenew(result)
+	go c.autoRenew()
+}
+
+// autoRenew renews the license and reports the result to the callbacks.
+func (c *Client) autoRenew() {
+	result, err := c.Renew()
+	if err != nil {
+		c.hookError(err)
+		return
+	}
+	if c.cfg.onRenew != nil && result != nil {
+		c.safeCall("OnRenew", func() { c.cfg.onRenew(result) })
+	}
+}
+
+// scheduleRenewal arranges a single auto-renewal within the renewal
+// schedule's next window and outside quiet hours, or immediately if the
+// license expires first.
+func (c *Client) scheduleRenewal(timeLeft time.Duration) {
+	c.mu.Lock()
+	if c.renewTimer != nil || c.closed.Load() {
+		c.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	var delay time.Duration
+	if c.renewSchedule != nil {
+		delay = c.renewSchedule.delay(now)
+	}
+	quiet, until := c.quietHours.active(now.Add(delay))
+	if quiet {
+		delay = until.Sub(now)
+	}
+	if delay >= timeLeft {
+		delay, quiet = 0, false
+	}
+	c.renewTimer = time.AfterFunc(delay, func() {
+		c.mu.Lock()
+		c.renewTimer = nil
+		c.mu.Unlock()
+		c.autoRenew()
+	})
+	c.mu.Unlock()
+
+	if quiet {
+		c.emitEvent(Event{Type: EventNetworkDeferred, Message: "auto-renewal deferred until " + until.Format("15:04"), Data: until})
+	}
+}
+
+// verify checks the token against the configured public key and, failing
+// that, any additional keys trusted through a license bundle.
+func (c *Client) verify(token string) (*tokenPayload, error) {
+	payload, err := verifyToken(c.cfg.publicKey, token, c.cfg.strictPayload)
+	if err == nil || len(c.trustedKeys) == 0 {
+		return payload, err
+	}
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) || vErr.Code != InvalidLicenseSignature {
+		return nil, err
+	}
+	for _, key := range c.trustedKeys {
+		if payload, keyErr := verifyToken(key, token, c.cfg.strictPayload); keyErr == nil {
+			return payload, nil
 		}
-	}()
+	}
+	return nil, err
+}
+
+// adoptToken verifies token and, only if it yields a valid license, makes it
+// the current token and license. Unlike Validate it never falls back to the
+// cache, so a bad token cannot be mistaken for a good one.
+func (c *Client) adoptToken(token string) (*License, error) {
+	license, err := c.checkToken(token)
+	if err != nil {
+		return license, err
+	}
+	c.commit(license, token, nil)
+	return license, nil
+}
+
+// checkToken verifies token without a cache fallback and applies the
+// temporal and policy checks, without changing client state.
+func (c *Client) checkToken(token string) (*License, error) {
+	if c.cfg.publicKey == nil {
+		return nil, ErrNoPublicKey
+	}
+
+	payload, err := c.verify(token)
+	if err != nil {
+		return nil, err
+	}
+	license, err := c.evaluate(payload, token)
+	if err != nil {
+		return license, err
+	}
+	now := time.Now()
+	if !payload.IssuedAt.IsZero() && now.Before(payload.IssuedAt) {
+		return license, &ValidationError{Code: LicenseNotValidBefore, Message: "token is not valid yet"}
+	}
+	if !payload.ExpiresAt.IsZero() && now.After(payload.ExpiresAt) {
+		return license, &ValidationError{Code: LicenseNotValidAfter, Message: "token has expired"}
+	}
+	return license, nil
+}
+
+// SetToken replaces the active token while the client is running. The token
+// is verified and checked against the same temporal and policy rules as
+// Validate; if it is not valid, an error is returned and the current license
+// and token are left untouched. On success the new license is cached, a
+// running heartbeat loop is asked to send the new token right away instead of
+// at its next tick, and EventLicenseChanged is emitted if the license differs
+// from the previous one.
+func (c *Client) SetToken(token string) (*License, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if token == "" {
+		return nil, ErrNoToken
+	}
+
+	previous := c.License()
+	license, err := c.adoptToken(token)
+	if err != nil {
+		return license, err
+	}
+
+	c.requestHeartbeat()
+
+	if previous == nil || !licensesEqual(previous, license) {
+		c.emitEvent(Event{Type: EventLicenseChanged, Message: "license token replaced", Data: license})
+	}
+	return license, nil
 }
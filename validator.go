@@ -0,0 +1,122 @@
+package licenseedict
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"time"
+)
+
+// Validator verifies license tokens for backend services. Unlike
+// CheckLicense and Client, it has no side effects: it never reads or writes
+// a cache, resolves XDG directories, or contacts a server. A Validator is
+// safe for concurrent use, so one instance can be shared by all request
+// handlers.
+type Validator struct {
+	pubKey          ed25519.PublicKey
+	now             func() time.Time
+	clockSkew       time.Duration
+	strict          bool
+	expectedProduct string
+}
+
+// ValidatorOption configures a Validator.
+type ValidatorOption func(*Validator)
+
+// WithValidatorClock sets the clock used for temporal checks. It defaults to
+// time.Now; tests can inject a fixed clock.
+func WithValidatorClock(now func() time.Time) ValidatorOption {
+	return func(v *Validator) {
+		v.now = now
+	}
+}
+
+// WithValidatorClockSkew tolerates clock differences of up to d between the
+// issuing server and this host when checking issue and expiry times.
+func WithValidatorClockSkew(d time.Duration) ValidatorOption {
+	return func(v *Validator) {
+		v.clockSkew = d
+	}
+}
+
+// WithValidatorStrict rejects tokens whose payload is missing required
+// fields or has malformed timestamps, as WithStrictPayload does for clients.
+func WithValidatorStrict() ValidatorOption {
+	return func(v *Validator) {
+		v.strict = true
+	}
+}
+
+// WithValidatorProduct rejects tokens issued for any product other than
+// productID. ValidateProduct overrides it per call.
+func WithValidatorProduct(productID string) ValidatorOption {
+	return func(v *Validator) {
+		v.expectedProduct = productID
+	}
+}
+
+// NewValidator returns a Validator for the given base64-encoded public key.
+func NewValidator(publicKey string, opts ...ValidatorOption) (*Validator, error) {
+	if publicKey == "" {
+		return nil, ErrNoPublicKey
+	}
+	pubKey, err := DecodePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewValidatorWithKey(pubKey, opts...), nil
+}
+
+// NewValidatorWithKey is like NewValidator but takes a decoded public key.
+func NewValidatorWithKey(pubKey ed25519.PublicKey, opts ...ValidatorOption) *Validator {
+	v := &Validator{pubKey: pubKey, now: time.Now}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Validate verifies token and checks that it is currently valid.
+//
+// If the signature cannot be verified, it returns a nil License. If the
+// token verifies but fails a temporal or product check, it returns the
+// decoded License with Valid set to false alongside a *ValidationError.
+func (v *Validator) Validate(token string) (*License, error) {
+	return v.ValidateProduct(token, v.expectedProduct)
+}
+
+// ValidateProduct is like Validate but requires the token to have been
+// issued for productID, for services that serve several products. An empty
+// productID skips the product check.
+func (v *Validator) ValidateProduct(token, productID string) (*License, error) {
+	if token == "" {
+		return nil, ErrNoToken
+	}
+	payload, err := verifyToken(v.pubKey, token, v.strict)
+	if err != nil {
+		return nil, err
+	}
+	license := payloadToLicense(payload, token, true)
+
+	now := v.now()
+	switch {
+	case !payload.IssuedAt.IsZero() && now.Add(v.clockSkew).Before(payload.IssuedAt):
+		license.Valid = false
+		return license, &ValidationError{
+			Code:    LicenseNotValidBefore,
+			Message: fmt.Sprintf("license is not valid before %s", payload.IssuedAt.Format(time.RFC3339)),
+		}
+	case !payload.ExpiresAt.IsZero() && now.Add(-v.clockSkew).After(payload.ExpiresAt):
+		license.Valid = false
+		return license, &ValidationError{
+			Code:    LicenseNotValidAfter,
+			Message: fmt.Sprintf("license expired at %s", payload.ExpiresAt.Format(time.RFC3339)),
+		}
+	case productID != "" && license.ProductID != productID:
+		license.Valid = false
+		return license, &ValidationError{
+			Code:    ProductMismatch,
+			Message: fmt.Sprintf("license is for product %q, expected %q", license.ProductID, productID),
+		}
+	}
+	return license, nil
+}